@@ -16,10 +16,35 @@ export function deleteBucket(bucket: string) {
 	return http.delete(`${PFX}/buckets/${encodeURIComponent(bucket)}`)
 }
 
+export function setBucketPublic(bucket: string, isPublic: boolean) {
+	return http.post(`${PFX}/buckets/${encodeURIComponent(bucket)}/public?public=${isPublic}`)
+}
+
 // Objects
+export interface ObjectInfo {
+	key: string
+	size: number
+	content_type: string
+	etag: string
+	last_modified: string
+}
+
+// 一页列举结果；非递归时下一级“文件夹”在 prefixes 中，next_token 为空表示已到末页
+export interface ObjectPage {
+	objects: ObjectInfo[]
+	prefixes: string[]
+	next_token: string
+}
+
+export interface ObjectDetail extends ObjectInfo {
+	metadata: Record<string, string>
+	tags: Record<string, string>
+}
+
 export interface ListObjectParams {
 	prefix?: string
 	recursive?: boolean
+	token?: string
 	limit?: number
 }
 
@@ -27,19 +52,64 @@ export function listObjects(bucket: string, params: ListObjectParams = {}) {
 	const query = new URLSearchParams()
 	if (params.prefix) query.set('prefix', params.prefix)
 	if (params.recursive) query.set('recursive', 'true')
+	if (params.token) query.set('token', params.token)
 	if (params.limit) query.set('limit', String(params.limit))
 	const qs = query.toString()
-	return http.get<string[]>(`${PFX}/buckets/${encodeURIComponent(bucket)}/objects${qs ? '?' + qs : ''}`)
+	return http.get<ObjectPage>(`${PFX}/buckets/${encodeURIComponent(bucket)}/objects${qs ? '?' + qs : ''}`)
+}
+
+// pageKeys 将一页结果展开为名称列表，“文件夹”在前
+export function pageKeys(page?: ObjectPage): string[] {
+	if (!page) return []
+	return [...(page.prefixes || []), ...(page.objects || []).map((o) => o.key)]
+}
+
+// 单个对象的接口通过查询参数 key 传递对象名（可含 /）
+function objectURL(bucket: string, key: string, action = '') {
+	return `${PFX}/buckets/${encodeURIComponent(bucket)}/object${action ? '/' + action : ''}?key=${encodeURIComponent(key)}`
+}
+
+export function getObject(bucket: string, key: string) {
+	return http.get<ObjectDetail>(objectURL(bucket, key))
+}
+
+export function getObjectUrl(bucket: string, key: string, expiry?: number) {
+	return http.get<{ url: string }>(objectURL(bucket, key, 'url') + (expiry ? `&expiry=${expiry}` : ''))
+}
+
+export function updateObjectMetadata(bucket: string, key: string, metadata: Record<string, string>, contentType?: string) {
+	return http.put<ObjectDetail>(objectURL(bucket, key, 'metadata'), { content_type: contentType, metadata })
+}
+
+export function setObjectTags(bucket: string, key: string, tags: Record<string, string>) {
+	return http.put<ObjectDetail>(objectURL(bucket, key, 'tags'), { tags })
 }
 
-export function uploadObject(bucket: string, file: File) {
+// key 以 / 结尾时复制/移动整个“文件夹”；destBucket 为空表示同一 bucket
+export function copyObject(bucket: string, key: string, destKey: string, destBucket?: string) {
+	return http.post<{ objects: number }>(`${PFX}/buckets/${encodeURIComponent(bucket)}/object/copy`, { key, dest_key: destKey, dest_bucket: destBucket })
+}
+
+export function moveObject(bucket: string, key: string, destKey: string, destBucket?: string) {
+	return http.post<{ objects: number }>(`${PFX}/buckets/${encodeURIComponent(bucket)}/object/move`, { key, dest_key: destKey, dest_bucket: destBucket })
+}
+
+export function uploadObject(bucket: string, file: File, prefix = '') {
 	const form = new FormData()
 	form.append('file', file)
+	if (prefix) form.append('prefix', prefix)
 	return http.post<{ url: string; object: string }>(`${PFX}/buckets/${encodeURIComponent(bucket)}/objects`, form, {
 		headers: { 'Content-Type': 'multipart/form-data' },
 	})
 }
 
 export function deleteObject(bucket: string, object: string) {
-	return http.delete(`${PFX}/buckets/${encodeURIComponent(bucket)}/objects/${encodeURIComponent(object)}`)
+	return http.delete(objectURL(bucket, object))
+}
+
+export function deleteObjects(bucket: string, keys: string[]) {
+	return http.post<{ deleted: number; failed: { key: string; error: string }[] }>(
+		`${PFX}/buckets/${encodeURIComponent(bucket)}/objects/delete`,
+		{ keys },
+	)
 }
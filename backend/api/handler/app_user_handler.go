@@ -1,20 +1,17 @@
 package handler
 
 import (
-	"io"
 	"net/http"
-	"path/filepath"
 	"strconv"
-	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 
 	apimodel "alice/api/model"
 	"alice/application"
+	friendentity "alice/domain/appfriend/entity"
 	friendsvc "alice/domain/appfriend/service"
 	appsvc "alice/domain/appuser/service"
-	"alice/infra/config"
+	mediaservice "alice/domain/media/service"
 	"alice/pkg/logger"
 )
 
@@ -23,38 +20,6 @@ type AppUserHandler struct {
 	friendSvc friendsvc.FriendService
 }
 
-// fullAvatarURL 根据存储的 avatar 字段（相对路径或完整 URL）补全最终访问 URL。
-// 规则：
-// 1. 为空直接返回 ""
-// 2. 以 http(s) 开头，视为已经是完整 URL，直接返回（兼容旧数据）
-// 3. 其他情况视为相对路径（建议以 / 开头），拼接 MinIO BaseURL（或 endpoint 推导）
-func (h *AppUserHandler) fullAvatarURL(raw string) string {
-	if raw == "" {
-		return ""
-	}
-	lower := strings.ToLower(raw)
-	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
-		return raw // 旧数据兼容
-	}
-	cfg := config.Load()
-	base := cfg.Minio.BaseURL
-	if base == "" { // fallback 与存储层逻辑保持一致
-		scheme := "http"
-		if cfg.Minio.UseSSL {
-			scheme = "https"
-		}
-		base = scheme + "://" + cfg.Minio.Endpoint
-	}
-	// 处理 /
-	if strings.HasSuffix(base, "/") {
-		base = strings.TrimRight(base, "/")
-	}
-	if !strings.HasPrefix(raw, "/") {
-		raw = "/" + raw
-	}
-	return base + raw
-}
-
 func NewAppUserHandler(svc appsvc.AppUserService, friendSvc friendsvc.FriendService) *AppUserHandler {
 	return &AppUserHandler{svc: svc, friendSvc: friendSvc}
 }
@@ -85,7 +50,7 @@ func (h *AppUserHandler) AppRegister(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, "failed to issue token"))
 		return
 	}
-	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.AppAuthResponse{User: apimodel.AppUserInfo{ID: u.ID, Email: u.Email, Nickname: u.Nickname, Avatar: h.fullAvatarURL(u.Avatar), Gender: u.Gender, Bio: u.Bio}, Token: token}))
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.AppAuthResponse{User: apimodel.AppUserInfo{ID: u.ID, Email: u.Email, Username: u.Username, Nickname: u.Nickname, Avatar: application.URLs.Resolve(u.Avatar), Gender: u.Gender, Bio: u.Bio}, Token: token}))
 }
 
 // AppLogin 移动端登录
@@ -133,7 +98,7 @@ func (h *AppUserHandler) AppProfile(c *gin.Context) {
 		c.JSON(http.StatusNotFound, apimodel.ErrorResponse(apimodel.CodeNotFound, apimodel.MsgUserNotFound))
 		return
 	}
-	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.AppUserInfo{ID: u.ID, Email: u.Email, Nickname: u.Nickname, Avatar: h.fullAvatarURL(u.Avatar), Gender: u.Gender, Bio: u.Bio}))
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.AppUserInfo{ID: u.ID, Email: u.Email, Username: u.Username, Nickname: u.Nickname, Avatar: application.URLs.Resolve(u.Avatar), Gender: u.Gender, Bio: u.Bio}))
 }
 
 // AppUpdateProfile 更新移动端用户资料
@@ -164,11 +129,219 @@ func (h *AppUserHandler) AppUpdateProfile(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
 		return
 	}
-	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.AppUserInfo{ID: u.ID, Email: u.Email, Nickname: u.Nickname, Avatar: h.fullAvatarURL(u.Avatar), Gender: u.Gender, Bio: u.Bio}))
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.AppUserInfo{ID: u.ID, Email: u.Email, Username: u.Username, Nickname: u.Nickname, Avatar: application.URLs.Resolve(u.Avatar), Gender: u.Gender, Bio: u.Bio}))
+}
+
+// friendRequestItems 组装好友申请列表，incoming 决定展示申请人还是接收人资料
+func (h *AppUserHandler) friendRequestItems(reqs []*friendentity.FriendRequest, incoming bool) []apimodel.FriendRequestItem {
+	peerOf := func(r *friendentity.FriendRequest) uint {
+		if incoming {
+			return r.RequesterID
+		}
+		return r.AddresseeID
+	}
+	ids := make([]uint, 0, len(reqs))
+	for _, r := range reqs {
+		ids = append(ids, peerOf(r))
+	}
+	users := map[uint]apimodel.AppUserInfo{}
+	if list, err := h.svc.GetByIDs(ids); err == nil {
+		for _, u := range list {
+			users[u.ID] = apimodel.AppUserInfo{ID: u.ID, Email: u.Email, Username: u.Username, Nickname: u.Nickname, Avatar: application.URLs.Resolve(u.Avatar), Gender: u.Gender, Bio: u.Bio}
+		}
+	}
+	items := make([]apimodel.FriendRequestItem, 0, len(reqs))
+	for _, r := range reqs {
+		item := apimodel.FriendRequestItem{ID: r.ID, RequesterID: r.RequesterID, AddresseeID: r.AddresseeID, User: users[peerOf(r)], Message: r.Message, Source: string(r.Source), Status: string(r.Status), CreatedAt: r.CreatedAt.Unix()}
+		if r.ExpiresAt != nil {
+			item.ExpiresAt = r.ExpiresAt.Unix()
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// AppUpdateHandle 设置唯一 handle
+// @Summary App 设置 handle
+// @Tags App
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body model.AppUpdateHandleRequest true "handle"
+// @Success 200 {object} model.APIResponse{data=model.AppUserInfo}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/profile/handle [put]
+func (h *AppUserHandler) AppUpdateHandle(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	var req apimodel.AppUpdateHandleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
+	}
+	u, err := h.svc.SetHandle(uid, req.Handle)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.AppUserInfo{ID: u.ID, Email: u.Email, Username: u.Username, Nickname: u.Nickname, Avatar: application.URLs.Resolve(u.Avatar), Gender: u.Gender, Bio: u.Bio}))
+}
+
+// AppGetPrivacy 获取可发现性设置
+// @Summary App 隐私设置
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} model.APIResponse{data=model.AppPrivacyInfo}
+// @Failure 401 {object} model.APIResponse
+// @Router /app/profile/privacy [get]
+func (h *AppUserHandler) AppGetPrivacy(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	p, err := h.svc.GetPrivacy(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.AppPrivacyInfo{FindableByEmail: p.FindableByEmail, FindableByHandle: p.FindableByHandle, FindableViaGroups: p.FindableViaGroups}))
+}
+
+// AppUpdatePrivacy 更新可发现性设置
+// @Summary App 更新隐私设置
+// @Tags App
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body model.AppUpdatePrivacyRequest true "隐私设置"
+// @Success 200 {object} model.APIResponse{data=model.AppPrivacyInfo}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/profile/privacy [put]
+func (h *AppUserHandler) AppUpdatePrivacy(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	var req apimodel.AppUpdatePrivacyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
+	}
+	p, err := h.svc.UpdatePrivacy(uid, req.FindableByEmail, req.FindableByHandle, req.FindableViaGroups)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.AppPrivacyInfo{FindableByEmail: p.FindableByEmail, FindableByHandle: p.FindableByHandle, FindableViaGroups: p.FindableViaGroups}))
+}
+
+// SearchUsers 搜索用户
+// @Summary App 搜索用户
+// @Description 按 handle（精确/前缀）、昵称（模糊）或完整邮箱（精确）搜索，遵循对方的可发现性设置
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Param q query string true "关键字（至少 2 个字符）"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页条数"
+// @Success 200 {object} model.APIResponse{data=model.AppUserSearchResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/users/search [get]
+func (h *AppUserHandler) SearchUsers(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	page := 1
+	pageSize := 20
+	if v := c.Query("page"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			page = n
+		}
+	}
+	if v := c.Query("page_size"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 50 {
+			pageSize = n
+		}
+	}
+	results, total, err := h.friendSvc.SearchUsers(uid, c.Query("q"), page, pageSize)
+	if err == friendsvc.ErrQueryTooShort {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	items := make([]apimodel.AppUserSearchItem, 0, len(results))
+	for _, r := range results {
+		u := r.User
+		items = append(items, apimodel.AppUserSearchItem{ID: u.ID, Username: u.Username, Nickname: u.Nickname, Avatar: application.URLs.Resolve(u.Avatar), Gender: u.Gender, Bio: u.Bio, IsFriend: r.IsFriend})
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.AppUserSearchResponse{Items: items, Total: total, Page: page, PageSize: pageSize}))
 }
 
-// RequestFriend 发送好友请求（通过对方邮箱）
+// ListFriendSuggestions 可能认识的人
+// @Summary App 可能认识的人
+// @Description 按共同好友数与共同群数排序，排除已是好友、拉黑及拒绝过的用户
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页条数"
+// @Success 200 {object} model.APIResponse{data=model.FriendSuggestionListResponse}
+// @Failure 401 {object} model.APIResponse
+// @Router /app/friends/suggestions [get]
+func (h *AppUserHandler) ListFriendSuggestions(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	page := 1
+	pageSize := 20
+	if v := c.Query("page"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			page = n
+		}
+	}
+	if v := c.Query("page_size"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 50 {
+			pageSize = n
+		}
+	}
+	list, total, err := h.friendSvc.Suggestions(uid, page, pageSize)
+	if err != nil {
+		logger.Errorf("list friend suggestions failed: %v", err)
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	items := make([]apimodel.FriendSuggestionItem, 0, len(list))
+	for _, s := range list {
+		u := s.User
+		items = append(items, apimodel.FriendSuggestionItem{ID: u.ID, Username: u.Username, Nickname: u.Nickname, Avatar: application.URLs.Resolve(u.Avatar), MutualFriends: s.MutualFriends, SharedGroups: s.SharedGroups, Reason: s.Reason})
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.FriendSuggestionListResponse{Items: items, Total: total, Page: page, PageSize: pageSize}))
+}
+
+// RequestFriend 发送好友请求（通过对方邮箱或用户 ID）
 // @Summary App 发送好友请求
+// @Description 通过 friend_email 或 user_id 指定对方；按 user_id 添加时需遵循对方的可发现性设置
 // @Tags App
 // @Security BearerAuth
 // @Accept json
@@ -190,12 +363,31 @@ func (h *AppUserHandler) RequestFriend(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
 		return
 	}
-	if err := h.friendSvc.RequestFriend(uid, req.FriendEmail); err != nil {
+	var (
+		fr  *friendentity.FriendRequest
+		err error
+	)
+	source := friendentity.FriendRequestSource(req.Source)
+	switch {
+	case req.UserID != 0:
+		fr, err = h.friendSvc.SendRequest(uid, req.UserID, req.Message, source)
+	case req.FriendEmail != "":
+		fr, err = h.friendSvc.RequestFriend(uid, req.FriendEmail, req.Message, source)
+	default:
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "friend_email or user_id is required"))
+		return
+	}
+	if err != nil {
 		logger.Errorf("request friend failed: %v", err)
 		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
 		return
 	}
-	c.JSON(http.StatusOK, apimodel.SuccessResponseWithMessage("request sent", nil))
+	// 对方已向自己发起过申请时直接成为好友
+	if fr.Status == friendentity.FriendRequestAccepted {
+		c.JSON(http.StatusOK, apimodel.SuccessResponseWithMessage("accepted", gin.H{"request_id": fr.ID, "status": fr.Status}))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponseWithMessage("request sent", gin.H{"request_id": fr.ID, "status": fr.Status}))
 }
 
 // ListFriends 好友列表（返回详细资料）
@@ -203,6 +395,7 @@ func (h *AppUserHandler) RequestFriend(c *gin.Context) {
 // @Tags App
 // @Security BearerAuth
 // @Produce json
+// @Param tag_id query int false "按标签筛选"
 // @Param page query int false "页码"
 // @Param page_size query int false "每页条数"
 // @Success 200 {object} model.APIResponse{data=model.FriendDetailListResponse}
@@ -227,14 +420,20 @@ func (h *AppUserHandler) ListFriends(c *gin.Context) {
 			pageSize = n
 		}
 	}
-	users, total, err := h.friendSvc.ListFriendDetails(uid, page, pageSize)
+	tagID, _ := strconv.ParseUint(c.Query("tag_id"), 10, 64)
+	friends, total, err := h.friendSvc.ListFriendDetails(uid, uint(tagID), page, pageSize)
+	if err == friendsvc.ErrTagNotFound {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
 		return
 	}
-	items := make([]apimodel.FriendDetail, 0, len(users))
-	for _, u := range users {
-		items = append(items, apimodel.FriendDetail{ID: u.ID, Email: u.Email, Nickname: u.Nickname, Avatar: h.fullAvatarURL(u.Avatar), Gender: u.Gender, Bio: u.Bio})
+	items := make([]apimodel.FriendDetail, 0, len(friends))
+	for _, f := range friends {
+		u := f.User
+		items = append(items, apimodel.FriendDetail{ID: u.ID, Email: u.Email, Nickname: u.Nickname, Avatar: application.URLs.Resolve(u.Avatar), Gender: u.Gender, Bio: u.Bio, Alias: f.Alias, Notes: f.Notes, TagIDs: f.TagIDs})
 	}
 	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.FriendDetailListResponse{Items: items, Total: total, Page: page, PageSize: pageSize}))
 }
@@ -246,7 +445,7 @@ func (h *AppUserHandler) ListFriends(c *gin.Context) {
 // @Produce json
 // @Param page query int false "页码"
 // @Param page_size query int false "每页条数"
-// @Success 200 {object} model.APIResponse{data=object}
+// @Success 200 {object} model.APIResponse{data=object} "items 为 []model.FriendRequestItem"
 // @Failure 401 {object} model.APIResponse
 // @Router /app/friends/requests [get]
 func (h *AppUserHandler) ListPendingRequests(c *gin.Context) {
@@ -268,12 +467,56 @@ func (h *AppUserHandler) ListPendingRequests(c *gin.Context) {
 			pageSize = n
 		}
 	}
-	reqIDs, requesterIDs, total, err := h.friendSvc.ListPending(uid, page, pageSize)
+	reqs, total, err := h.friendSvc.ListPending(uid, page, pageSize)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
 		return
 	}
-	c.JSON(http.StatusOK, apimodel.SuccessResponse(gin.H{"request_ids": reqIDs, "requester_ids": requesterIDs, "total": total, "page": page, "page_size": pageSize}))
+	// request_ids / requester_ids 保留以兼容旧客户端
+	reqIDs := make([]uint, 0, len(reqs))
+	requesterIDs := make([]uint, 0, len(reqs))
+	for _, r := range reqs {
+		reqIDs = append(reqIDs, r.ID)
+		requesterIDs = append(requesterIDs, r.RequesterID)
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(gin.H{"items": h.friendRequestItems(reqs, true), "request_ids": reqIDs, "requester_ids": requesterIDs, "total": total, "page": page, "page_size": pageSize}))
+}
+
+// ListOutgoingRequests 自己发出的待处理好友请求
+// @Summary App 已发出的好友请求
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页条数"
+// @Success 200 {object} model.APIResponse{data=object} "items 为 []model.FriendRequestItem"
+// @Failure 401 {object} model.APIResponse
+// @Router /app/friends/requests/outgoing [get]
+func (h *AppUserHandler) ListOutgoingRequests(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	page := 1
+	pageSize := 20
+	if v := c.Query("page"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			page = n
+		}
+	}
+	if v := c.Query("page_size"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			pageSize = n
+		}
+	}
+	reqs, total, err := h.friendSvc.ListOutgoing(uid, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(gin.H{"items": h.friendRequestItems(reqs, false), "total": total, "page": page, "page_size": pageSize}))
 }
 
 // AcceptFriendRequest 接受好友请求
@@ -321,7 +564,6 @@ func (h *AppUserHandler) DeclineFriendRequest(c *gin.Context) {
 		return
 	}
 	uid, _ := idAny.(uint)
-	_ = uid // reserved for ownership check
 	ridStr := c.Param("request_id")
 	rid, err := strconv.ParseUint(ridStr, 10, 64)
 	if err != nil {
@@ -335,124 +577,394 @@ func (h *AppUserHandler) DeclineFriendRequest(c *gin.Context) {
 	c.JSON(http.StatusOK, apimodel.SuccessResponseWithMessage("declined", nil))
 }
 
-// AppUploadAvatar 上传并更新头像 (单步：上传文件到对象存储并立即更新用户头像字段)
-// @Summary App 上传头像并更新资料
+// CancelFriendRequest 撤回自己发出的好友请求
+// @Summary App 撤回好友请求
 // @Tags App
 // @Security BearerAuth
-// @Accept multipart/form-data
-// @Produce json
-// @Param file formData file true "头像文件"
-// @Success 200 {object} model.APIResponse{data=model.AppUserInfo}
+// @Param request_id path int true "请求ID"
+// @Success 200 {object} model.APIResponse
 // @Failure 400 {object} model.APIResponse
 // @Failure 401 {object} model.APIResponse
-// @Router /app/profile/avatar [post]
-func (h *AppUserHandler) AppUploadAvatar(c *gin.Context) {
-	// 鉴权
+// @Router /app/friends/requests/{request_id}/cancel [post]
+func (h *AppUserHandler) CancelFriendRequest(c *gin.Context) {
 	idAny, ok := c.Get("app_user_id")
 	if !ok {
 		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
 		return
 	}
 	uid, _ := idAny.(uint)
-
-	if application.ObjectStore == nil {
-		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, "storage not initialized"))
+	rid, err := strconv.ParseUint(c.Param("request_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
 		return
 	}
+	if err := h.friendSvc.CancelRequest(uid, uint(rid)); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponseWithMessage("cancelled", nil))
+}
 
-	file, header, err := c.Request.FormFile("file")
+// RemoveFriend 删除好友（双向解除，并通过 WS 通知对方）
+// @Summary App 删除好友
+// @Tags App
+// @Security BearerAuth
+// @Param friend_id path int true "好友ID"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/friends/{friend_id} [delete]
+func (h *AppUserHandler) RemoveFriend(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	fid, err := strconv.ParseUint(c.Param("friend_id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "missing file"))
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
 		return
 	}
-	defer file.Close()
-	data, err := io.ReadAll(file)
+	if err := h.friendSvc.RemoveFriend(uid, uint(fid)); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponseWithMessage("removed", nil))
+}
+
+// SetFriendRemark 设置好友备注名与描述（仅自己可见）
+// @Summary App 设置好友备注
+// @Tags App
+// @Security BearerAuth
+// @Accept json
+// @Param friend_id path int true "好友ID"
+// @Param request body model.FriendRemarkRequest true "备注"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Router /app/friends/{friend_id}/remark [put]
+func (h *AppUserHandler) SetFriendRemark(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	fid, err := strconv.ParseUint(c.Param("friend_id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, "read file failed"))
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
+	}
+	var req apimodel.FriendRemarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
 		return
 	}
+	if err := h.friendSvc.SetRemark(uid, uint(fid), req.Alias, req.Notes); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(gin.H{"friend_id": fid, "alias": req.Alias, "notes": req.Notes}))
+}
 
-	cfg := config.Load()
-	maxBytes := int64(cfg.Minio.MaxFileSizeMB) * 1024 * 1024
-	if maxBytes > 0 && int64(len(data)) > maxBytes { // 复用全局文件大小限制
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "file too large"))
+// ListFriendTags 好友标签列表
+// @Summary App 好友标签列表
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} model.APIResponse{data=[]model.FriendTagItem}
+// @Router /app/friends/tags [get]
+func (h *AppUserHandler) ListFriendTags(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
 		return
 	}
+	uid, _ := idAny.(uint)
+	tags, err := h.friendSvc.ListTags(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	items := make([]apimodel.FriendTagItem, 0, len(tags))
+	for _, t := range tags {
+		items = append(items, toFriendTagItem(t))
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(items))
+}
 
-	contentType := header.Header.Get("Content-Type")
-	if contentType == "" {
-		// 简单推断：根据扩展名
-		ext := strings.ToLower(filepath.Ext(header.Filename))
-		switch ext {
-		case ".jpg", ".jpeg":
-			contentType = "image/jpeg"
-		case ".png":
-			contentType = "image/png"
-		case ".gif":
-			contentType = "image/gif"
-		default:
-			contentType = "application/octet-stream"
-		}
+// CreateFriendTag 创建好友标签
+// @Summary App 创建好友标签
+// @Tags App
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body model.FriendTagRequest true "标签"
+// @Success 200 {object} model.APIResponse{data=model.FriendTagItem}
+// @Failure 400 {object} model.APIResponse
+// @Router /app/friends/tags [post]
+func (h *AppUserHandler) CreateFriendTag(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
 	}
+	uid, _ := idAny.(uint)
+	var req apimodel.FriendTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
+	}
+	t, err := h.friendSvc.CreateTag(uid, req.Name, req.FriendIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(toFriendTagItem(t)))
+}
 
-	// 仅允许图片类型
-	if !strings.HasPrefix(strings.ToLower(contentType), "image/") {
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "only image allowed"))
+// UpdateFriendTag 重命名好友标签
+// @Summary App 重命名好友标签
+// @Tags App
+// @Security BearerAuth
+// @Accept json
+// @Param tag_id path int true "标签ID"
+// @Param request body model.FriendTagRequest true "标签"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Router /app/friends/tags/{tag_id} [put]
+func (h *AppUserHandler) UpdateFriendTag(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	tid, err := strconv.ParseUint(c.Param("tag_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
 		return
 	}
-	// 若配置了 AllowedMIMEs 进一步校验
-	if !appMimeAllowed(cfg.Minio.AllowedMIMEs, contentType) {
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "mime not allowed"))
+	var req apimodel.FriendTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
 		return
 	}
+	t, err := h.friendSvc.RenameTag(uid, uint(tid), req.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(gin.H{"id": t.ID, "name": t.Name}))
+}
 
-	// 生成对象名：avatar-{uid}-{timestamp}{ext}
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if len(ext) > 10 { // 防止异常过长
-		ext = ""
+// DeleteFriendTag 删除好友标签（不影响好友关系）
+// @Summary App 删除好友标签
+// @Tags App
+// @Security BearerAuth
+// @Param tag_id path int true "标签ID"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Router /app/friends/tags/{tag_id} [delete]
+func (h *AppUserHandler) DeleteFriendTag(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	tid, err := strconv.ParseUint(c.Param("tag_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
+	}
+	if err := h.friendSvc.DeleteTag(uid, uint(tid)); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
 	}
-	objectName := "avatar-" + strconv.FormatUint(uint64(uid), 10) + "-" + strconv.FormatInt(time.Now().Unix(), 10) + ext
-	bucket := "app-avatars" // 固定 bucket，必要时可放配置
+	c.JSON(http.StatusOK, apimodel.SuccessResponseWithMessage("deleted", nil))
+}
 
-	_, err = application.ObjectStore.PutObject(c.Request.Context(), bucket, objectName, data, contentType)
+// SetFriendTagMembers 设置标签成员（整体替换，非好友会被忽略）
+// @Summary App 设置好友标签成员
+// @Tags App
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param tag_id path int true "标签ID"
+// @Param request body model.FriendTagMembersRequest true "成员"
+// @Success 200 {object} model.APIResponse{data=model.FriendTagItem}
+// @Failure 400 {object} model.APIResponse
+// @Router /app/friends/tags/{tag_id}/members [put]
+func (h *AppUserHandler) SetFriendTagMembers(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	tid, err := strconv.ParseUint(c.Param("tag_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
+	}
+	var req apimodel.FriendTagMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
+	}
+	t, err := h.friendSvc.SetTagMembers(uid, uint(tid), req.FriendIDs)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
 		return
 	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(toFriendTagItem(t)))
+}
 
-	// 更新用户头像
-	// 数据库存储相对路径：/bucket/object
-	relativePath := "/" + bucket + "/" + objectName
-	u, err := h.svc.UpdateProfile(uid, "", relativePath, "", "")
+func toFriendTagItem(t *friendsvc.TagDetail) apimodel.FriendTagItem {
+	return apimodel.FriendTagItem{ID: t.Tag.ID, Name: t.Tag.Name, FriendIDs: t.FriendIDs, CreatedAt: t.Tag.CreatedAt.Unix()}
+}
+
+// BlockUser 拉黑用户（对方无感知）
+// @Summary App 拉黑用户
+// @Tags App
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body model.BlockUserRequest true "被拉黑用户"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/blocks [post]
+func (h *AppUserHandler) BlockUser(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	var req apimodel.BlockUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
+	}
+	if err := h.friendSvc.Block(uid, req.UserID); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponseWithMessage("blocked", nil))
+}
+
+// UnblockUser 取消拉黑
+// @Summary App 取消拉黑
+// @Tags App
+// @Security BearerAuth
+// @Param user_id path int true "用户ID"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/blocks/{user_id} [delete]
+func (h *AppUserHandler) UnblockUser(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	target, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, "update profile failed"))
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
+	}
+	if err := h.friendSvc.Unblock(uid, uint(target)); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
 		return
 	}
-	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.AppUserInfo{ID: u.ID, Email: u.Email, Nickname: u.Nickname, Avatar: h.fullAvatarURL(u.Avatar), Gender: u.Gender, Bio: u.Bio}))
+	c.JSON(http.StatusOK, apimodel.SuccessResponseWithMessage("unblocked", nil))
 }
 
-// appMimeAllowed 与存储 handler 类似：支持 * / 前缀 / 精确；为空表示不限制
-func appMimeAllowed(allowed []string, ct string) bool {
-	if len(allowed) == 0 {
-		return true
+// ListBlocked 黑名单列表
+// @Summary App 黑名单列表
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页条数"
+// @Success 200 {object} model.APIResponse{data=model.FriendDetailListResponse}
+// @Failure 401 {object} model.APIResponse
+// @Router /app/blocks [get]
+func (h *AppUserHandler) ListBlocked(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
 	}
-	ct = strings.ToLower(ct)
-	for _, a := range allowed {
-		a = strings.ToLower(strings.TrimSpace(a))
-		if a == "" {
-			continue
-		}
-		if a == "*" {
-			return true
+	uid, _ := idAny.(uint)
+	page := 1
+	pageSize := 20
+	if v := c.Query("page"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			page = n
 		}
-		if strings.HasSuffix(a, "/*") {
-			prefix := strings.TrimSuffix(a, "/*") + "/"
-			if strings.HasPrefix(ct, prefix) {
-				return true
-			}
-		} else if a == ct {
-			return true
+	}
+	if v := c.Query("page_size"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			pageSize = n
 		}
 	}
-	return false
+	users, total, err := h.friendSvc.ListBlocked(uid, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	items := make([]apimodel.FriendDetail, 0, len(users))
+	for _, u := range users {
+		items = append(items, apimodel.FriendDetail{ID: u.ID, Email: u.Email, Nickname: u.Nickname, Avatar: application.URLs.Resolve(u.Avatar), Gender: u.Gender, Bio: u.Bio})
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.FriendDetailListResponse{Items: items, Total: total, Page: page, PageSize: pageSize}))
+}
+
+// AppUploadAvatar 上传并更新头像 (单步：上传文件到对象存储并立即更新用户头像字段)
+// @Summary App 上传头像并更新资料
+// @Tags App
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "头像文件"
+// @Success 200 {object} model.APIResponse{data=model.AppUserInfo}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/profile/avatar [post]
+func (h *AppUserHandler) AppUploadAvatar(c *gin.Context) {
+	// 鉴权
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "missing file"))
+		return
+	}
+	defer file.Close()
+	// 按内容判断真实类型（不信任客户端 Content-Type/扩展名），去除 EXIF/GPS 等元数据并生成衍生图
+	up, err := application.UploadSvc.Upload(c.Request.Context(), mediaservice.UploadRequest{
+		Profile: "avatar", OwnerID: uid, Reader: file, Size: header.Size, Filename: header.Filename, ContentType: header.Header.Get("Content-Type"),
+	})
+	if err != nil {
+		uploadError(c, err)
+		return
+	}
+
+	// 更新用户头像
+	u, err := h.svc.UpdateProfile(uid, "", up.Path, "", "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, "update profile failed"))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.AppUserInfo{ID: u.ID, Email: u.Email, Username: u.Username, Nickname: u.Nickname, Avatar: application.URLs.Resolve(u.Avatar), Gender: u.Gender, Bio: u.Bio,
+		AvatarThumb: application.URLs.Resolve(up.Variants["thumb"]), AvatarMedium: application.URLs.Resolve(up.Variants["medium"])}))
 }
@@ -1,18 +1,16 @@
 package chat
 
 import (
-	"io"
 	"net/http"
-	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	apimodel "alice/api/model"
 	"alice/application"
-	"alice/infra/config"
+	mediaentity "alice/domain/media/entity"
+	mediaservice "alice/domain/media/service"
 )
 
 type GroupHandler struct{}
@@ -110,41 +108,23 @@ func (h *GroupHandler) GroupMessages(c *gin.Context) {
 		ids = append(ids, id)
 	}
 	users, _ := application.AppUserSvc.GetByIDs(ids)
-	cfg := config.Load()
-	full := func(raw string) string {
-		if raw == "" {
-			return ""
-		}
-		lw := strings.ToLower(raw)
-		if strings.HasPrefix(lw, "http://") || strings.HasPrefix(lw, "https://") {
-			return raw
-		}
-		base := cfg.Minio.BaseURL
-		if base == "" {
-			scheme := "http"
-			if cfg.Minio.UseSSL {
-				scheme = "https"
-			}
-			base = scheme + "://" + cfg.Minio.Endpoint
-		}
-		if strings.HasSuffix(base, "/") {
-			base = strings.TrimRight(base, "/")
-		}
-		if !strings.HasPrefix(raw, "/") {
-			raw = "/" + raw
-		}
-		return base + raw
-	}
 	userMap := make(map[uint]gin.H, len(users))
 	for _, u := range users {
 		if u != nil {
-			userMap[u.ID] = gin.H{"id": u.ID, "nickname": u.Nickname, "avatar": full(u.Avatar)}
+			userMap[u.ID] = gin.H{"id": u.ID, "nickname": u.Nickname, "avatar": application.URLs.Resolve(u.Avatar)}
+		}
+	}
+	msgIDs := make([]uint, 0, len(msgs))
+	for _, m := range msgs {
+		if m != nil {
+			msgIDs = append(msgIDs, m.ID)
 		}
 	}
+	media := messageMedia(mediaentity.RefGroupMessage, msgIDs)
 	enriched := make([]gin.H, 0, len(msgs))
 	for _, m := range msgs {
 		if m != nil {
-			enriched = append(enriched, gin.H{"id": m.ID, "group_id": m.GroupID, "sender_id": m.SenderID, "type": m.Type, "content": m.Content, "created_at": m.CreatedAt, "sender": userMap[m.SenderID]})
+			enriched = append(enriched, gin.H{"id": m.ID, "group_id": m.GroupID, "sender_id": m.SenderID, "type": m.Type, "content": m.Content, "created_at": m.CreatedAt, "recalled_at": m.RecalledAt, "sender": userMap[m.SenderID], "mentions": m.MentionIDs(), "media": media[m.ID]})
 		}
 	}
 	c.JSON(http.StatusOK, apimodel.SuccessResponse(gin.H{"items": enriched, "total": total, "page": page, "page_size": pageSize}))
@@ -209,68 +189,22 @@ func (h *GroupHandler) UploadGroupAvatar(c *gin.Context) {
 		return
 	}
 	defer file.Close()
-	data, err := io.ReadAll(file)
+	// 按内容判断真实类型，去除 EXIF/GPS 等元数据并生成衍生图
+	up, err := application.UploadSvc.Upload(c.Request.Context(), mediaservice.UploadRequest{
+		Profile: "group-avatar", OwnerID: uint(gid64), Reader: file, Size: header.Size, Filename: header.Filename, ContentType: header.Header.Get("Content-Type"),
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, "read failed"))
-		return
-	}
-	cfg := config.Load()
-	maxBytes := int64(cfg.Minio.MaxFileSizeMB) * 1024 * 1024
-	if maxBytes > 0 && int64(len(data)) > maxBytes {
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "file too large"))
-		return
-	}
-	contentType := header.Header.Get("Content-Type")
-	if !strings.HasPrefix(strings.ToLower(contentType), "image/") {
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "only image allowed"))
-		return
-	}
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if len(ext) > 10 {
-		ext = ""
-	}
-	if application.ObjectStore == nil {
-		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, "storage not initialized"))
-		return
-	}
-	objectName := "group-avatar-" + strconv.FormatUint(gid64, 10) + "-" + strconv.FormatInt(time.Now().UnixNano(), 10) + ext
-	bucket := "app-group-avatars"
-	if _, err = application.ObjectStore.PutObject(c.Request.Context(), bucket, objectName, data, contentType); err != nil {
-		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, err.Error()))
+		uploadError(c, err)
 		return
 	}
-	relative := "/" + bucket + "/" + objectName
+	relative := up.Path
 	// 保存
 	if _, err = application.GroupSvc.UpdateGroup(uid, uint(gid64), "", relative); err != nil {
 		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, err.Error()))
 		return
 	}
-	full := func(raw string) string {
-		if raw == "" {
-			return ""
-		}
-		lw := strings.ToLower(raw)
-		if strings.HasPrefix(lw, "http://") || strings.HasPrefix(lw, "https://") {
-			return raw
-		}
-		cfg2 := config.Load()
-		base := cfg2.Minio.BaseURL
-		if base == "" {
-			scheme := "http"
-			if cfg2.Minio.UseSSL {
-				scheme = "https"
-			}
-			base = scheme + "://" + cfg2.Minio.Endpoint
-		}
-		if strings.HasSuffix(base, "/") {
-			base = strings.TrimRight(base, "/")
-		}
-		if !strings.HasPrefix(raw, "/") {
-			raw = "/" + raw
-		}
-		return base + raw
-	}
-	c.JSON(http.StatusOK, apimodel.SuccessResponse(gin.H{"path": relative, "url": full(relative)}))
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(gin.H{"path": relative, "url": application.URLs.Resolve(relative),
+		"thumb_url": application.URLs.Resolve(up.Variants["thumb"]), "medium_url": application.URLs.Resolve(up.Variants["medium"])}))
 }
 
 // ListMembers 返回群成员基础信息
@@ -296,33 +230,8 @@ func (h *GroupHandler) ListMembers(c *gin.Context) {
 	// 复用 app user service 获取用户
 	users, _ := application.AppUserSvc.GetByIDs(ids)
 	out := make([]gin.H, 0, len(users))
-	cfg := config.Load()
-	full := func(raw string) string {
-		if raw == "" {
-			return ""
-		}
-		lw := strings.ToLower(raw)
-		if strings.HasPrefix(lw, "http://") || strings.HasPrefix(lw, "https://") {
-			return raw
-		}
-		base := cfg.Minio.BaseURL
-		if base == "" {
-			scheme := "http"
-			if cfg.Minio.UseSSL {
-				scheme = "https"
-			}
-			base = scheme + "://" + cfg.Minio.Endpoint
-		}
-		if strings.HasSuffix(base, "/") {
-			base = strings.TrimRight(base, "/")
-		}
-		if !strings.HasPrefix(raw, "/") {
-			raw = "/" + raw
-		}
-		return base + raw
-	}
 	for _, u := range users {
-		out = append(out, gin.H{"id": u.ID, "nickname": u.Nickname, "avatar": full(u.Avatar)})
+		out = append(out, gin.H{"id": u.ID, "nickname": u.Nickname, "avatar": application.URLs.Resolve(u.Avatar)})
 	}
 	c.JSON(http.StatusOK, apimodel.SuccessResponse(gin.H{"members": out}))
 }
@@ -0,0 +1,119 @@
+package chat
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	apimodel "alice/api/model"
+	"alice/application"
+	mediaentity "alice/domain/media/entity"
+	mediaservice "alice/domain/media/service"
+)
+
+// mediaPayload 附件的响应结构，与 model.MediaItem 字段一致；聊天媒体位于私有 bucket，URL 为短时有效的预签名地址，
+// 需长期缓存地址的客户端使用 GET /app/chat/media/{id} 换取
+func mediaPayload(a *mediaentity.Attachment) gin.H {
+	if a == nil {
+		return nil
+	}
+	h := gin.H{"id": a.ID, "url": application.URLs.Resolve(a.Path), "path": a.Path, "mime": a.Mime, "width": a.Width, "height": a.Height, "size": a.Size, "blurhash": a.Blurhash}
+	if a.ThumbPath != "" {
+		h["thumb_url"] = application.URLs.Resolve(a.ThumbPath)
+	}
+	if a.MediumPath != "" {
+		h["medium_url"] = application.URLs.Resolve(a.MediumPath)
+	}
+	if a.DurationMs > 0 {
+		h["duration_ms"] = a.DurationMs
+	}
+	if a.PosterPath != "" {
+		h["poster_url"] = application.URLs.Resolve(a.PosterPath)
+	}
+	return h
+}
+
+// messageMedia 批量读取消息附件（每条图片/视频消息一个），返回消息 ID -> 附件
+func messageMedia(refType mediaentity.RefType, ids []uint) map[uint]gin.H {
+	out := make(map[uint]gin.H, len(ids))
+	if len(ids) == 0 {
+		return out
+	}
+	byRef, _ := application.MediaSvc.ListByRefs(refType, ids)
+	for id, list := range byRef {
+		if len(list) > 0 {
+			out[id] = mediaPayload(list[0])
+		}
+	}
+	return out
+}
+
+// Media 聊天媒体跳转：校验当前用户是会话参与者后 302 到新签发的预签名地址，供缓存了地址的客户端使用
+// @Summary App 获取聊天媒体
+// @Description variant 可选 thumb / medium / poster，默认原文件；仅私聊双方、群成员或上传者本人（尚未发送）可访问
+// @Tags App
+// @Security BearerAuth
+// @Param media_id path int true "附件ID"
+// @Param variant query string false "衍生图"
+// @Success 302
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /app/chat/media/{media_id} [get]
+func (h *Hub) Media(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	uid, _ := idAny.(uint)
+	id, err := strconv.ParseUint(c.Param("media_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
+	}
+	a, err := application.MediaSvc.Get(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	if a == nil || a.Kind != mediaentity.KindChat {
+		c.JSON(http.StatusNotFound, apimodel.ErrorResponse(apimodel.CodeNotFound, "media not found"))
+		return
+	}
+	if !h.chat.CanViewMedia(uid, a) {
+		c.JSON(http.StatusForbidden, apimodel.ErrorResponse(apimodel.CodeForbidden, "no permission to view this media"))
+		return
+	}
+	path := a.Path
+	switch c.Query("variant") {
+	case "thumb":
+		path = a.ThumbPath
+	case "medium":
+		path = a.MediumPath
+	case "poster":
+		path = a.PosterPath
+	}
+	url := application.URLs.Resolve(path)
+	if url == "" {
+		c.JSON(http.StatusNotFound, apimodel.ErrorResponse(apimodel.CodeNotFound, "media not found"))
+		return
+	}
+	// 跳转地址很快过期，不允许缓存跳转本身
+	c.Header("Cache-Control", "private, no-store")
+	c.Redirect(http.StatusFound, url)
+}
+
+// uploadError 上传失败的响应：存储未初始化为 500，超出存储配额为 403，其余（大小、类型、处理失败等）为 400
+func uploadError(c *gin.Context, err error) {
+	if errors.Is(err, mediaservice.ErrStorageUnavailable) || errors.Is(err, mediaservice.ErrScanUnavailable) {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, err.Error()))
+		return
+	}
+	if errors.Is(err, mediaservice.ErrQuotaExceeded) {
+		c.JSON(http.StatusForbidden, apimodel.ErrorResponse(apimodel.CodeForbidden, err.Error()))
+		return
+	}
+	c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+}
@@ -0,0 +1,84 @@
+package chat
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	apimodel "alice/api/model"
+	"alice/application"
+	chatservice "alice/domain/chat/service"
+)
+
+// Recall 撤回私聊消息（仅发送方），通知对方与发送方的其它在线设备
+func (h *Hub) Recall(c *gin.Context) {
+	uid, err := getAppUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	id, err := parseUintParam(c, "message_id")
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "invalid message id"))
+		return
+	}
+	m, err := h.chat.Recall(uid, id)
+	if err != nil {
+		recallError(c, err)
+		return
+	}
+	event := gin.H{"id": m.ID, "sender_id": m.SenderID, "receiver_id": m.ReceiverID, "recalled_at": m.RecalledAt}
+	application.Realtime.Emit(m.SenderID, chatservice.EventMessageRecalled, event)
+	if !m.Dropped {
+		application.Realtime.Emit(m.ReceiverID, chatservice.EventMessageRecalled, event)
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(h.enrichSingleMessage(m)))
+}
+
+// RecallGroupMessage 撤回群消息（仅发送方），通知群内全部成员
+func (h *GroupHandler) RecallGroupMessage(c *gin.Context) {
+	uid, err := getAppUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	gid, err := parseUintParam(c, "group_id")
+	if err != nil || gid == 0 {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "invalid group id"))
+		return
+	}
+	id, err := parseUintParam(c, "message_id")
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "invalid message id"))
+		return
+	}
+	// 先确认消息属于路径中的群，避免借其它群的 ID 探测
+	if m, err := application.GroupSvc.GetMessage(id); err != nil || m == nil || m.GroupID != gid {
+		recallError(c, chatservice.ErrMessageNotFound)
+		return
+	}
+	m, err := application.GroupSvc.RecallMessage(uid, id)
+	if err != nil {
+		recallError(c, err)
+		return
+	}
+	event := gin.H{"id": m.ID, "group_id": m.GroupID, "sender_id": m.SenderID, "recalled_at": m.RecalledAt}
+	memberIDs, _ := application.GroupSvc.ListMemberIDs(m.GroupID)
+	for _, mid := range memberIDs {
+		application.Realtime.Emit(mid, chatservice.EventGroupMessageRecalled, event)
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(gin.H{"id": m.ID, "group_id": m.GroupID, "sender_id": m.SenderID, "type": m.Type, "content": m.Content, "created_at": m.CreatedAt, "recalled_at": m.RecalledAt, "mentions": m.MentionIDs()}))
+}
+
+// recallError 消息不存在（或不可见）为 404，非发送方为 403
+func recallError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, chatservice.ErrMessageNotFound):
+		c.JSON(http.StatusNotFound, apimodel.ErrorResponse(apimodel.CodeNotFound, err.Error()))
+	case errors.Is(err, chatservice.ErrNotSender):
+		c.JSON(http.StatusForbidden, apimodel.ErrorResponse(apimodel.CodeForbidden, err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+	}
+}
@@ -2,12 +2,9 @@ package chat
 
 import (
 	"fmt"
-	"io"
 	"net/http"
-	"path/filepath"
 	"sort"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
@@ -20,7 +17,8 @@ import (
 	appuserservice "alice/domain/appuser/service"
 	chatentity "alice/domain/chat/entity"
 	chatservice "alice/domain/chat/service"
-	"alice/infra/config"
+	mediaentity "alice/domain/media/entity"
+	mediaservice "alice/domain/media/service"
 	"alice/pkg/logger"
 )
 
@@ -28,17 +26,40 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool { return true },
 }
 
+// wsConn gorilla websocket 不支持并发写，聊天转发与业务事件推送会在不同 goroutine 中写同一连接
+type wsConn struct {
+	*websocket.Conn
+	wmu sync.Mutex
+}
+
+func (c *wsConn) WriteJSON(v any) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return c.Conn.WriteJSON(v)
+}
+
 // Hub 管理用户连接与转发
 type Hub struct {
 	mu        sync.RWMutex
-	conns     map[uint]*websocket.Conn
+	conns     map[uint]*wsConn
 	chat      chatservice.ChatService
 	appUserSv appuserservice.AppUserService
 	// group service via application package (quick access)
 }
 
 func NewHub(s chatservice.ChatService, appUserSv appuserservice.AppUserService) *Hub {
-	return &Hub{conns: make(map[uint]*websocket.Conn), chat: s, appUserSv: appUserSv}
+	return &Hub{conns: make(map[uint]*wsConn), chat: s, appUserSv: appUserSv}
+}
+
+// Deliver 实现 realtime.Sink：向在线用户推送消息
+func (h *Hub) Deliver(userID uint, msg any) bool {
+	h.mu.RLock()
+	conn := h.conns[userID]
+	h.mu.RUnlock()
+	if conn == nil {
+		return false
+	}
+	return conn.WriteJSON(msg) == nil
 }
 
 // WS 处理 WebSocket 连接
@@ -48,29 +69,33 @@ func (h *Hub) WS(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, "unauthorized"))
 		return
 	}
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	raw, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		logger.Errorf("ws upgrade failed: %v", err)
 		return
 	}
+	conn := &wsConn{Conn: raw}
 	// 注册连接
 	h.mu.Lock()
 	h.conns[uid] = conn
 	h.mu.Unlock()
 	defer func() {
 		h.mu.Lock()
-		delete(h.conns, uid)
+		if h.conns[uid] == conn { // 同一用户重连时不要删掉新连接
+			delete(h.conns, uid)
+		}
 		h.mu.Unlock()
 		_ = conn.Close()
 	}()
 
 	for {
 		var payload struct {
-			Type    string `json:"type"`
-			To      uint   `json:"to"`
-			GroupID uint   `json:"group_id"`
-			Content string `json:"content"`
-			MsgType string `json:"msg_type"`
+			Type     string `json:"type"`
+			To       uint   `json:"to"`
+			GroupID  uint   `json:"group_id"`
+			Content  string `json:"content"`
+			MsgType  string `json:"msg_type"`
+			Mentions []uint `json:"mentions"`
 		}
 		if err := conn.ReadJSON(&payload); err != nil {
 			logger.Infof("ws read closed: %v", err)
@@ -78,7 +103,7 @@ func (h *Hub) WS(c *gin.Context) {
 		}
 		// 群聊消息
 		if payload.GroupID > 0 {
-			gm, err := application.GroupSvc.SendMessage(payload.GroupID, uid, payload.MsgType, payload.Content)
+			gm, err := application.GroupSvc.SendMessage(payload.GroupID, uid, payload.MsgType, payload.Content, payload.Mentions)
 			if err != nil {
 				_ = conn.WriteJSON(gin.H{"error": err.Error()})
 				continue
@@ -98,25 +123,17 @@ func (h *Hub) WS(c *gin.Context) {
 				"message_type": gm.Type,
 				"created_at":   gm.CreatedAt,
 				"sender":       sender,
+				"mentions":     gm.MentionIDs(),
+				"media":        messageMedia(mediaentity.RefGroupMessage, []uint{gm.ID})[gm.ID],
 			}
-			// 只推送给群成员在线连接
+			// 自己先回显，其余成员经 Realtime 投递（不在线的成员转离线推送）
+			_ = conn.WriteJSON(resp)
 			memberIDs, _ := application.GroupSvc.ListMemberIDs(gm.GroupID)
-			memberSet := make(map[uint]struct{}, len(memberIDs))
 			for _, mid := range memberIDs {
-				memberSet[mid] = struct{}{}
-			}
-			// 自己先回显
-			_ = conn.WriteJSON(resp)
-			h.mu.RLock()
-			for mid, c2 := range h.conns {
-				if mid == uid { // 已回显
-					continue
-				}
-				if _, ok := memberSet[mid]; ok {
-					_ = c2.WriteJSON(resp)
+				if mid != uid {
+					application.Realtime.Send(mid, resp)
 				}
 			}
-			h.mu.RUnlock()
 			continue
 		}
 		// 私聊消息
@@ -127,12 +144,10 @@ func (h *Hub) WS(c *gin.Context) {
 		}
 		enriched := h.enrichSingleMessage(msg)
 		_ = conn.WriteJSON(enriched)
-		h.mu.RLock()
-		peer := h.conns[payload.To]
-		h.mu.RUnlock()
-		if peer != nil {
-			_ = peer.WriteJSON(enriched)
+		if msg.Dropped { // 对方已拉黑：仅回显给发送方
+			continue
 		}
+		application.Realtime.Send(payload.To, enriched)
 	}
 }
 
@@ -181,33 +196,8 @@ func (h *Hub) Conversations(c *gin.Context) {
 	}
 	users, _ := h.appUserSv.GetByIDs(peerIDs)
 	userMap := make(map[uint]gin.H, len(users))
-	cfg := config.Load()
-	full := func(raw string) string {
-		if raw == "" {
-			return ""
-		}
-		lw := strings.ToLower(raw)
-		if strings.HasPrefix(lw, "http://") || strings.HasPrefix(lw, "https://") {
-			return raw
-		}
-		base := cfg.Minio.BaseURL
-		if base == "" {
-			scheme := "http"
-			if cfg.Minio.UseSSL {
-				scheme = "https"
-			}
-			base = scheme + "://" + cfg.Minio.Endpoint
-		}
-		if strings.HasSuffix(base, "/") {
-			base = strings.TrimRight(base, "/")
-		}
-		if !strings.HasPrefix(raw, "/") {
-			raw = "/" + raw
-		}
-		return base + raw
-	}
 	for _, u := range users {
-		userMap[u.ID] = gin.H{"id": u.ID, "nickname": u.Nickname, "avatar": full(u.Avatar)}
+		userMap[u.ID] = gin.H{"id": u.ID, "nickname": u.Nickname, "avatar": application.URLs.Resolve(u.Avatar)}
 	}
 	type convoItem struct {
 		Data gin.H
@@ -271,6 +261,7 @@ func (h *Hub) enrichSingleMessage(m *chatentity.Message) gin.H {
 	}
 	users, _ := h.appUserSv.GetByIDs([]uint{m.SenderID, m.ReceiverID})
 	userMap := h.userInfoMap(users)
+	media := messageMedia(mediaentity.RefMessage, []uint{m.ID})
 	return gin.H{
 		"id":          m.ID,
 		"sender_id":   m.SenderID,
@@ -280,8 +271,10 @@ func (h *Hub) enrichSingleMessage(m *chatentity.Message) gin.H {
 		"is_read":     m.IsRead,
 		"read_at":     m.ReadAt,
 		"created_at":  m.CreatedAt,
+		"recalled_at": m.RecalledAt,
 		"sender":      userMap[m.SenderID],
 		"receiver":    userMap[m.ReceiverID],
+		"media":       media[m.ID],
 	}
 }
 
@@ -304,6 +297,13 @@ func (h *Hub) enrichMessages(items []*chatentity.Message) []gin.H {
 	}
 	users, _ := h.appUserSv.GetByIDs(ids)
 	userMap := h.userInfoMap(users)
+	msgIDs := make([]uint, 0, len(items))
+	for _, m := range items {
+		if m != nil {
+			msgIDs = append(msgIDs, m.ID)
+		}
+	}
+	media := messageMedia(mediaentity.RefMessage, msgIDs)
 	out := make([]gin.H, 0, len(items))
 	for _, m := range items { // 不再反转
 		if m == nil {
@@ -318,8 +318,10 @@ func (h *Hub) enrichMessages(items []*chatentity.Message) []gin.H {
 			"is_read":     m.IsRead,
 			"read_at":     m.ReadAt,
 			"created_at":  m.CreatedAt,
+			"recalled_at": m.RecalledAt,
 			"sender":      userMap[m.SenderID],
 			"receiver":    userMap[m.ReceiverID],
+			"media":       media[m.ID],
 		})
 	}
 	return out
@@ -330,36 +332,11 @@ func (h *Hub) userInfoMap(users []*appentity.AppUser) map[uint]gin.H {
 	if len(users) == 0 {
 		return m
 	}
-	cfg := config.Load()
-	full := func(raw string) string {
-		if raw == "" {
-			return ""
-		}
-		lw := strings.ToLower(raw)
-		if strings.HasPrefix(lw, "http://") || strings.HasPrefix(lw, "https://") {
-			return raw
-		}
-		base := cfg.Minio.BaseURL
-		if base == "" {
-			scheme := "http"
-			if cfg.Minio.UseSSL {
-				scheme = "https"
-			}
-			base = scheme + "://" + cfg.Minio.Endpoint
-		}
-		if strings.HasSuffix(base, "/") {
-			base = strings.TrimRight(base, "/")
-		}
-		if !strings.HasPrefix(raw, "/") {
-			raw = "/" + raw
-		}
-		return base + raw
-	}
 	for _, u := range users {
 		if u == nil {
 			continue
 		}
-		m[u.ID] = gin.H{"id": u.ID, "nickname": u.Nickname, "avatar": full(u.Avatar)}
+		m[u.ID] = gin.H{"id": u.ID, "nickname": u.Nickname, "avatar": application.URLs.Resolve(u.Avatar)}
 	}
 	return m
 }
@@ -404,75 +381,25 @@ func (h *Hub) UploadImage(c *gin.Context) {
 		return
 	}
 	uid, _ := idAny.(uint)
-	if application.ObjectStore == nil {
-		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, "storage not initialized"))
-		return
-	}
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
 		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "missing file"))
 		return
 	}
 	defer file.Close()
-	data, err := io.ReadAll(file)
+	up, err := application.UploadSvc.Upload(c.Request.Context(), mediaservice.UploadRequest{
+		Profile: "chat-image", OwnerID: uid, Reader: file, Size: header.Size, Filename: header.Filename, ContentType: header.Header.Get("Content-Type"),
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, "read file failed"))
-		return
-	}
-	cfg := config.Load()
-	maxBytes := int64(cfg.Minio.MaxFileSizeMB) * 1024 * 1024
-	if maxBytes > 0 && int64(len(data)) > maxBytes {
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "file too large"))
+		uploadError(c, err)
 		return
 	}
-	contentType := header.Header.Get("Content-Type")
-	if !strings.HasPrefix(strings.ToLower(contentType), "image/") {
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "only image allowed"))
+	att := up.Attachment(uid)
+	if err := application.MediaSvc.Register(att, up.Image); err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
 		return
 	}
-	if !appMimeAllowed(cfg.Minio.AllowedMIMEs, contentType) { // 复用 app 头像/动态校验逻辑
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "mime not allowed"))
-		return
-	}
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if len(ext) > 10 {
-		ext = ""
-	}
-	objectName := "chat-" + strconv.FormatUint(uint64(uid), 10) + "-" + strconv.FormatInt(time.Now().UnixNano(), 10) + ext
-	bucket := "app-chat-images"
-	_, err = application.ObjectStore.PutObject(c.Request.Context(), bucket, objectName, data, contentType)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
-		return
-	}
-	relative := "/" + bucket + "/" + objectName
-	// 使用与 Conversations 中相同的 full() 逻辑构造完整 URL
-	fullURL := func(raw string) string {
-		if raw == "" {
-			return ""
-		}
-		lw := strings.ToLower(raw)
-		if strings.HasPrefix(lw, "http://") || strings.HasPrefix(lw, "https://") {
-			return raw
-		}
-		cfg2 := config.Load()
-		base := cfg2.Minio.BaseURL
-		if base == "" {
-			scheme := "http"
-			if cfg2.Minio.UseSSL {
-				scheme = "https"
-			}
-			base = scheme + "://" + cfg2.Minio.Endpoint
-		}
-		if strings.HasSuffix(base, "/") {
-			base = strings.TrimRight(base, "/")
-		}
-		if !strings.HasPrefix(raw, "/") {
-			raw = "/" + raw
-		}
-		return base + raw
-	}
-	c.JSON(http.StatusOK, apimodel.SuccessResponse(gin.H{"path": relative, "url": fullURL(relative)}))
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(mediaPayload(att)))
 }
 
 // UploadVideo 聊天视频上传（仅允许 video mime）
@@ -483,74 +410,25 @@ func (h *Hub) UploadVideo(c *gin.Context) {
 		return
 	}
 	uid, _ := idAny.(uint)
-	if application.ObjectStore == nil {
-		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, "storage not initialized"))
-		return
-	}
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
 		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "missing file"))
 		return
 	}
 	defer file.Close()
-	data, err := io.ReadAll(file)
+	up, err := application.UploadSvc.Upload(c.Request.Context(), mediaservice.UploadRequest{
+		Profile: "chat-video", OwnerID: uid, Reader: file, Size: header.Size, Filename: header.Filename, ContentType: header.Header.Get("Content-Type"),
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, "read file failed"))
-		return
-	}
-	cfg := config.Load()
-	maxBytes := int64(cfg.Minio.MaxFileSizeMB) * 1024 * 1024
-	if maxBytes > 0 && int64(len(data)) > maxBytes {
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "file too large"))
-		return
-	}
-	contentType := header.Header.Get("Content-Type")
-	if !strings.HasPrefix(strings.ToLower(contentType), "video/") {
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "only video allowed"))
-		return
-	}
-	if !appMimeAllowed(cfg.Minio.AllowedMIMEs, contentType) { // 复用校验逻辑
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "mime not allowed"))
+		uploadError(c, err)
 		return
 	}
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if len(ext) > 10 {
-		ext = ""
-	}
-	objectName := "chat-video-" + strconv.FormatUint(uint64(uid), 10) + "-" + strconv.FormatInt(time.Now().UnixNano(), 10) + ext
-	bucket := "app-chat-videos"
-	_, err = application.ObjectStore.PutObject(c.Request.Context(), bucket, objectName, data, contentType)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+	att := up.Attachment(uid)
+	if err := application.MediaSvc.Register(att, up.Image); err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
 		return
 	}
-	relative := "/" + bucket + "/" + objectName
-	fullURL := func(raw string) string {
-		if raw == "" {
-			return ""
-		}
-		lw := strings.ToLower(raw)
-		if strings.HasPrefix(lw, "http://") || strings.HasPrefix(lw, "https://") {
-			return raw
-		}
-		cfg2 := config.Load()
-		base := cfg2.Minio.BaseURL
-		if base == "" {
-			scheme := "http"
-			if cfg2.Minio.UseSSL {
-				scheme = "https"
-			}
-			base = scheme + "://" + cfg2.Minio.Endpoint
-		}
-		if strings.HasSuffix(base, "/") {
-			base = strings.TrimRight(base, "/")
-		}
-		if !strings.HasPrefix(raw, "/") {
-			raw = "/" + raw
-		}
-		return base + raw
-	}
-	c.JSON(http.StatusOK, apimodel.SuccessResponse(gin.H{"path": relative, "url": fullURL(relative)}))
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(mediaPayload(att)))
 }
 
 func parseUintParam(c *gin.Context, name string) (uint, error) {
@@ -588,29 +466,3 @@ func getAppUserID(c *gin.Context) (uint, error) {
 	}
 	return 0, fmt.Errorf("invalid app_user_id type")
 }
-
-// appMimeAllowed 复制自 app_user_handler，保持一致
-func appMimeAllowed(allowed []string, ct string) bool {
-	if len(allowed) == 0 {
-		return true
-	}
-	ct = strings.ToLower(ct)
-	for _, a := range allowed {
-		a = strings.ToLower(strings.TrimSpace(a))
-		if a == "" {
-			continue
-		}
-		if a == "*" {
-			return true
-		}
-		if strings.HasSuffix(a, "/*") {
-			prefix := strings.TrimSuffix(a, "/*") + "/"
-			if strings.HasPrefix(ct, prefix) {
-				return true
-			}
-		} else if a == ct {
-			return true
-		}
-	}
-	return false
-}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"alice/api/model"
+	"alice/application"
+	"alice/infra/storage"
+)
+
+// FileHandler local / memory 存储驱动的对象访问：公共读 bucket 直接下载，其余须携带 HMAC 签名链接；
+// 同时接收预签名 PUT 与表单 POST 直传。MinIO 驱动下对象由 MinIO 直接提供，这些路由返回 404
+type FileHandler struct{}
+
+func NewFileHandler() *FileHandler { return &FileHandler{} }
+
+// linkServer 当前存储支持由本服务提供对象访问时返回 true
+func linkServer(c *gin.Context) (storage.LinkServer, bool) {
+	ls, ok := application.ObjectStore.(storage.LinkServer)
+	if !ok {
+		c.JSON(http.StatusNotFound, model.ErrorResponse(model.CodeNotFound, "file serving not enabled"))
+		return nil, false
+	}
+	return ls, true
+}
+
+func objectParams(c *gin.Context) (string, string) {
+	return c.Param("bucket"), strings.TrimPrefix(c.Param("object"), "/")
+}
+
+// linkError 签名无效或过期为 403
+func linkError(c *gin.Context, err error) {
+	c.JSON(http.StatusForbidden, model.ErrorResponse(model.CodeForbidden, err.Error()))
+}
+
+// Get 下载对象（支持 Range 与 HEAD）
+func (h *FileHandler) Get(c *gin.Context) {
+	ls, ok := linkServer(c)
+	if !ok {
+		return
+	}
+	bucket, object := objectParams(c)
+	if !ls.IsPublic(bucket) {
+		if _, err := ls.Links().Verify(http.MethodGet, bucket, object, c.Request.URL.Query()); err != nil {
+			linkError(c, err)
+			return
+		}
+	}
+	info, err := application.ObjectStore.StatObject(c.Request.Context(), bucket, object)
+	if err != nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse(model.CodeNotFound, storage.ErrObjectNotFound.Error()))
+		return
+	}
+	obj, size, err := application.ObjectStore.GetObject(c.Request.Context(), bucket, object)
+	if err != nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse(model.CodeNotFound, storage.ErrObjectNotFound.Error()))
+		return
+	}
+	defer obj.Close()
+	if info.ContentType != "" {
+		c.Header("Content-Type", info.ContentType)
+	}
+	if info.ETag != "" {
+		c.Header("ETag", `"`+info.ETag+`"`)
+	}
+	http.ServeContent(c.Writer, c.Request, path.Base(object), info.LastModified, io.NewSectionReader(obj, 0, size))
+}
+
+// Put 预签名 PUT 直传：请求的 Content-Type 与 Content-Length 须与签名一致
+func (h *FileHandler) Put(c *gin.Context) {
+	ls, ok := linkServer(c)
+	if !ok {
+		return
+	}
+	bucket, object := objectParams(c)
+	constraints, err := ls.Links().Verify(http.MethodPut, bucket, object, c.Request.URL.Query())
+	if err != nil {
+		linkError(c, err)
+		return
+	}
+	ct := c.GetHeader("Content-Type")
+	if want := constraints.Get("content-type"); want != "" && want != ct {
+		c.JSON(http.StatusForbidden, model.ErrorResponse(model.CodeForbidden, "content type does not match signed link"))
+		return
+	}
+	if want := constraints.Get("content-length"); want != "" && want != strconv.FormatInt(c.Request.ContentLength, 10) {
+		c.JSON(http.StatusForbidden, model.ErrorResponse(model.CodeForbidden, "content length does not match signed link"))
+		return
+	}
+	if _, err := application.ObjectStore.PutObject(c.Request.Context(), bucket, object, c.Request.Body, c.Request.ContentLength, ct); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// Post 表单直传：字段由 PresignedPostPolicy 签发，文件字段为 file
+func (h *FileHandler) Post(c *gin.Context) {
+	ls, ok := linkServer(c)
+	if !ok {
+		return
+	}
+	bucket := c.Param("bucket")
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, "missing file"))
+		return
+	}
+	defer file.Close()
+	form := map[string]string{}
+	for _, k := range []string{storage.FormKey, storage.FormContentType, storage.FormMaxSize, storage.FormExpires, storage.FormSignature} {
+		form[k] = c.PostForm(k)
+	}
+	object, ct, max, err := ls.Links().VerifyPost(bucket, form)
+	if err != nil {
+		linkError(c, err)
+		return
+	}
+	if header.Size <= 0 || header.Size > max {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, "file size not allowed by policy"))
+		return
+	}
+	if _, err := application.ObjectStore.PutObject(c.Request.Context(), bucket, object, file, header.Size, ct); err != nil {
+		if errors.Is(err, storage.ErrInvalidObject) || errors.Is(err, storage.ErrInvalidBucket) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, err.Error()))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"alice/api/model"
+	modentity "alice/domain/moderation/entity"
+	modsvc "alice/domain/moderation/service"
+	"alice/pkg/logger"
+)
+
+// ModerationHandler 内容审核（敏感词 + 待审队列）后台接口
+type ModerationHandler struct {
+	svc modsvc.ModerationService
+}
+
+// NewModerationHandler 创建内容审核处理器
+func NewModerationHandler(svc modsvc.ModerationService) *ModerationHandler {
+	return &ModerationHandler{svc: svc}
+}
+
+// ListWords 敏感词列表
+// @Summary 敏感词列表
+// @Tags Moderation
+// @Security BearerAuth
+// @Produce json
+// @Param keyword query string false "关键字"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} model.APIResponse{data=model.PageResponse}
+// @Router /moderation/words [get]
+func (h *ModerationHandler) ListWords(c *gin.Context) {
+	page, pageSize := pageParams(c)
+	list, total, err := h.svc.ListWords(c.Query("keyword"), page, pageSize)
+	if err != nil {
+		logger.Errorf("获取敏感词列表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(model.PageResponse{Items: list, Total: total, Page: page, PageSize: pageSize}))
+}
+
+// AddWords 批量添加敏感词（已存在的词更新动作/分类）
+// @Summary 添加敏感词
+// @Tags Moderation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body model.AddSensitiveWordsRequest true "敏感词"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Router /moderation/words [post]
+func (h *ModerationHandler) AddWords(c *gin.Context) {
+	var req model.AddSensitiveWordsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, "请求参数格式错误"))
+		return
+	}
+	n, err := h.svc.AddWords(req.Words, modentity.Action(req.Action), req.Category)
+	if err != nil {
+		logger.Errorf("添加敏感词失败: %v", err)
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(gin.H{"added": n}))
+}
+
+// UpdateWord 更新敏感词
+// @Summary 更新敏感词
+// @Tags Moderation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "敏感词ID"
+// @Param request body model.UpdateSensitiveWordRequest true "更新内容"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Router /moderation/words/{id} [put]
+func (h *ModerationHandler) UpdateWord(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req model.UpdateSensitiveWordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, "请求参数格式错误"))
+		return
+	}
+	w, err := h.svc.UpdateWord(id, modentity.Action(req.Action), req.Category, req.Enabled)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(w))
+}
+
+// DeleteWord 删除敏感词
+// @Summary 删除敏感词
+// @Tags Moderation
+// @Security BearerAuth
+// @Param id path int true "敏感词ID"
+// @Success 200 {object} model.APIResponse
+// @Router /moderation/words/{id} [delete]
+func (h *ModerationHandler) DeleteWord(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteWord(id); err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponseWithMessage("deleted", nil))
+}
+
+// ReloadWords 立即重新加载词库（词库文件修改后可手动触发）
+// @Summary 重新加载敏感词库
+// @Tags Moderation
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse
+// @Router /moderation/words/reload [post]
+func (h *ModerationHandler) ReloadWords(c *gin.Context) {
+	if err := h.svc.Reload(); err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponseWithMessage("reloaded", nil))
+}
+
+// ListFlags 待审内容队列
+// @Summary 待审内容列表
+// @Tags Moderation
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "状态 pending/approved/rejected/blocked"
+// @Param scene query string false "场景 chat_message/group_message/group_name/nickname/bio/moment/comment"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} model.APIResponse{data=model.PageResponse}
+// @Router /moderation/flags [get]
+func (h *ModerationHandler) ListFlags(c *gin.Context) {
+	page, pageSize := pageParams(c)
+	list, total, err := h.svc.ListFlags(modentity.FlagStatus(c.Query("status")), modentity.Scene(c.Query("scene")), page, pageSize)
+	if err != nil {
+		logger.Errorf("获取待审内容失败: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(model.PageResponse{Items: list, Total: total, Page: page, PageSize: pageSize}))
+}
+
+// ReviewFlag 复核待审内容
+// @Summary 复核待审内容
+// @Tags Moderation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "待审记录ID"
+// @Param request body model.ReviewFlagRequest true "复核结果"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Router /moderation/flags/{id}/review [post]
+func (h *ModerationHandler) ReviewFlag(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req model.ReviewFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, "请求参数格式错误"))
+		return
+	}
+	f, err := h.svc.ReviewFlag(operatorID(c), id, modentity.FlagStatus(req.Status), req.Note)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(f))
+}
+
+// pageParams 读取 page / page_size 查询参数
+func pageParams(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return page, pageSize
+}
+
+// parseIDParam 解析路径中的数字 ID，失败时直接写 400 响应
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	v, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || v == 0 {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, model.MsgInvalidRequest))
+		return 0, false
+	}
+	return uint(v), true
+}
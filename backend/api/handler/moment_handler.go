@@ -3,14 +3,12 @@ package handler
 import (
 	apimodel "alice/api/model"
 	"alice/application"
+	mediaentity "alice/domain/media/entity"
+	mediaservice "alice/domain/media/service"
+	momententity "alice/domain/moment/entity"
 	momentservice "alice/domain/moment/service"
-	"alice/infra/config"
-	"io"
 	"net/http"
-	"path/filepath"
 	"strconv"
-	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -44,28 +42,119 @@ func (h *MomentHandler) PostMoment(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "invalid request"))
 		return
 	}
-	m, err := h.svc.Publish(uid, req.Content, req.Images)
+	audience := req.UserIDs
+	if len(req.TagIDs) > 0 {
+		tagged, err := application.FriendSvc.ExpandTags(uid, req.TagIDs)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+			return
+		}
+		audience = append(audience, tagged...)
+	}
+	m, err := h.svc.Publish(uid, req.Content, req.Images, req.Video, momententity.Visibility(req.Visibility), audience)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
 		return
 	}
-	u, _ := application.AppUserSvc.GetByID(uid)
-	// 补全图片 URL（安卓需完整 http(s) 才能显示）
-	imgs := m.ParseImages()
-	if len(imgs) > 0 {
-		fullImgs := make([]string, 0, len(imgs))
-		for _, p := range imgs {
-			fullImgs = append(fullImgs, fullAvatarURL(p))
+	item := h.momentItems([]*momententity.Moment{m}, uid)[0]
+	item.AudienceIDs, _ = h.svc.ListAudience(uid, m.ID)
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(item))
+}
+
+// momentItems 组装动态列表条目（作者资料、完整图片 URL、点赞状态）
+func (h *MomentHandler) momentItems(list []*momententity.Moment, viewerID uint) []apimodel.MomentItem {
+	items := make([]apimodel.MomentItem, 0, len(list))
+	ids := make([]uint, 0, len(list))
+	for _, m := range list {
+		ids = append(ids, m.ID)
+	}
+	media, _ := application.MediaSvc.ListByRefs(mediaentity.RefMoment, ids)
+	for _, m := range list {
+		u, _ := application.AppUserSvc.GetByID(m.UserID)
+		// 补全图片 URL（安卓需完整 http(s) 才能显示）；没有附件记录的旧数据按路径兜底
+		var mediaItems []apimodel.MediaItem
+		if atts := media[m.ID]; len(atts) > 0 {
+			mediaItems = mediaItemsOf(atts)
+		} else {
+			for _, p := range m.ParseImages() {
+				mediaItems = append(mediaItems, apimodel.MediaItem{URL: application.URLs.Resolve(p), Path: p})
+			}
 		}
-		imgs = fullImgs
+		// 视频动态：视频单独返回，其余仍为图片
+		var video *apimodel.MediaItem
+		if m.Video != "" {
+			rest := make([]apimodel.MediaItem, 0, len(mediaItems))
+			for i := range mediaItems {
+				if video == nil && mediaItems[i].Path == m.Video {
+					video = &mediaItems[i]
+				} else {
+					rest = append(rest, mediaItems[i])
+				}
+			}
+			mediaItems = rest
+			if video == nil {
+				video = &apimodel.MediaItem{URL: application.URLs.Resolve(m.Video), Path: m.Video}
+			}
+		}
+		imgs := make([]string, 0, len(mediaItems))
+		for _, it := range mediaItems {
+			imgs = append(imgs, it.URL)
+		}
+		if mediaItems == nil {
+			mediaItems = []apimodel.MediaItem{}
+		}
+		likeCnt, _ := h.svc.CountLikes(m.ID)
+		liked, _ := h.svc.HasLiked(viewerID, m.ID)
+		items = append(items, apimodel.MomentItem{ID: m.ID, UserID: m.UserID, Nickname: u.Nickname, Avatar: application.URLs.Resolve(u.Avatar), Content: m.Content, Images: imgs, Media: mediaItems, Video: video, CreatedAt: m.CreatedAt.Unix(), LikeCount: likeCnt, Liked: liked, Visibility: string(m.Visibility)})
 	}
-	likeCnt, _ := h.svc.CountLikes(m.ID)
-	item := apimodel.MomentItem{ID: m.ID, UserID: m.UserID, Nickname: u.Nickname, Avatar: fullAvatarURL(u.Avatar), Content: m.Content, Images: imgs, CreatedAt: m.CreatedAt.Unix(), LikeCount: likeCnt, Liked: false}
-	c.JSON(http.StatusOK, apimodel.SuccessResponse(item))
+	return items
+}
+
+// mediaItemsOf 附件转为响应结构（补全 URL）
+func mediaItemsOf(list []*mediaentity.Attachment) []apimodel.MediaItem {
+	out := make([]apimodel.MediaItem, 0, len(list))
+	for _, a := range list {
+		out = append(out, apimodel.MediaItem{URL: application.URLs.Resolve(a.Path), Path: a.Path, Mime: a.Mime, Width: a.Width, Height: a.Height, Size: a.Size, Blurhash: a.Blurhash,
+			ThumbURL: application.URLs.Resolve(a.ThumbPath), MediumURL: application.URLs.Resolve(a.MediumPath), DurationMs: a.DurationMs, PosterURL: application.URLs.Resolve(a.PosterPath)})
+	}
+	return out
+}
+
+// ListMoments 好友时间线（自己与好友的动态，时间倒序，游标分页）
+// @Summary App 好友时间线
+// @Description 首次请求不传 cursor，之后传上一页返回的 next_cursor；has_more=false 表示没有更多
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Param cursor query int false "游标（上一页最后一条动态 ID）"
+// @Param limit query int false "条数，默认 20，最大 100"
+// @Success 200 {object} model.APIResponse{data=model.MomentTimelineResponse}
+// @Failure 401 {object} model.APIResponse
+// @Router /app/moments [get]
+func (h *MomentHandler) ListMoments(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	currentUID, _ := idAny.(uint)
+	limit := 20
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+	cursor, _ := strconv.ParseUint(c.Query("cursor"), 10, 64)
+	list, next, err := h.svc.Timeline(currentUID, uint(cursor), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.MomentTimelineResponse{Items: h.momentItems(list, currentUID), NextCursor: next, HasMore: next != 0}))
 }
 
-// ListMoments 全部动态（时间倒序）
-// @Summary App 动态列表
+// ListPublicMoments 动态广场（所有对当前用户可见的动态，时间倒序）
+// @Summary App 动态广场
 // @Tags App
 // @Security BearerAuth
 // @Produce json
@@ -73,13 +162,14 @@ func (h *MomentHandler) PostMoment(c *gin.Context) {
 // @Param page_size query int false "每页"
 // @Success 200 {object} model.APIResponse{data=model.MomentListResponse}
 // @Failure 401 {object} model.APIResponse
-// @Router /app/moments [get]
-func (h *MomentHandler) ListMoments(c *gin.Context) {
-	_, ok := c.Get("app_user_id")
+// @Router /app/moments/public [get]
+func (h *MomentHandler) ListPublicMoments(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
 	if !ok {
 		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
 		return
 	}
+	currentUID, _ := idAny.(uint)
 	page, pageSize := 1, 20
 	if v := c.Query("page"); v != "" {
 		if p, err := strconv.Atoi(v); err == nil {
@@ -91,29 +181,12 @@ func (h *MomentHandler) ListMoments(c *gin.Context) {
 			pageSize = ps
 		}
 	}
-	list, total, err := h.svc.ListAll(page, pageSize)
+	list, total, err := h.svc.ListAll(currentUID, page, pageSize)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
 		return
 	}
-	items := make([]apimodel.MomentItem, 0, len(list))
-	idAny, _ := c.Get("app_user_id")
-	currentUID, _ := idAny.(uint)
-	for _, m := range list {
-		u, _ := application.AppUserSvc.GetByID(m.UserID)
-		imgs := m.ParseImages()
-		if len(imgs) > 0 {
-			fullImgs := make([]string, 0, len(imgs))
-			for _, p := range imgs {
-				fullImgs = append(fullImgs, fullAvatarURL(p))
-			}
-			imgs = fullImgs
-		}
-		likeCnt, _ := h.svc.CountLikes(m.ID)
-		liked, _ := h.svc.HasLiked(currentUID, m.ID)
-		items = append(items, apimodel.MomentItem{ID: m.ID, UserID: m.UserID, Nickname: u.Nickname, Avatar: fullAvatarURL(u.Avatar), Content: m.Content, Images: imgs, CreatedAt: m.CreatedAt.Unix(), LikeCount: likeCnt, Liked: liked})
-	}
-	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.MomentListResponse{Items: items, Total: total, Page: page, PageSize: pageSize}))
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.MomentListResponse{Items: h.momentItems(list, currentUID), Total: total, Page: page, PageSize: pageSize}))
 }
 
 // ListUserMoments 查看某个用户的动态
@@ -150,29 +223,59 @@ func (h *MomentHandler) ListUserMoments(c *gin.Context) {
 			pageSize = ps
 		}
 	}
-	list, total, err := h.svc.ListByUser(uint(uid64), page, pageSize)
+	idAny, _ := c.Get("app_user_id")
+	currentUID, _ := idAny.(uint)
+	list, total, err := h.svc.ListByUser(currentUID, uint(uid64), page, pageSize)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
 		return
 	}
-	items := make([]apimodel.MomentItem, 0, len(list))
-	idAny, _ := c.Get("app_user_id")
+	items := h.momentItems(list, currentUID)
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.MomentListResponse{Items: items, Total: total, Page: page, PageSize: pageSize}))
+}
+
+// GetMoment 动态详情
+// @Summary App 动态详情
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Param moment_id path int true "动态ID"
+// @Success 200 {object} model.APIResponse{data=model.MomentItem}
+// @Failure 404 {object} model.APIResponse
+// @Router /app/moments/{moment_id} [get]
+func (h *MomentHandler) GetMoment(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
 	currentUID, _ := idAny.(uint)
-	for _, m := range list {
-		u, _ := application.AppUserSvc.GetByID(m.UserID)
-		imgs := m.ParseImages()
-		if len(imgs) > 0 {
-			fullImgs := make([]string, 0, len(imgs))
-			for _, p := range imgs {
-				fullImgs = append(fullImgs, fullAvatarURL(p))
-			}
-			imgs = fullImgs
+	mid, err := strconv.ParseUint(c.Param("moment_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
+	}
+	m, err := h.svc.Get(currentUID, uint(mid))
+	if err != nil {
+		c.JSON(http.StatusNotFound, apimodel.ErrorResponse(apimodel.CodeNotFound, err.Error()))
+		return
+	}
+	u, _ := application.AppUserSvc.GetByID(m.UserID)
+	imgs := m.ParseImages()
+	if len(imgs) > 0 {
+		fullImgs := make([]string, 0, len(imgs))
+		for _, p := range imgs {
+			fullImgs = append(fullImgs, application.URLs.Resolve(p))
 		}
-		likeCnt, _ := h.svc.CountLikes(m.ID)
-		liked, _ := h.svc.HasLiked(currentUID, m.ID)
-		items = append(items, apimodel.MomentItem{ID: m.ID, UserID: m.UserID, Nickname: u.Nickname, Avatar: fullAvatarURL(u.Avatar), Content: m.Content, Images: imgs, CreatedAt: m.CreatedAt.Unix(), LikeCount: likeCnt, Liked: liked})
+		imgs = fullImgs
 	}
-	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.MomentListResponse{Items: items, Total: total, Page: page, PageSize: pageSize}))
+	likeCnt, _ := h.svc.CountLikes(m.ID)
+	liked, _ := h.svc.HasLiked(currentUID, m.ID)
+	item := apimodel.MomentItem{ID: m.ID, UserID: m.UserID, Nickname: u.Nickname, Avatar: application.URLs.Resolve(u.Avatar), Content: m.Content, Images: imgs, CreatedAt: m.CreatedAt.Unix(), LikeCount: likeCnt, Liked: liked, Visibility: string(m.Visibility)}
+	if m.UserID == currentUID {
+		item.AudienceIDs, _ = h.svc.ListAudience(currentUID, m.ID)
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(item))
 }
 
 // UploadImage 上传动态图片（单文件）
@@ -182,7 +285,7 @@ func (h *MomentHandler) ListUserMoments(c *gin.Context) {
 // @Accept multipart/form-data
 // @Produce json
 // @Param file formData file true "图片文件"
-// @Success 200 {object} model.APIResponse{data=object}
+// @Success 200 {object} model.APIResponse{data=model.MediaItem}
 // @Failure 400 {object} model.APIResponse
 // @Failure 401 {object} model.APIResponse
 // @Router /app/moments/images [post]
@@ -193,52 +296,74 @@ func (h *MomentHandler) UploadImage(c *gin.Context) {
 		return
 	}
 	uid, _ := idAny.(uint)
-	if application.ObjectStore == nil {
-		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, "storage not initialized"))
-		return
-	}
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
 		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "missing file"))
 		return
 	}
 	defer file.Close()
-	data, err := io.ReadAll(file)
+	// 按内容判断真实类型，去除 EXIF/GPS 等元数据并生成衍生图
+	up, err := application.UploadSvc.Upload(c.Request.Context(), mediaservice.UploadRequest{
+		Profile: "moment-image", OwnerID: uid, Reader: file, Size: header.Size, Filename: header.Filename, ContentType: header.Header.Get("Content-Type"),
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, "read file failed"))
+		uploadError(c, err)
 		return
 	}
-
-	cfg := config.Load()
-	maxBytes := int64(cfg.Minio.MaxFileSizeMB) * 1024 * 1024
-	if maxBytes > 0 && int64(len(data)) > maxBytes {
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "file too large"))
+	att := up.Attachment(uid)
+	if err := application.MediaSvc.Register(att, up.Image); err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
 		return
 	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(mediaItemsOf([]*mediaentity.Attachment{att})[0]))
+}
 
-	contentType := header.Header.Get("Content-Type")
-	if !strings.HasPrefix(strings.ToLower(contentType), "image/") {
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "only image allowed"))
+// UploadVideo 上传动态视频（一条动态仅一个视频），需同时上传客户端截取的封面图
+// @Summary App 上传动态视频
+// @Description 仅支持 MP4/MOV，服务端解析时长与尺寸；返回的 path 用于发布动态的 video 字段，客户端应先展示 poster_url 再拉流
+// @Tags App
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "视频文件"
+// @Param poster formData file true "封面图"
+// @Success 200 {object} model.APIResponse{data=model.MediaItem}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/moments/videos [post]
+func (h *MomentHandler) UploadVideo(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	video, videoHeader, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "missing file"))
 		return
 	}
-	if !appMimeAllowed(cfg.Minio.AllowedMIMEs, contentType) {
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "mime not allowed"))
+	defer video.Close()
+	poster, posterHeader, err := c.Request.FormFile("poster")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "missing poster"))
 		return
 	}
+	defer poster.Close()
 
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if len(ext) > 10 {
-		ext = ""
-	}
-	objectName := "moment-" + strconv.FormatUint(uint64(uid), 10) + "-" + strconv.FormatInt(time.Now().UnixNano(), 10) + ext
-	bucket := "app-moment-images"
-	_, err = application.ObjectStore.PutObject(c.Request.Context(), bucket, objectName, data, contentType)
+	// 视频：解析容器头获取真实类型、时长与尺寸；封面按场景配置的封面场景处理（去元数据、生成衍生图）
+	up, err := application.UploadSvc.Upload(c.Request.Context(), mediaservice.UploadRequest{
+		Profile: "moment-video", OwnerID: uid, Reader: video, Size: videoHeader.Size, Filename: videoHeader.Filename, ContentType: videoHeader.Header.Get("Content-Type"),
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		uploadError(c, err)
+		return
+	}
+	att, ok := registerUpload(c, uid, up, poster, posterHeader)
+	if !ok {
 		return
 	}
-	relative := "/" + bucket + "/" + objectName
-	c.JSON(http.StatusOK, apimodel.SuccessResponse(gin.H{"path": relative, "url": fullAvatarURL(relative)}))
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(mediaItemsOf([]*mediaentity.Attachment{att})[0]))
 }
 
 // DeleteMoment 删除自己的动态
@@ -324,7 +449,7 @@ func (h *MomentHandler) UnlikeMoment(c *gin.Context) {
 	c.JSON(http.StatusOK, apimodel.SuccessResponseWithMessage("unliked", nil))
 }
 
-// AddComment 评论
+// AddComment 评论（reply_to_id 非 0 时为回复评论）
 // @Summary App 评论动态
 // @Tags App
 // @Security BearerAuth
@@ -351,17 +476,15 @@ func (h *MomentHandler) AddComment(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
 		return
 	}
-	cmt, err := h.svc.AddComment(uid, uint(mid), req.Content)
+	cmt, err := h.svc.AddComment(uid, uint(mid), req.ReplyToID, req.Content)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
 		return
 	}
-	u, _ := application.AppUserSvc.GetByID(uid)
-	item := apimodel.MomentCommentItem{ID: cmt.ID, MomentID: cmt.MomentID, UserID: cmt.UserID, Nickname: u.Nickname, Avatar: fullAvatarURL(u.Avatar), Content: cmt.Content, CreatedAt: cmt.CreatedAt.Unix()}
-	c.JSON(http.StatusOK, apimodel.SuccessResponse(item))
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(commentItem(cmt)))
 }
 
-// ListComments 列出评论
+// ListComments 列出顶层评论（附带回复预览），仅返回自己与好友的评论（动态作者可见全部）
 // @Summary App 动态评论列表
 // @Tags App
 // @Security BearerAuth
@@ -393,42 +516,115 @@ func (h *MomentHandler) ListComments(c *gin.Context) {
 			pageSize = ps
 		}
 	}
-	list, total, err := h.svc.ListComments(uint(mid), page, pageSize)
+	idAny, _ := c.Get("app_user_id")
+	currentUID, _ := idAny.(uint)
+	list, total, err := h.svc.ListComments(currentUID, uint(mid), page, pageSize)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
 		return
 	}
 	items := make([]apimodel.MomentCommentItem, 0, len(list))
-	for _, cm := range list {
-		u, _ := application.AppUserSvc.GetByID(cm.UserID)
-		items = append(items, apimodel.MomentCommentItem{ID: cm.ID, MomentID: cm.MomentID, UserID: cm.UserID, Nickname: u.Nickname, Avatar: fullAvatarURL(u.Avatar), Content: cm.Content, CreatedAt: cm.CreatedAt.Unix()})
+	for _, t := range list {
+		item := commentItem(t.Comment)
+		item.ReplyCount = t.ReplyCount
+		for _, r := range t.Replies {
+			item.Replies = append(item.Replies, commentItem(r))
+		}
+		items = append(items, item)
 	}
 	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.MomentCommentListResponse{Items: items, Total: total, Page: page, PageSize: pageSize}))
 }
 
-// fullAvatarURL 复制自 AppUserHandler（避免循环引用），后续可抽公共
-func fullAvatarURL(raw string) string {
-	if raw == "" {
-		return ""
-	}
-	lower := strings.ToLower(raw)
-	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
-		return raw
-	}
-	cfg := config.Load()
-	base := cfg.Minio.BaseURL
-	if base == "" {
-		scheme := "http"
-		if cfg.Minio.UseSSL {
-			scheme = "https"
+// ListCommentReplies 某条评论下的回复
+// @Summary App 评论回复列表
+// @Tags App
+// @Security BearerAuth
+// @Param moment_id path int true "动态ID"
+// @Param comment_id path int true "顶层评论ID"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页"
+// @Success 200 {object} model.APIResponse{data=model.MomentCommentListResponse}
+// @Router /app/moments/{moment_id}/comments/{comment_id}/replies [get]
+func (h *MomentHandler) ListCommentReplies(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	cid, err := strconv.ParseUint(c.Param("comment_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
+	}
+	page, pageSize := 1, 20
+	if v := c.Query("page"); v != "" {
+		if p, err := strconv.Atoi(v); err == nil {
+			page = p
 		}
-		base = scheme + "://" + cfg.Minio.Endpoint
 	}
-	if strings.HasSuffix(base, "/") {
-		base = strings.TrimRight(base, "/")
+	if v := c.Query("page_size"); v != "" {
+		if ps, err := strconv.Atoi(v); err == nil {
+			pageSize = ps
+		}
+	}
+	list, total, err := h.svc.ListReplies(uid, uint(cid), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
+	items := make([]apimodel.MomentCommentItem, 0, len(list))
+	for _, cm := range list {
+		items = append(items, commentItem(cm))
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.MomentCommentListResponse{Items: items, Total: total, Page: page, PageSize: pageSize}))
+}
+
+// DeleteComment 删除评论（评论作者或动态作者）
+// @Summary App 删除评论
+// @Tags App
+// @Security BearerAuth
+// @Param moment_id path int true "动态ID"
+// @Param comment_id path int true "评论ID"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /app/moments/{moment_id}/comments/{comment_id} [delete]
+func (h *MomentHandler) DeleteComment(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	mid, err1 := strconv.ParseUint(c.Param("moment_id"), 10, 64)
+	cid, err2 := strconv.ParseUint(c.Param("comment_id"), 10, 64)
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
 	}
-	if !strings.HasPrefix(raw, "/") {
-		raw = "/" + raw
+	if err := h.svc.DeleteComment(uid, uint(mid), uint(cid)); err != nil {
+		if err == momentservice.ErrCommentForbidden {
+			c.JSON(http.StatusForbidden, apimodel.ErrorResponse(apimodel.CodeForbidden, err.Error()))
+			return
+		}
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponseWithMessage("deleted", nil))
+}
+
+// commentItem 组装评论条目（评论者与被回复者资料）
+func commentItem(cm *momententity.MomentComment) apimodel.MomentCommentItem {
+	item := apimodel.MomentCommentItem{ID: cm.ID, MomentID: cm.MomentID, UserID: cm.UserID, Content: cm.Content, CreatedAt: cm.CreatedAt.Unix(), ParentID: cm.ParentID, ReplyToUserID: cm.ReplyToUserID}
+	if u, _ := application.AppUserSvc.GetByID(cm.UserID); u != nil {
+		item.Nickname = u.Nickname
+		item.Avatar = application.URLs.Resolve(u.Avatar)
+	}
+	if cm.ReplyToUserID != 0 {
+		if u, _ := application.AppUserSvc.GetByID(cm.ReplyToUserID); u != nil {
+			item.ReplyToNickname = u.Nickname
+		}
 	}
-	return base + raw
+	return item
 }
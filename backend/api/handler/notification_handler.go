@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	apimodel "alice/api/model"
+	"alice/application"
+	notifyentity "alice/domain/notification/entity"
+	notifyservice "alice/domain/notification/service"
+	"alice/pkg/logger"
+)
+
+type NotificationHandler struct {
+	svc notifyservice.NotificationService
+}
+
+func NewNotificationHandler(svc notifyservice.NotificationService) *NotificationHandler {
+	return &NotificationHandler{svc: svc}
+}
+
+// ListNotifications 通知列表
+// @Summary App 通知列表
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Param unread query bool false "仅未读"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页条数"
+// @Success 200 {object} model.APIResponse{data=model.NotificationListResponse}
+// @Failure 401 {object} model.APIResponse
+// @Router /app/notifications [get]
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	page, pageSize := pageParams(c)
+	list, total, err := h.svc.List(uid, c.Query("unread") == "true", page, pageSize)
+	if err != nil {
+		logger.Errorf("list notifications failed: %v", err)
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	items := make([]apimodel.NotificationItem, 0, len(list))
+	for _, n := range list {
+		item := apimodel.NotificationItem{ID: n.ID, Type: string(n.Type), ActorID: n.ActorID, TargetID: n.TargetID, SubjectID: n.SubjectID, Content: n.Content, IsRead: n.IsRead, CreatedAt: n.CreatedAt.Unix()}
+		if u, _ := application.AppUserSvc.GetByID(n.ActorID); u != nil {
+			item.ActorNickname = u.Nickname
+			item.ActorAvatar = application.URLs.Resolve(u.Avatar)
+		}
+		items = append(items, item)
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.NotificationListResponse{Items: items, Total: total, Page: page, PageSize: pageSize}))
+}
+
+// ListNotificationGroups 聚合通知
+// @Summary App 聚合通知
+// @Description 同类型同对象的通知合并为一条（如 "3 people liked your moment"），按最新一条倒序
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页条数"
+// @Success 200 {object} model.APIResponse{data=model.NotificationGroupListResponse}
+// @Failure 401 {object} model.APIResponse
+// @Router /app/notifications/grouped [get]
+func (h *NotificationHandler) ListNotificationGroups(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	page, pageSize := pageParams(c)
+	list, total, err := h.svc.ListGroups(uid, page, pageSize)
+	if err != nil {
+		logger.Errorf("list notification groups failed: %v", err)
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	items := make([]apimodel.NotificationGroupItem, 0, len(list))
+	for _, g := range list {
+		item := apimodel.NotificationGroupItem{Type: string(g.Type), TargetID: g.TargetID, ActorCount: g.ActorCount, Summary: g.Summary, Unread: g.Unread, LastID: g.LastID, LastAt: g.LastAt.Unix()}
+		item.Actors = make([]apimodel.NotificationActor, 0, len(g.Actors))
+		for _, u := range g.Actors {
+			item.Actors = append(item.Actors, apimodel.NotificationActor{UserID: u.ID, Nickname: u.Nickname, Avatar: application.URLs.Resolve(u.Avatar)})
+		}
+		items = append(items, item)
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.NotificationGroupListResponse{Items: items, Total: total, Page: page, PageSize: pageSize}))
+}
+
+// UnreadCount 未读通知数
+// @Summary App 未读通知数
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} model.APIResponse{data=model.NotificationUnreadResponse}
+// @Failure 401 {object} model.APIResponse
+// @Router /app/notifications/unread-count [get]
+func (h *NotificationHandler) UnreadCount(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	cnt, err := h.svc.CountUnread(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.NotificationUnreadResponse{UnreadCount: cnt}))
+}
+
+// MarkRead 标记通知已读
+// @Summary App 标记通知已读
+// @Description 传 ids 按条标记；或传 type + target_id 标记整个聚合项
+// @Tags App
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body model.MarkNotificationsReadRequest true "已读范围"
+// @Success 200 {object} model.APIResponse{data=model.NotificationUnreadResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/notifications/read [post]
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	var req apimodel.MarkNotificationsReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (len(req.IDs) == 0 && req.Type == "") {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "invalid request"))
+		return
+	}
+	var err error
+	if len(req.IDs) > 0 {
+		err = h.svc.MarkRead(uid, req.IDs)
+	} else {
+		err = h.svc.MarkGroupRead(uid, notifyentity.Type(req.Type), req.TargetID)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
+	h.respondUnread(c, uid)
+}
+
+// MarkAllRead 全部标记已读
+// @Summary App 通知全部已读
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} model.APIResponse{data=model.NotificationUnreadResponse}
+// @Failure 401 {object} model.APIResponse
+// @Router /app/notifications/read-all [post]
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	if err := h.svc.MarkAllRead(uid); err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	h.respondUnread(c, uid)
+}
+
+func (h *NotificationHandler) respondUnread(c *gin.Context, uid uint) {
+	cnt, _ := h.svc.CountUnread(uid)
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.NotificationUnreadResponse{UnreadCount: cnt}))
+}
+
+// GetPreferences 通知偏好
+// @Summary App 获取通知偏好
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} model.APIResponse{data=model.NotificationPreferences}
+// @Failure 401 {object} model.APIResponse
+// @Router /app/notifications/preferences [get]
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	prefs, err := h.svc.Preferences(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(preferencesResponse(prefs)))
+}
+
+// UpdatePreferences 更新通知偏好（只需传要修改的类型）
+// @Summary App 更新通知偏好
+// @Description 类型：moment_like / moment_comment / comment_reply / friend_request / friend_accepted / group_invite / mention
+// @Tags App
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body model.NotificationPreferences true "类型开关"
+// @Success 200 {object} model.APIResponse{data=model.NotificationPreferences}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/notifications/preferences [put]
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	var req apimodel.NotificationPreferences
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "invalid request"))
+		return
+	}
+	in := make(map[notifyentity.Type]bool, len(req.Preferences))
+	for k, v := range req.Preferences {
+		in[notifyentity.Type(k)] = v
+	}
+	prefs, err := h.svc.SetPreferences(uid, in)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(preferencesResponse(prefs)))
+}
+
+func preferencesResponse(prefs map[notifyentity.Type]bool) apimodel.NotificationPreferences {
+	out := make(map[string]bool, len(prefs))
+	for k, v := range prefs {
+		out[string(k)] = v
+	}
+	return apimodel.NotificationPreferences{Preferences: out}
+}
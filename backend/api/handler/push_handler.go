@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	apimodel "alice/api/model"
+	pushentity "alice/domain/push/entity"
+	pushservice "alice/domain/push/service"
+)
+
+type PushHandler struct {
+	svc pushservice.PushService
+}
+
+func NewPushHandler(svc pushservice.PushService) *PushHandler {
+	return &PushHandler{svc: svc}
+}
+
+// RegisterDevice 注册推送设备令牌
+// @Summary App 注册推送设备
+// @Description 同一令牌重复注册会换绑到当前用户（设备切换账号）
+// @Tags App
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body model.RegisterDeviceRequest true "设备令牌"
+// @Success 200 {object} model.APIResponse{data=model.PushDeviceItem}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/push/devices [post]
+func (h *PushHandler) RegisterDevice(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	var req apimodel.RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "invalid request"))
+		return
+	}
+	d, err := h.svc.RegisterDevice(uid, req.Platform, req.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.PushDeviceItem{ID: d.ID, Platform: d.Platform, Token: d.Token, UpdatedAt: d.UpdatedAt.Unix()}))
+}
+
+// ListDevices 已注册的推送设备
+// @Summary App 推送设备列表
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} model.APIResponse{data=[]model.PushDeviceItem}
+// @Failure 401 {object} model.APIResponse
+// @Router /app/push/devices [get]
+func (h *PushHandler) ListDevices(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	list, err := h.svc.ListDevices(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	items := make([]apimodel.PushDeviceItem, 0, len(list))
+	for _, d := range list {
+		items = append(items, apimodel.PushDeviceItem{ID: d.ID, Platform: d.Platform, Token: d.Token, UpdatedAt: d.UpdatedAt.Unix()})
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(items))
+}
+
+// UnregisterDevice 注销推送设备（退出登录时调用）
+// @Summary App 注销推送设备
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Param token path string true "设备令牌"
+// @Success 200 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/push/devices/{token} [delete]
+func (h *PushHandler) UnregisterDevice(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	if err := h.svc.UnregisterDevice(uid, c.Param("token")); err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(nil))
+}
+
+// ListMutes 免打扰列表
+// @Summary App 免打扰列表
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} model.APIResponse{data=[]model.MuteItem}
+// @Failure 401 {object} model.APIResponse
+// @Router /app/push/mutes [get]
+func (h *PushHandler) ListMutes(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	list, err := h.svc.ListMutes(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return
+	}
+	items := make([]apimodel.MuteItem, 0, len(list))
+	for _, m := range list {
+		items = append(items, muteItem(m))
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(items))
+}
+
+// Mute 设置免打扰
+// @Summary App 设置免打扰
+// @Description 免打扰的会话不发离线推送、不计入角标；群聊中被 @ 时仍推送
+// @Tags App
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body model.MuteRequest true "免打扰对象"
+// @Success 200 {object} model.APIResponse{data=model.MuteItem}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/push/mutes [put]
+func (h *PushHandler) Mute(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	var req apimodel.MuteRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Minutes < 0 {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "invalid request"))
+		return
+	}
+	m, err := h.svc.Mute(uid, pushentity.MuteTarget(req.TargetType), req.TargetID, time.Duration(req.Minutes)*time.Minute)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(muteItem(m)))
+}
+
+// Unmute 取消免打扰
+// @Summary App 取消免打扰
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Param target_type path string true "all / user / group"
+// @Param target_id path int true "对象 ID（all 时为 0）"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/push/mutes/{target_type}/{target_id} [delete]
+func (h *PushHandler) Unmute(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	targetID, err := strconv.ParseUint(c.Param("target_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "invalid target_id"))
+		return
+	}
+	if err := h.svc.Unmute(uid, pushentity.MuteTarget(c.Param("target_type")), uint(targetID)); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(nil))
+}
+
+func muteItem(m *pushentity.MuteSetting) apimodel.MuteItem {
+	item := apimodel.MuteItem{TargetType: string(m.TargetType), TargetID: m.TargetID}
+	if m.Until != nil {
+		item.Until = m.Until.Unix()
+	}
+	return item
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"alice/api/model"
+	appsvc "alice/domain/appuser/service"
+	mediaservice "alice/domain/media/service"
+)
+
+// QuotaHandler App 用户存储用量与配额：App 端查看本人用量，管理端查看与调整配额
+type QuotaHandler struct {
+	svc   mediaservice.QuotaService
+	users appsvc.AppUserService
+}
+
+func NewQuotaHandler(svc mediaservice.QuotaService, users appsvc.AppUserService) *QuotaHandler {
+	return &QuotaHandler{svc: svc, users: users}
+}
+
+// MyUsage 当前用户的存储用量
+// @Summary App 查看存储用量
+// @Description 用量与上限单位为字节，limit 为 0 表示不限制；按类别（chat / moment / avatar）列出，头像上传为替换计算
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} model.APIResponse{data=mediaservice.StorageUsage}
+// @Failure 401 {object} model.APIResponse
+// @Router /app/storage/usage [get]
+func (h *QuotaHandler) MyUsage(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.CodeUnauthorized, model.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	u, err := h.svc.Usage(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, model.MsgInternalError))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(u))
+}
+
+// GetQuota 查看 App 用户的存储用量与配额
+// @Summary 查看 App 用户存储配额
+// @Tags Storage
+// @Security BearerAuth
+// @Produce json
+// @Param user_id path int true "App 用户ID"
+// @Success 200 {object} model.APIResponse{data=mediaservice.StorageUsage}
+// @Failure 404 {object} model.APIResponse
+// @Router /storage/quotas/{user_id} [get]
+func (h *QuotaHandler) GetQuota(c *gin.Context) {
+	uid, ok := h.appUser(c)
+	if !ok {
+		return
+	}
+	u, err := h.svc.Usage(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, model.MsgInternalError))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(u))
+}
+
+// SetQuota 调整 App 用户的配额档位与总量上限
+// @Summary 调整 App 用户存储配额
+// @Description 整体替换：tier 为空使用默认档位，limit_mb 为空沿用档位的总量上限，0 表示不限制；已超出新配额的用量不会被删除，只拒绝后续上传
+// @Tags Storage
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param user_id path int true "App 用户ID"
+// @Param request body model.SetStorageQuotaRequest true "配额"
+// @Success 200 {object} model.APIResponse{data=mediaservice.StorageUsage}
+// @Failure 400 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /storage/quotas/{user_id} [put]
+func (h *QuotaHandler) SetQuota(c *gin.Context) {
+	uid, ok := h.appUser(c)
+	if !ok {
+		return
+	}
+	var req model.SetStorageQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, model.MsgInvalidRequest))
+		return
+	}
+	u, err := h.svc.SetQuota(operatorID(c), uid, req.Tier, req.LimitMB)
+	if errors.Is(err, mediaservice.ErrUnknownTier) || errors.Is(err, mediaservice.ErrInvalidLimit) {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, model.MsgInternalError))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(u))
+}
+
+// appUser 解析路径中的 App 用户 ID 并确认用户存在
+func (h *QuotaHandler) appUser(c *gin.Context) (uint, bool) {
+	uid, ok := parseIDParam(c, "user_id")
+	if !ok {
+		return 0, false
+	}
+	if _, err := h.users.GetByID(uid); err != nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse(model.CodeNotFound, err.Error()))
+		return 0, false
+	}
+	return uid, true
+}
@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"alice/api/model"
+	modentity "alice/domain/moderation/entity"
+	modsvc "alice/domain/moderation/service"
+	"alice/pkg/logger"
+)
+
+// ReportHandler 用户举报（App 端提交 + 后台处理）
+type ReportHandler struct {
+	svc modsvc.ReportService
+}
+
+// NewReportHandler 创建举报处理器
+func NewReportHandler(svc modsvc.ReportService) *ReportHandler {
+	return &ReportHandler{svc: svc}
+}
+
+// CreateReport 举报消息/动态/评论/用户
+// @Summary App 举报
+// @Tags App
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body model.CreateReportRequest true "举报内容"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Router /app/reports [post]
+func (h *ReportHandler) CreateReport(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.CodeUnauthorized, model.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	var req model.CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, model.MsgInvalidRequest))
+		return
+	}
+	r, err := h.svc.Submit(uid, modentity.ReportTargetType(req.TargetType), req.TargetID, req.Reason, req.Detail)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
+		return
+	}
+	// 不向举报人暴露证据快照与处理细节
+	c.JSON(http.StatusOK, model.SuccessResponse(gin.H{"id": r.ID, "status": r.Status}))
+}
+
+// ListReports 举报列表
+// @Summary 举报列表
+// @Tags Moderation
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "状态 pending/triaged/resolved/dismissed"
+// @Param target_type query string false "对象类型 message/group_message/moment/comment/user"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} model.APIResponse{data=model.PageResponse}
+// @Router /moderation/reports [get]
+func (h *ReportHandler) ListReports(c *gin.Context) {
+	page, pageSize := pageParams(c)
+	list, total, err := h.svc.List(modentity.ReportStatus(c.Query("status")), modentity.ReportTargetType(c.Query("target_type")), page, pageSize)
+	if err != nil {
+		logger.Errorf("获取举报列表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(model.PageResponse{Items: list, Total: total, Page: page, PageSize: pageSize}))
+}
+
+// GetReport 举报详情（含证据快照）
+// @Summary 举报详情
+// @Tags Moderation
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "举报ID"
+// @Success 200 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /moderation/reports/{id} [get]
+func (h *ReportHandler) GetReport(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	r, err := h.svc.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse(model.CodeNotFound, "举报不存在"))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(r))
+}
+
+// TriageReport 受理举报
+// @Summary 受理举报
+// @Tags Moderation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "举报ID"
+// @Param request body model.HandleReportRequest false "备注"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Router /moderation/reports/{id}/triage [post]
+func (h *ReportHandler) TriageReport(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req model.HandleReportRequest
+	_ = c.ShouldBindJSON(&req)
+	r, err := h.svc.Triage(operatorID(c), id, req.Note)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(r))
+}
+
+// ResolveReport 结案（成立 / 驳回）
+// @Summary 处理举报
+// @Tags Moderation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "举报ID"
+// @Param request body model.ResolveReportRequest true "处理结果"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Router /moderation/reports/{id}/resolve [post]
+func (h *ReportHandler) ResolveReport(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req model.ResolveReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, "请求参数格式错误"))
+		return
+	}
+	r, err := h.svc.Resolve(operatorID(c), id, req.Dismiss, req.Resolution)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(r))
+}
+
+// DeleteReportedContent 删除被举报内容并结案
+// @Summary 删除违规内容
+// @Tags Moderation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "举报ID"
+// @Param request body model.HandleReportRequest false "备注"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Router /moderation/reports/{id}/delete-content [post]
+func (h *ReportHandler) DeleteReportedContent(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req model.HandleReportRequest
+	_ = c.ShouldBindJSON(&req)
+	r, err := h.svc.DeleteContent(operatorID(c), id, req.Note)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(r))
+}
+
+// BanAppUser 封禁 App 用户
+// @Summary 封禁 App 用户
+// @Tags Moderation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param user_id path int true "App 用户ID"
+// @Param request body model.BanAppUserRequest false "封禁原因"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Router /moderation/app-users/{user_id}/ban [post]
+func (h *ReportHandler) BanAppUser(c *gin.Context) {
+	uid, ok := parseIDParam(c, "user_id")
+	if !ok {
+		return
+	}
+	var req model.BanAppUserRequest
+	_ = c.ShouldBindJSON(&req)
+	if err := h.svc.BanUser(operatorID(c), uid, req.ReportID, req.Reason); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponseWithMessage("banned", nil))
+}
+
+// UnbanAppUser 解封 App 用户
+// @Summary 解封 App 用户
+// @Tags Moderation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param user_id path int true "App 用户ID"
+// @Param request body model.BanAppUserRequest false "解封原因"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Router /moderation/app-users/{user_id}/unban [post]
+func (h *ReportHandler) UnbanAppUser(c *gin.Context) {
+	uid, ok := parseIDParam(c, "user_id")
+	if !ok {
+		return
+	}
+	var req model.BanAppUserRequest
+	_ = c.ShouldBindJSON(&req)
+	if err := h.svc.UnbanUser(operatorID(c), uid, req.Reason); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponseWithMessage("unbanned", nil))
+}
+
+// ListAuditLogs 审核操作日志
+// @Summary 审核操作日志
+// @Tags Moderation
+// @Security BearerAuth
+// @Produce json
+// @Param operator_id query int false "操作人ID"
+// @Param action query string false "动作 report.triage/report.resolve/report.dismiss/content.delete/user.ban/user.unban"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} model.APIResponse{data=model.PageResponse}
+// @Router /moderation/audit-logs [get]
+func (h *ReportHandler) ListAuditLogs(c *gin.Context) {
+	page, pageSize := pageParams(c)
+	opID, _ := strconv.ParseUint(c.Query("operator_id"), 10, 64)
+	list, total, err := h.svc.ListAudits(uint(opID), c.Query("action"), page, pageSize)
+	if err != nil {
+		logger.Errorf("获取审核日志失败: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(model.PageResponse{Items: list, Total: total, Page: page, PageSize: pageSize}))
+}
+
+// operatorID 当前后台操作人 ID
+func operatorID(c *gin.Context) uint {
+	v, _ := c.Get("user_id")
+	id, _ := v.(uint)
+	return id
+}
@@ -1,24 +1,63 @@
 package handler
 
 import (
-	"io"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"alice/api/model"
 	"alice/application"
-	"alice/infra/config"
+	mediaservice "alice/domain/media/service"
+	"alice/infra/storage"
 )
 
-// StorageHandler 提供简易的 MinIO 操作接口
+const (
+	// defaultListLimit / maxListLimit 对象列举的每页数量
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+// StorageHandler 管理端对象存储浏览：bucket 与对象的增删查、元数据与标签编辑、复制与移动
 type StorageHandler struct{}
 
 func NewStorageHandler() *StorageHandler { return &StorageHandler{} }
 
+// storeReady 对象存储未初始化时返回 500
+func storeReady(c *gin.Context) bool {
+	if application.ObjectStore == nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, "storage not initialized"))
+		return false
+	}
+	return true
+}
+
+// objectKey 对象名：兼容路径参数 :object（不含 /），含 / 的对象名通过查询参数 key 传递
+func objectKey(c *gin.Context) (string, bool) {
+	key := c.Param("object")
+	if key == "" {
+		key = c.Query("key")
+	}
+	if key == "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, "key required"))
+		return "", false
+	}
+	return key, true
+}
+
+// storageError bucket 或对象不存在为 404，其余为 400
+func storageError(c *gin.Context, err error) {
+	if errors.Is(err, storage.ErrObjectNotFound) || errors.Is(err, storage.ErrBucketNotFound) {
+		c.JSON(http.StatusNotFound, model.ErrorResponse(model.CodeNotFound, err.Error()))
+		return
+	}
+	c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
+}
+
 // CreateBucket 创建 bucket
 // @Summary 创建存储桶
 // @Tags Storage
@@ -28,8 +67,7 @@ func NewStorageHandler() *StorageHandler { return &StorageHandler{} }
 // @Router /storage/buckets/{bucket} [post]
 func (h *StorageHandler) CreateBucket(c *gin.Context) {
 	bucket := c.Param("bucket")
-	if application.ObjectStore == nil {
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, "storage not initialized"))
+	if !storeReady(c) {
 		return
 	}
 	if err := application.ObjectStore.CreateBucket(c.Request.Context(), bucket); err != nil {
@@ -48,8 +86,7 @@ func (h *StorageHandler) CreateBucket(c *gin.Context) {
 // @Router /storage/buckets/{bucket} [delete]
 func (h *StorageHandler) DeleteBucket(c *gin.Context) {
 	bucket := c.Param("bucket")
-	if application.ObjectStore == nil {
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, "storage not initialized"))
+	if !storeReady(c) {
 		return
 	}
 	if err := application.ObjectStore.DeleteBucket(c.Request.Context(), bucket); err != nil {
@@ -65,57 +102,27 @@ func (h *StorageHandler) DeleteBucket(c *gin.Context) {
 // @Security BearerAuth
 // @Param bucket path string true "Bucket 名称"
 // @Param file formData file true "要上传的文件"
+// @Param prefix formData string false "目标“文件夹”，如 docs/"
 // @Success 200 {object} model.APIResponse{data=map[string]string}
 // @Router /storage/buckets/{bucket}/objects [post]
 func (h *StorageHandler) UploadObject(c *gin.Context) {
 	bucket := c.Param("bucket")
-	if application.ObjectStore == nil {
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, "storage not initialized"))
-		return
-	}
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
 		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, "missing file"))
 		return
 	}
 	defer file.Close()
-	data, err := io.ReadAll(file)
+	// 管理端上传：保留原文件名，类型与大小按 admin 场景（默认沿用 minio 全局配置）校验
+	up, err := application.UploadSvc.Upload(c.Request.Context(), mediaservice.UploadRequest{
+		Profile: "admin", Reader: file, Size: header.Size, Filename: header.Filename, ContentType: header.Header.Get("Content-Type"),
+		Bucket: bucket, Object: c.PostForm("prefix") + header.Filename,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, "read file failed"))
-		return
-	}
-
-	// 访问配置 (重新加载或建议通过依赖注入，这里简单读取)
-	// 由于没有全局直接暴露，这里简单重新读取 config.Load(); 保持一致性
-	// NOTE: 若后续频繁调用可在 application 包持有 Config 引用
-	cfgLoaded := config.Load()
-	maxBytes := int64(cfgLoaded.Minio.MaxFileSizeMB) * 1024 * 1024
-	if maxBytes > 0 && int64(len(data)) > maxBytes {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, "file too large"))
+		uploadError(c, err)
 		return
 	}
-	contentType := header.Header.Get("Content-Type")
-	if contentType == "" {
-		contentType = "application/octet-stream"
-	}
-	// MIME 允许策略：
-	// 1. 精确匹配 (application/pdf)
-	// 2. 前缀通配 (image/* 允许所有 image/xxx)
-	// 3. 全量放开 (*)
-	if !mimeAllowed(cfgLoaded.Minio.AllowedMIMEs, contentType) {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, "mime not allowed"))
-		return
-	}
-	if cfgLoaded.Minio.EnableVirusScan {
-		// 病毒扫描钩子：此处仅占位，可接入 ClamAV / 第三方服务
-		// if infected { return 400 }
-	}
-	url, err := application.ObjectStore.PutObject(c.Request.Context(), bucket, header.Filename, data, contentType)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
-		return
-	}
-	c.JSON(http.StatusOK, model.SuccessResponse(map[string]string{"url": url, "object": header.Filename}))
+	c.JSON(http.StatusOK, model.SuccessResponse(map[string]string{"url": application.URLs.Resolve(up.Path), "object": up.Object}))
 }
 
 // DeleteObject 删除对象
@@ -124,13 +131,14 @@ func (h *StorageHandler) UploadObject(c *gin.Context) {
 // @Security BearerAuth
 // @Param bucket path string true "Bucket 名称"
 // @Param object path string true "对象名"
+// @Param key query string false "对象名（含 / 时使用）"
 // @Success 200 {object} model.APIResponse
 // @Router /storage/buckets/{bucket}/objects/{object} [delete]
+// @Router /storage/buckets/{bucket}/object [delete]
 func (h *StorageHandler) DeleteObject(c *gin.Context) {
 	bucket := c.Param("bucket")
-	object := c.Param("object")
-	if application.ObjectStore == nil {
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, "storage not initialized"))
+	object, ok := objectKey(c)
+	if !ok || !storeReady(c) {
 		return
 	}
 	if err := application.ObjectStore.DeleteObject(c.Request.Context(), bucket, object); err != nil {
@@ -140,6 +148,41 @@ func (h *StorageHandler) DeleteObject(c *gin.Context) {
 	c.JSON(http.StatusOK, model.SuccessResponseWithMessage("object deleted", nil))
 }
 
+// DeleteObjects 批量删除对象
+// @Summary 批量删除对象
+// @Description 单次至多 1000 个；不存在的对象视为已删除，部分失败时在 failed 中列出
+// @Tags Storage
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param bucket path string true "Bucket 名称"
+// @Param request body model.DeleteObjectsRequest true "对象名列表"
+// @Success 200 {object} model.APIResponse{data=model.DeleteObjectsResponse}
+// @Failure 404 {object} model.APIResponse
+// @Router /storage/buckets/{bucket}/objects/delete [post]
+func (h *StorageHandler) DeleteObjects(c *gin.Context) {
+	bucket := c.Param("bucket")
+	var req model.DeleteObjectsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, model.MsgInvalidRequest))
+		return
+	}
+	if !storeReady(c) {
+		return
+	}
+	failed, err := application.ObjectStore.DeleteObjects(c.Request.Context(), bucket, req.Keys)
+	if err != nil {
+		storageError(c, err)
+		return
+	}
+	resp := model.DeleteObjectsResponse{Deleted: len(req.Keys) - len(failed), Failed: make([]model.ObjectError, 0, len(failed))}
+	for k, e := range failed {
+		resp.Failed = append(resp.Failed, model.ObjectError{Key: k, Error: e.Error()})
+	}
+	sort.Slice(resp.Failed, func(i, j int) bool { return resp.Failed[i].Key < resp.Failed[j].Key })
+	c.JSON(http.StatusOK, model.SuccessResponse(resp))
+}
+
 // ListBuckets 列举所有 buckets
 // @Summary 列举 Buckets
 // @Tags Storage
@@ -147,8 +190,7 @@ func (h *StorageHandler) DeleteObject(c *gin.Context) {
 // @Success 200 {object} model.APIResponse{data=[]string}
 // @Router /storage/buckets [get]
 func (h *StorageHandler) ListBuckets(c *gin.Context) {
-	if application.ObjectStore == nil {
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, "storage not initialized"))
+	if !storeReady(c) {
 		return
 	}
 	buckets, err := application.ObjectStore.ListBuckets(c.Request.Context())
@@ -159,36 +201,222 @@ func (h *StorageHandler) ListBuckets(c *gin.Context) {
 	c.JSON(http.StatusOK, model.SuccessResponse(buckets))
 }
 
-// ListObjects 列举对象
+// ListObjects 分页列举对象
 // @Summary 列举对象
+// @Description 非递归时按 / 分隔，下一级“文件夹”放入 prefixes；next_token 不为空时作为 token 请求下一页
 // @Tags Storage
 // @Security BearerAuth
 // @Param bucket path string true "Bucket 名称"
-// @Param prefix query string false "前缀"
+// @Param prefix query string false "前缀（“文件夹”以 / 结尾）"
 // @Param recursive query bool false "是否递归"
-// @Param limit query int false "返回数量限制"
-// @Success 200 {object} model.APIResponse{data=[]string}
+// @Param token query string false "上一页返回的 next_token"
+// @Param limit query int false "每页数量 (默认100，最大1000)"
+// @Success 200 {object} model.APIResponse{data=storage.ObjectPage}
+// @Failure 404 {object} model.APIResponse
 // @Router /storage/buckets/{bucket}/objects [get]
 func (h *StorageHandler) ListObjects(c *gin.Context) {
-	if application.ObjectStore == nil {
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, "storage not initialized"))
+	if !storeReady(c) {
 		return
 	}
 	bucket := c.Param("bucket")
 	prefix := c.Query("prefix")
 	recursive := c.Query("recursive") == "true"
-	limit := 0
+	limit := defaultListLimit
 	if v := c.Query("limit"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			limit = n
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = min(n, maxListLimit)
 		}
 	}
-	objs, err := application.ObjectStore.ListObjects(c.Request.Context(), bucket, prefix, recursive, limit)
+	page, err := application.ObjectStore.ListObjectPage(c.Request.Context(), bucket, prefix, recursive, c.Query("token"), limit)
+	if err != nil {
+		storageError(c, err)
+		return
+	}
+	if page.Objects == nil {
+		page.Objects = []storage.ObjectInfo{}
+	}
+	if page.Prefixes == nil {
+		page.Prefixes = []string{}
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(page))
+}
+
+// GetObject 对象详情
+// @Summary 查看对象详情
+// @Description 返回大小、类型、ETag、修改时间、自定义元数据与标签
+// @Tags Storage
+// @Security BearerAuth
+// @Param bucket path string true "Bucket 名称"
+// @Param key query string true "对象名"
+// @Success 200 {object} model.APIResponse{data=model.StorageObject}
+// @Failure 404 {object} model.APIResponse
+// @Router /storage/buckets/{bucket}/object [get]
+func (h *StorageHandler) GetObject(c *gin.Context) {
+	bucket := c.Param("bucket")
+	object, ok := objectKey(c)
+	if !ok || !storeReady(c) {
+		return
+	}
+	ctx := c.Request.Context()
+	info, err := application.ObjectStore.StatObject(ctx, bucket, object)
+	if err != nil {
+		storageError(c, err)
+		return
+	}
+	tags, err := application.ObjectStore.GetObjectTags(ctx, bucket, object)
+	if err != nil {
+		storageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(storageObject(info, tags)))
+}
+
+// UpdateObjectMetadata 替换对象的类型与自定义元数据
+// @Summary 编辑对象元数据
+// @Tags Storage
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param bucket path string true "Bucket 名称"
+// @Param key query string true "对象名"
+// @Param request body model.UpdateObjectMetadataRequest true "元数据"
+// @Success 200 {object} model.APIResponse{data=model.StorageObject}
+// @Failure 400 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /storage/buckets/{bucket}/object/metadata [put]
+func (h *StorageHandler) UpdateObjectMetadata(c *gin.Context) {
+	bucket := c.Param("bucket")
+	object, ok := objectKey(c)
+	if !ok {
+		return
+	}
+	var req model.UpdateObjectMetadataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, model.MsgInvalidRequest))
+		return
+	}
+	meta, err := storage.NormalizeMetadata(req.Metadata)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
 		return
 	}
-	c.JSON(http.StatusOK, model.SuccessResponse(objs))
+	if !storeReady(c) {
+		return
+	}
+	if err := application.ObjectStore.UpdateObjectMetadata(c.Request.Context(), bucket, object, req.ContentType, meta); err != nil {
+		storageError(c, err)
+		return
+	}
+	h.GetObject(c)
+}
+
+// SetObjectTags 替换对象标签
+// @Summary 编辑对象标签
+// @Description 整体替换，至多 10 个；tags 为空时清除全部标签
+// @Tags Storage
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param bucket path string true "Bucket 名称"
+// @Param key query string true "对象名"
+// @Param request body model.SetObjectTagsRequest true "标签"
+// @Success 200 {object} model.APIResponse{data=model.StorageObject}
+// @Failure 400 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /storage/buckets/{bucket}/object/tags [put]
+func (h *StorageHandler) SetObjectTags(c *gin.Context) {
+	bucket := c.Param("bucket")
+	object, ok := objectKey(c)
+	if !ok {
+		return
+	}
+	var req model.SetObjectTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, model.MsgInvalidRequest))
+		return
+	}
+	if err := storage.ValidateTags(req.Tags); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
+		return
+	}
+	if !storeReady(c) {
+		return
+	}
+	if err := application.ObjectStore.SetObjectTags(c.Request.Context(), bucket, object, req.Tags); err != nil {
+		storageError(c, err)
+		return
+	}
+	h.GetObject(c)
+}
+
+// CopyObject 复制对象
+// @Summary 复制对象
+// @Description key 以 / 结尾时复制该“文件夹”下的全部对象（至多 1000 个）；目标已存在时覆盖
+// @Tags Storage
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param bucket path string true "源 Bucket 名称"
+// @Param request body model.TransferObjectRequest true "源与目标"
+// @Success 200 {object} model.APIResponse{data=model.TransferObjectResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /storage/buckets/{bucket}/object/copy [post]
+func (h *StorageHandler) CopyObject(c *gin.Context) {
+	h.transfer(c, false)
+}
+
+// MoveObject 移动或重命名对象
+// @Summary 移动 / 重命名对象
+// @Description 复制到目标位置后删除源对象；key 以 / 结尾时移动该“文件夹”下的全部对象（至多 1000 个）。业务数据中对原路径的引用不会随之更新
+// @Tags Storage
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param bucket path string true "源 Bucket 名称"
+// @Param request body model.TransferObjectRequest true "源与目标"
+// @Success 200 {object} model.APIResponse{data=model.TransferObjectResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /storage/buckets/{bucket}/object/move [post]
+func (h *StorageHandler) MoveObject(c *gin.Context) {
+	h.transfer(c, true)
+}
+
+func (h *StorageHandler) transfer(c *gin.Context, move bool) {
+	bucket := c.Param("bucket")
+	var req model.TransferObjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, model.MsgInvalidRequest))
+		return
+	}
+	if !storeReady(c) {
+		return
+	}
+	dstBucket := req.DestBucket
+	if dstBucket == "" {
+		dstBucket = bucket
+	}
+	n, err := storage.TransferObjects(c.Request.Context(), application.ObjectStore, bucket, req.Key, dstBucket, req.DestKey, move)
+	if err != nil {
+		if n > 0 { // 按前缀处理时中途失败，已处理的对象保留在目标位置
+			err = fmt.Errorf("%d objects done before failure: %w", n, err)
+		}
+		storageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(model.TransferObjectResponse{Objects: n}))
+}
+
+func storageObject(info storage.ObjectInfo, tags map[string]string) model.StorageObject {
+	if info.Metadata == nil {
+		info.Metadata = map[string]string{}
+	}
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	return model.StorageObject{Key: info.Key, Size: info.Size, ContentType: info.ContentType, ETag: info.ETag, LastModified: info.LastModified,
+		Metadata: info.Metadata, Tags: tags}
 }
 
 // GetObjectPresigned 获取对象的预签名下载 URL
@@ -197,16 +425,17 @@ func (h *StorageHandler) ListObjects(c *gin.Context) {
 // @Security BearerAuth
 // @Param bucket path string true "Bucket 名称"
 // @Param object path string true "对象名"
+// @Param key query string false "对象名（含 / 时使用）"
 // @Param expiry query int false "有效期秒 (默认3600)"
 // @Success 200 {object} model.APIResponse{data=map[string]string}
 // @Router /storage/buckets/{bucket}/objects/{object}/url [get]
+// @Router /storage/buckets/{bucket}/object/url [get]
 func (h *StorageHandler) GetObjectPresigned(c *gin.Context) {
-	if application.ObjectStore == nil {
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, "storage not initialized"))
+	bucket := c.Param("bucket")
+	object, ok := objectKey(c)
+	if !ok || !storeReady(c) {
 		return
 	}
-	bucket := c.Param("bucket")
-	object := c.Param("object")
 	expirySec := 3600
 	if v := c.Query("expiry"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil && n > 0 {
@@ -230,8 +459,7 @@ func (h *StorageHandler) GetObjectPresigned(c *gin.Context) {
 // @Success 200 {object} model.APIResponse
 // @Router /storage/buckets/{bucket}/public [post]
 func (h *StorageHandler) SetBucketPublic(c *gin.Context) {
-	if application.ObjectStore == nil {
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, "storage not initialized"))
+	if !storeReady(c) {
 		return
 	}
 	bucket := c.Param("bucket")
@@ -247,31 +475,37 @@ func (h *StorageHandler) SetBucketPublic(c *gin.Context) {
 	c.JSON(http.StatusOK, model.SuccessResponseWithMessage(msg, nil))
 }
 
-// mimeAllowed 判断 contentType 是否在允许列表中，支持：
-//   - "*" 全量放开
-//   - 精确匹配
-//   - 前缀通配，如 "image/*" 匹配所有 image/xxx
-func mimeAllowed(allowed []string, ct string) bool {
-	if len(allowed) == 0 { // 未配置认为全部允许（与之前逻辑一致：只有配置了才限制）
-		return true
-	}
-	ctLower := strings.ToLower(ct)
-	for _, a := range allowed {
-		a = strings.ToLower(strings.TrimSpace(a))
-		if a == "" {
-			continue
-		}
-		if a == "*" {
-			return true
-		}
-		if strings.HasSuffix(a, "/*") { // 前缀匹配
-			prefix := strings.TrimSuffix(a, "/*") + "/"
-			if strings.HasPrefix(ctLower, prefix) {
-				return true
-			}
-		} else if a == ctLower { // 精确匹配
-			return true
-		}
+// RunLifecycle 手动执行对象生命周期任务，默认试运行只返回报告
+// @Summary 执行对象生命周期清理
+// @Description 删除超过宽限期仍未被引用的对象与超过 bucket 过期天数的对象；dry_run 默认为 true，仅返回待删除对象的报告
+// @Tags Storage
+// @Security BearerAuth
+// @Param dry_run query bool false "试运行 (默认 true)"
+// @Success 200 {object} model.APIResponse{data=mediaservice.LifecycleReport}
+// @Router /storage/lifecycle/run [post]
+func (h *StorageHandler) RunLifecycle(c *gin.Context) {
+	dryRun := c.DefaultQuery("dry_run", "true") != "false"
+	report, err := application.LifecycleSvc.Run(c.Request.Context(), dryRun)
+	if errors.Is(err, mediaservice.ErrLifecycleRunning) {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse(report))
+}
+
+// uploadError 上传失败的响应：存储未初始化为 500，超出存储配额为 403，其余（大小、类型、处理失败等）为 400
+func uploadError(c *gin.Context, err error) {
+	if errors.Is(err, mediaservice.ErrStorageUnavailable) || errors.Is(err, mediaservice.ErrScanUnavailable) {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeInternalError, err.Error()))
+		return
+	}
+	if errors.Is(err, mediaservice.ErrQuotaExceeded) {
+		c.JSON(http.StatusForbidden, model.ErrorResponse(model.CodeForbidden, err.Error()))
+		return
 	}
-	return false
+	c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeBadRequest, err.Error()))
 }
@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	apimodel "alice/api/model"
+	"alice/application"
+	mediaentity "alice/domain/media/entity"
+	mediaservice "alice/domain/media/service"
+	"alice/infra/storage"
+)
+
+// UploadHandler 大文件上传：分片上传（断点续传，创建会话 -> 逐片 PUT -> 完成合并）
+// 或直传对象存储（申请预签名地址 -> 客户端上传 -> 回调完成），完成后登记为媒体附件
+type UploadHandler struct{ svc mediaservice.UploadService }
+
+func NewUploadHandler(svc mediaservice.UploadService) *UploadHandler {
+	return &UploadHandler{svc: svc}
+}
+
+// InitMultipart 创建分片上传会话
+// @Summary App 创建分片上传
+// @Description 仅视频类场景支持；会话 24 小时内有效，超时未完成的分片由后台清理
+// @Tags App
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body model.InitMultipartRequest true "上传信息"
+// @Success 200 {object} model.APIResponse{data=model.MultipartSessionResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/uploads/multipart [post]
+func (h *UploadHandler) InitMultipart(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	var req apimodel.InitMultipartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
+	}
+	sess, err := h.svc.InitMultipart(c.Request.Context(), uid, req.Profile, req.Filename, req.ContentType, req.Size)
+	if err != nil {
+		uploadError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(multipartResponse(sess, nil)))
+}
+
+// GetMultipart 查询分片上传进度
+// @Summary App 查询分片上传
+// @Description 返回已上传的分片，客户端中断后据此跳过已完成的分片继续上传
+// @Tags App
+// @Security BearerAuth
+// @Produce json
+// @Param upload_id path int true "上传会话ID"
+// @Success 200 {object} model.APIResponse{data=model.MultipartSessionResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /app/uploads/multipart/{upload_id} [get]
+func (h *UploadHandler) GetMultipart(c *gin.Context) {
+	uid, id, ok := uploadSessionParams(c)
+	if !ok {
+		return
+	}
+	sess, parts, err := h.svc.GetMultipart(c.Request.Context(), uid, id)
+	if err != nil {
+		multipartError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(multipartResponse(sess, parts)))
+}
+
+// UploadPart 上传一个分片（请求体为分片原始内容，需携带 Content-Length）
+// @Summary App 上传分片
+// @Description 分片号 1~10000，重复上传同一分片号会覆盖；除最后一片外每片不小于 5MB
+// @Tags App
+// @Security BearerAuth
+// @Accept application/octet-stream
+// @Produce json
+// @Param upload_id path int true "上传会话ID"
+// @Param part_number path int true "分片号"
+// @Success 200 {object} model.APIResponse{data=model.UploadPartItem}
+// @Failure 400 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /app/uploads/multipart/{upload_id}/parts/{part_number} [put]
+func (h *UploadHandler) UploadPart(c *gin.Context) {
+	uid, id, ok := uploadSessionParams(c)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(c.Param("part_number"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
+	}
+	if c.Request.ContentLength <= 0 {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, "content-length required"))
+		return
+	}
+	part, err := h.svc.UploadPart(c.Request.Context(), uid, id, n, c.Request.Body, c.Request.ContentLength)
+	if err != nil {
+		multipartError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.UploadPartItem{PartNumber: part.Number, ETag: part.ETag, Size: part.Size}))
+}
+
+// CompleteMultipart 合并分片并登记媒体附件
+// @Summary App 完成分片上传
+// @Description 合并后按场景校验真实类型与时长，失败时删除对象；视频可同时上传封面图（form-data: poster）
+// @Tags App
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param upload_id path int true "上传会话ID"
+// @Param poster formData file false "封面图"
+// @Success 200 {object} model.APIResponse{data=model.MediaItem}
+// @Failure 400 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /app/uploads/multipart/{upload_id}/complete [post]
+func (h *UploadHandler) CompleteMultipart(c *gin.Context) {
+	uid, id, ok := uploadSessionParams(c)
+	if !ok {
+		return
+	}
+	poster, posterHeader, err := c.Request.FormFile("poster")
+	if err == nil {
+		defer poster.Close()
+	}
+	up, err := h.svc.CompleteMultipart(c.Request.Context(), uid, id)
+	if err != nil {
+		multipartError(c, err)
+		return
+	}
+	att, ok := registerUpload(c, uid, up, poster, posterHeader)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(mediaItemsOf([]*mediaentity.Attachment{att})[0]))
+}
+
+// PresignUpload 申请直传对象存储的预签名地址
+// @Summary App 申请直传地址
+// @Description 仅视频类场景支持；地址 15 分钟内有效，上传后须调用完成接口登记，未完成的对象由后台清理
+// @Tags App
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body model.PresignUploadRequest true "上传信息"
+// @Success 200 {object} model.APIResponse{data=model.PresignUploadResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /app/uploads/presigned [post]
+func (h *UploadHandler) PresignUpload(c *gin.Context) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return
+	}
+	uid, _ := idAny.(uint)
+	var req apimodel.PresignUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return
+	}
+	res, err := h.svc.PresignUpload(c.Request.Context(), uid, req.Profile, req.Filename, req.ContentType, req.Size, req.Method)
+	if err != nil {
+		uploadError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(apimodel.PresignUploadResponse{UploadID: res.Session.ID, Method: res.Method, URL: res.URL,
+		Headers: res.Headers, FormData: res.FormData, ExpiresAt: res.ExpiresAt.Unix()}))
+}
+
+// CompletePresigned 直传完成回调：确认对象已上传、校验内容并登记媒体附件
+// @Summary App 完成直传
+// @Description 对象尚未上传时返回错误且可重试；内容校验失败时删除对象；视频可同时上传封面图（form-data: poster）
+// @Tags App
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param upload_id path int true "上传会话ID"
+// @Param poster formData file false "封面图"
+// @Success 200 {object} model.APIResponse{data=model.MediaItem}
+// @Failure 400 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /app/uploads/presigned/{upload_id}/complete [post]
+func (h *UploadHandler) CompletePresigned(c *gin.Context) {
+	uid, id, ok := uploadSessionParams(c)
+	if !ok {
+		return
+	}
+	poster, posterHeader, err := c.Request.FormFile("poster")
+	if err == nil {
+		defer poster.Close()
+	}
+	up, err := h.svc.CompletePresigned(c.Request.Context(), uid, id)
+	if err != nil {
+		multipartError(c, err)
+		return
+	}
+	att, ok := registerUpload(c, uid, up, poster, posterHeader)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponse(mediaItemsOf([]*mediaentity.Attachment{att})[0]))
+}
+
+// AbortUpload 取消分片上传或直传，释放已上传的分片或对象
+// @Summary App 取消上传
+// @Tags App
+// @Security BearerAuth
+// @Param upload_id path int true "上传会话ID"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /app/uploads/multipart/{upload_id} [delete]
+// @Router /app/uploads/presigned/{upload_id} [delete]
+func (h *UploadHandler) AbortUpload(c *gin.Context) {
+	uid, id, ok := uploadSessionParams(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.AbortUpload(c.Request.Context(), uid, id); err != nil {
+		multipartError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, apimodel.SuccessResponseWithMessage("upload aborted", nil))
+}
+
+// registerUpload 登记上传结果为媒体附件；poster 不为空且场景配置了封面场景时一并上传封面并生成衍生图
+func registerUpload(c *gin.Context, uid uint, up *mediaservice.Upload, poster multipart.File, posterHeader *multipart.FileHeader) (*mediaentity.Attachment, bool) {
+	att := up.Attachment(uid)
+	img := up.Image
+	if poster != nil && up.PosterProfile != "" {
+		cover, err := application.UploadSvc.Upload(c.Request.Context(), mediaservice.UploadRequest{
+			Profile: up.PosterProfile, OwnerID: uid, Reader: poster, Size: posterHeader.Size, Filename: posterHeader.Filename, ContentType: posterHeader.Header.Get("Content-Type"),
+		})
+		if err != nil {
+			uploadError(c, err)
+			return nil, false
+		}
+		// 封面随附件登记，不单独计入用量，释放其预占
+		_ = application.QuotaSvc.Release(cover.Reservation)
+		att.PosterPath, att.ThumbPath, att.MediumPath = cover.Path, cover.Variants["thumb"], cover.Variants["medium"]
+		img = cover.Image
+	}
+	if err := application.MediaSvc.Register(att, img); err != nil {
+		c.JSON(http.StatusInternalServerError, apimodel.ErrorResponse(apimodel.CodeInternalError, apimodel.MsgInternalError))
+		return nil, false
+	}
+	return att, true
+}
+
+func uploadSessionParams(c *gin.Context) (uint, uint, bool) {
+	idAny, ok := c.Get("app_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apimodel.ErrorResponse(apimodel.CodeUnauthorized, apimodel.MsgUnauthorized))
+		return 0, 0, false
+	}
+	uid, _ := idAny.(uint)
+	id, err := strconv.ParseUint(c.Param("upload_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, apimodel.ErrorResponse(apimodel.CodeBadRequest, apimodel.MsgInvalidRequest))
+		return 0, 0, false
+	}
+	return uid, uint(id), true
+}
+
+// multipartError 会话不存在为 404，其余同 uploadError
+func multipartError(c *gin.Context, err error) {
+	if errors.Is(err, mediaservice.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, apimodel.ErrorResponse(apimodel.CodeNotFound, err.Error()))
+		return
+	}
+	uploadError(c, err)
+}
+
+func multipartResponse(sess *mediaentity.UploadSession, parts []storage.Part) apimodel.MultipartSessionResponse {
+	resp := apimodel.MultipartSessionResponse{UploadID: sess.ID, Profile: sess.Profile, Size: sess.Size, PartSize: mediaservice.DefaultPartSize,
+		ExpiresAt: sess.ExpiresAt.Unix(), Parts: make([]apimodel.UploadPartItem, 0, len(parts))}
+	for _, p := range parts {
+		resp.Parts = append(resp.Parts, apimodel.UploadPartItem{PartNumber: p.Number, ETag: p.ETag, Size: p.Size})
+	}
+	return resp
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 
 	"alice/api/model"
+	appsvc "alice/domain/appuser/service"
 	"alice/infra/config"
 )
 
@@ -37,6 +38,31 @@ func AppJWTAuth() gin.HandlerFunc {
 	}
 }
 
+// RequireActiveAppUser 拒绝已封禁/停用的 App 用户（需放在 AppJWTAuth 之后）。
+// 封禁在后台生效后，已签发的 token 也会立即失效，而不是等到过期。
+func RequireActiveAppUser(userSvc appsvc.AppUserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, err := GetAppUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.CodeUnauthorized, "invalid token payload"))
+			c.Abort()
+			return
+		}
+		u, err := userSvc.GetByID(uid)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.CodeUnauthorized, model.MsgUserNotFound))
+			c.Abort()
+			return
+		}
+		if !u.IsActive() {
+			c.JSON(http.StatusForbidden, model.ErrorResponse(model.CodeForbidden, "account "+string(u.Status)))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // 可与通用 validateToken 复用（同包）
 func ValidateAppToken(tokenString string) (jwt.MapClaims, error) {
 	cfg := config.Load()
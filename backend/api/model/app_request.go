@@ -23,7 +23,44 @@ type AppUpdateProfileRequest struct {
 	Bio    string `json:"bio" binding:"omitempty,max=160"`
 }
 
-// AddFriendRequest 添加好友
+// AppUpdateHandleRequest 设置 handle
+type AppUpdateHandleRequest struct {
+	Handle string `json:"handle" binding:"required,min=4,max=32"`
+}
+
+// AppUpdatePrivacyRequest 更新可发现性设置（未传字段保持不变）
+type AppUpdatePrivacyRequest struct {
+	FindableByEmail   *bool `json:"findable_by_email"`
+	FindableByHandle  *bool `json:"findable_by_handle"`
+	FindableViaGroups *bool `json:"findable_via_groups"`
+}
+
+// AddFriendRequest 添加好友（friend_email 与 user_id 二选一）
 type AddFriendRequest struct {
-	FriendEmail string `json:"friend_email" binding:"required,email"`
+	FriendEmail string `json:"friend_email" binding:"omitempty,email"`
+	UserID      uint   `json:"user_id"`
+	Message     string `json:"message" binding:"omitempty,max=200"`                   // 验证消息
+	Source      string `json:"source" binding:"omitempty,oneof=search group qr card"` // 来源，默认 search
+}
+
+// BlockUserRequest 拉黑用户
+type BlockUserRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// FriendRemarkRequest 设置好友备注
+type FriendRemarkRequest struct {
+	Alias string `json:"alias" binding:"omitempty,max=64"`
+	Notes string `json:"notes" binding:"omitempty,max=500"`
+}
+
+// FriendTagRequest 创建 / 重命名好友标签
+type FriendTagRequest struct {
+	Name      string `json:"name" binding:"required,max=32"`
+	FriendIDs []uint `json:"friend_ids"` // 仅创建时使用
+}
+
+// FriendTagMembersRequest 设置标签成员（整体替换）
+type FriendTagMembersRequest struct {
+	FriendIDs []uint `json:"friend_ids"`
 }
@@ -4,10 +4,59 @@ package model
 type AppUserInfo struct {
 	ID       uint   `json:"id"`
 	Email    string `json:"email"`
+	Username string `json:"username"` // handle，未设置时为空
 	Nickname string `json:"nickname"`
 	Avatar   string `json:"avatar"`
 	Gender   string `json:"gender"`
 	Bio      string `json:"bio"`
+	// AvatarThumb / AvatarMedium 上传头像时返回的衍生图
+	AvatarThumb  string `json:"avatar_thumb,omitempty"`
+	AvatarMedium string `json:"avatar_medium,omitempty"`
+}
+
+// AppUserSearchItem 用户搜索结果（不含邮箱等隐私字段）
+type AppUserSearchItem struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+	Gender   string `json:"gender"`
+	Bio      string `json:"bio"`
+	IsFriend bool   `json:"is_friend"`
+}
+
+// AppUserSearchResponse 用户搜索
+type AppUserSearchResponse struct {
+	Items    []AppUserSearchItem `json:"items"`
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+}
+
+// FriendSuggestionItem 可能认识的人
+type FriendSuggestionItem struct {
+	ID            uint   `json:"id"`
+	Username      string `json:"username"`
+	Nickname      string `json:"nickname"`
+	Avatar        string `json:"avatar"`
+	MutualFriends int    `json:"mutual_friends"`
+	SharedGroups  int    `json:"shared_groups"`
+	Reason        string `json:"reason"`
+}
+
+// FriendSuggestionListResponse 可能认识的人列表
+type FriendSuggestionListResponse struct {
+	Items    []FriendSuggestionItem `json:"items"`
+	Total    int64                  `json:"total"`
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"page_size"`
+}
+
+// AppPrivacyInfo 可发现性设置
+type AppPrivacyInfo struct {
+	FindableByEmail   bool `json:"findable_by_email"`
+	FindableByHandle  bool `json:"findable_by_handle"`
+	FindableViaGroups bool `json:"findable_via_groups"`
 }
 
 // AppAuthResponse 注册后下发 token + 基本资料
@@ -32,6 +81,9 @@ type FriendDetail struct {
 	Avatar   string `json:"avatar"`
 	Gender   string `json:"gender"`
 	Bio      string `json:"bio"`
+	Alias    string `json:"alias,omitempty"`   // 自己设置的备注名
+	Notes    string `json:"notes,omitempty"`   // 自己设置的备注描述
+	TagIDs   []uint `json:"tag_ids,omitempty"` // 所属标签
 }
 
 // FriendDetailListResponse 返回详细好友资料
@@ -41,3 +93,24 @@ type FriendDetailListResponse struct {
 	Page     int            `json:"page"`
 	PageSize int            `json:"page_size"`
 }
+
+// FriendTagItem 好友标签
+type FriendTagItem struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	FriendIDs []uint `json:"friend_ids"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// FriendRequestItem 好友申请
+type FriendRequestItem struct {
+	ID          uint        `json:"id"`
+	RequesterID uint        `json:"requester_id"`
+	AddresseeID uint        `json:"addressee_id"`
+	User        AppUserInfo `json:"user"` // 对方资料（收到的申请为申请人，发出的申请为接收人）
+	Message     string      `json:"message"`
+	Source      string      `json:"source"`
+	Status      string      `json:"status"`
+	CreatedAt   int64       `json:"created_at"`
+	ExpiresAt   int64       `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,72 @@
+package model
+
+// MediaItem 媒体附件（动态图片、聊天图片/视频）
+type MediaItem struct {
+	URL      string `json:"url"`
+	Path     string `json:"path"`
+	Mime     string `json:"mime"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Size     int64  `json:"size"`
+	Blurhash string `json:"blurhash,omitempty"`
+	// ThumbURL / MediumURL 缩略图（列表）与中图（预览），历史数据可能为空
+	ThumbURL  string `json:"thumb_url,omitempty"`
+	MediumURL string `json:"medium_url,omitempty"`
+	// DurationMs / PosterURL 视频时长（毫秒）与封面，客户端应先展示封面再开始拉流
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	PosterURL  string `json:"poster_url,omitempty"`
+}
+
+// InitMultipartRequest 创建分片上传会话
+type InitMultipartRequest struct {
+	// Profile 上传场景，仅支持视频类场景（如 moment-video、chat-video）
+	Profile     string `json:"profile" binding:"required"`
+	Filename    string `json:"filename" binding:"omitempty,max=255"`
+	ContentType string `json:"content_type" binding:"omitempty,max=100"`
+	Size        int64  `json:"size" binding:"required,gt=0"`
+}
+
+// UploadPartItem 已上传的分片
+type UploadPartItem struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+	Size       int64  `json:"size"`
+}
+
+// MultipartSessionResponse 分片上传会话；Parts 为已上传的分片，断点续传时跳过这些分片
+type MultipartSessionResponse struct {
+	UploadID  uint             `json:"upload_id"`
+	Profile   string           `json:"profile"`
+	Size      int64            `json:"size"`
+	PartSize  int64            `json:"part_size"` // 建议分片大小
+	ExpiresAt int64            `json:"expires_at"`
+	Parts     []UploadPartItem `json:"parts"`
+}
+
+// PresignUploadRequest 申请直传地址
+type PresignUploadRequest struct {
+	Profile     string `json:"profile" binding:"required"`
+	Filename    string `json:"filename" binding:"omitempty,max=255"`
+	ContentType string `json:"content_type" binding:"required,max=100"`
+	Size        int64  `json:"size" binding:"required,gt=0"`
+	// Method PUT（默认，签名绑定类型与大小）或 POST（表单上传）
+	Method string `json:"method" binding:"omitempty,oneof=PUT POST put post"`
+}
+
+// PresignUploadResponse 直传凭证：PUT 时携带 Headers 上传请求体；POST 时提交 FormData 字段及文件字段 file（放在最后）
+type PresignUploadResponse struct {
+	UploadID  uint              `json:"upload_id"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	FormData  map[string]string `json:"form_data,omitempty"`
+	ExpiresAt int64             `json:"expires_at"`
+}
+
+// SetStorageQuotaRequest 设置 App 用户的存储配额
+type SetStorageQuotaRequest struct {
+	// Tier 配额档位，为空使用默认档位
+	Tier string `json:"tier" binding:"omitempty,max=32"`
+	// LimitMB 总量上限（MB），为空沿用档位，0 表示不限制
+	LimitMB *int64 `json:"limit_mb" binding:"omitempty,gte=0"`
+}
@@ -0,0 +1,54 @@
+package model
+
+// AddSensitiveWordsRequest 批量添加敏感词
+type AddSensitiveWordsRequest struct {
+	Words    []string `json:"words" binding:"required,min=1"`
+	Action   string   `json:"action" binding:"omitempty,oneof=block mask flag"` // 默认 mask
+	Category string   `json:"category" binding:"omitempty,max=50"`
+}
+
+// UpdateSensitiveWordRequest 更新敏感词
+type UpdateSensitiveWordRequest struct {
+	Action   string `json:"action" binding:"omitempty,oneof=block mask flag"`
+	Category string `json:"category" binding:"omitempty,max=50"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// ReviewFlagRequest 复核待审内容
+type ReviewFlagRequest struct {
+	Status string `json:"status" binding:"required,oneof=approved rejected"`
+	Note   string `json:"note" binding:"omitempty,max=255"`
+}
+
+// PageResponse 通用分页响应
+type PageResponse struct {
+	Items    interface{} `json:"items"`
+	Total    int64       `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+}
+
+// CreateReportRequest App 端举报
+type CreateReportRequest struct {
+	TargetType string `json:"target_type" binding:"required,oneof=message group_message moment comment user"`
+	TargetID   uint   `json:"target_id" binding:"required"`
+	Reason     string `json:"reason" binding:"required,oneof=spam harassment porn violence fraud illegal other"`
+	Detail     string `json:"detail" binding:"omitempty,max=500"`
+}
+
+// HandleReportRequest 受理 / 删除违规内容时附带的备注
+type HandleReportRequest struct {
+	Note string `json:"note" binding:"omitempty,max=255"`
+}
+
+// ResolveReportRequest 结案
+type ResolveReportRequest struct {
+	Dismiss    bool   `json:"dismiss"` // true 表示举报不成立
+	Resolution string `json:"resolution" binding:"omitempty,max=255"`
+}
+
+// BanAppUserRequest 封禁 / 解封 App 用户
+type BanAppUserRequest struct {
+	ReportID uint   `json:"report_id"` // 可选：关联的举报
+	Reason   string `json:"reason" binding:"omitempty,max=255"`
+}
@@ -4,19 +4,32 @@ package model
 type CreateMomentRequest struct {
 	Content string   `json:"content" binding:"required"` // 文本内容
 	Images  []string `json:"images"`                     // 已上传后的相对路径 /bucket/object，最多9张
+	Video   string   `json:"video"`                      // 视频动态：POST /app/moments/videos 返回的 path，与 images 互斥
+	// Visibility 可见范围：public / friends / private / include / exclude，默认 friends
+	Visibility string `json:"visibility" binding:"omitempty,oneof=public friends private include exclude"`
+	// UserIDs / TagIDs include、exclude 模式下的好友名单，标签会展开为其中的好友
+	UserIDs []uint `json:"user_ids"`
+	TagIDs  []uint `json:"tag_ids"`
 }
 
 // MomentItem 动态条目
 type MomentItem struct {
-	ID        uint     `json:"id"`
-	UserID    uint     `json:"user_id"`
-	Nickname  string   `json:"nickname"`
-	Avatar    string   `json:"avatar"`
-	Content   string   `json:"content"`
-	Images    []string `json:"images"`
-	CreatedAt int64    `json:"created_at"`
-	LikeCount int64    `json:"like_count"`
-	Liked     bool     `json:"liked"` // 当前用户是否已点赞
+	ID       uint     `json:"id"`
+	UserID   uint     `json:"user_id"`
+	Nickname string   `json:"nickname"`
+	Avatar   string   `json:"avatar"`
+	Content  string   `json:"content"`
+	Images   []string `json:"images"`
+	// Media 图片的结构化信息（宽高、占位等），顺序与 Images 一致
+	Media []MediaItem `json:"media"`
+	// Video 视频动态的视频（含时长与封面），图片动态为空
+	Video     *MediaItem `json:"video,omitempty"`
+	CreatedAt int64      `json:"created_at"`
+	LikeCount int64      `json:"like_count"`
+	Liked     bool       `json:"liked"` // 当前用户是否已点赞
+	// Visibility 可见范围；AudienceIDs 仅作者本人可见
+	Visibility  string `json:"visibility"`
+	AudienceIDs []uint `json:"audience_ids,omitempty"`
 }
 
 // MomentListResponse 列表响应
@@ -27,9 +40,17 @@ type MomentListResponse struct {
 	PageSize int          `json:"page_size"`
 }
 
+// MomentTimelineResponse 好友时间线（游标分页）
+type MomentTimelineResponse struct {
+	Items      []MomentItem `json:"items"`
+	NextCursor uint         `json:"next_cursor"` // 下一页请求传入的 cursor，0 表示没有更多
+	HasMore    bool         `json:"has_more"`
+}
+
 // Comment 请求与响应
 type CreateCommentRequest struct {
-	Content string `json:"content" binding:"required"`
+	Content   string `json:"content" binding:"required"`
+	ReplyToID uint   `json:"reply_to_id"` // 回复的评论 ID，0 表示直接评论动态
 }
 
 type MomentCommentItem struct {
@@ -40,6 +61,13 @@ type MomentCommentItem struct {
 	Avatar    string `json:"avatar"`
 	Content   string `json:"content"`
 	CreatedAt int64  `json:"created_at"`
+	// ParentID 所属顶层评论，0 表示顶层评论；ReplyTo* 为被回复的人（展示“A 回复 B”）
+	ParentID        uint   `json:"parent_id"`
+	ReplyToUserID   uint   `json:"reply_to_user_id,omitempty"`
+	ReplyToNickname string `json:"reply_to_nickname,omitempty"`
+	// Replies 顶层评论的回复预览，完整列表通过 replies 接口分页获取
+	Replies    []MomentCommentItem `json:"replies,omitempty"`
+	ReplyCount int64               `json:"reply_count"`
 }
 
 type MomentCommentListResponse struct {
@@ -0,0 +1,64 @@
+package model
+
+// NotificationItem 单条通知
+type NotificationItem struct {
+	ID            uint   `json:"id"`
+	Type          string `json:"type"`
+	ActorID       uint   `json:"actor_id"`
+	ActorNickname string `json:"actor_nickname"`
+	ActorAvatar   string `json:"actor_avatar"`
+	TargetID      uint   `json:"target_id"`
+	SubjectID     uint   `json:"subject_id"`
+	Content       string `json:"content"`
+	IsRead        bool   `json:"is_read"`
+	CreatedAt     int64  `json:"created_at"`
+}
+
+type NotificationListResponse struct {
+	Items    []NotificationItem `json:"items"`
+	Total    int64              `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+}
+
+// NotificationActor 聚合项中的触发人
+type NotificationActor struct {
+	UserID   uint   `json:"user_id"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+}
+
+// NotificationGroupItem 聚合通知（如 "3 people liked your moment"）
+type NotificationGroupItem struct {
+	Type       string              `json:"type"`
+	TargetID   uint                `json:"target_id"`
+	ActorCount int64               `json:"actor_count"`
+	Actors     []NotificationActor `json:"actors"`
+	Summary    string              `json:"summary"`
+	Unread     int64               `json:"unread"`
+	LastID     uint                `json:"last_id"`
+	LastAt     int64               `json:"last_at"`
+}
+
+type NotificationGroupListResponse struct {
+	Items    []NotificationGroupItem `json:"items"`
+	Total    int64                   `json:"total"`
+	Page     int                     `json:"page"`
+	PageSize int                     `json:"page_size"`
+}
+
+type NotificationUnreadResponse struct {
+	UnreadCount int64 `json:"unread_count"`
+}
+
+// MarkNotificationsReadRequest 按 ID 标记已读，或按聚合项 (type, target_id) 标记已读
+type MarkNotificationsReadRequest struct {
+	IDs      []uint `json:"ids"`
+	Type     string `json:"type"`
+	TargetID uint   `json:"target_id"`
+}
+
+// NotificationPreferences 各类型通知开关（键为通知类型）
+type NotificationPreferences struct {
+	Preferences map[string]bool `json:"preferences" binding:"required"`
+}
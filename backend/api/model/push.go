@@ -0,0 +1,27 @@
+package model
+
+// RegisterDeviceRequest 注册推送设备
+type RegisterDeviceRequest struct {
+	Platform string `json:"platform" binding:"required"` // apns / fcm
+	Token    string `json:"token" binding:"required"`
+}
+
+type PushDeviceItem struct {
+	ID        uint   `json:"id"`
+	Platform  string `json:"platform"`
+	Token     string `json:"token"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// MuteRequest 设置免打扰；target_type 为 all / user / group，minutes 为 0 表示一直免打扰
+type MuteRequest struct {
+	TargetType string `json:"target_type" binding:"required"`
+	TargetID   uint   `json:"target_id"`
+	Minutes    int    `json:"minutes"`
+}
+
+type MuteItem struct {
+	TargetType string `json:"target_type"`
+	TargetID   uint   `json:"target_id"`
+	Until      int64  `json:"until"` // 0 表示一直免打扰
+}
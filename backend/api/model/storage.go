@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+// StorageObject 对象详情
+type StorageObject struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	ContentType  string            `json:"content_type"`
+	ETag         string            `json:"etag"`
+	LastModified time.Time         `json:"last_modified"`
+	Metadata     map[string]string `json:"metadata"`
+	Tags         map[string]string `json:"tags"`
+}
+
+// UpdateObjectMetadataRequest 替换对象的自定义元数据
+type UpdateObjectMetadataRequest struct {
+	// ContentType 为空时保留原类型
+	ContentType string `json:"content_type" binding:"omitempty,max=100"`
+	// Metadata 整体替换，键仅允许字母、数字、- 与 _，不区分大小写
+	Metadata map[string]string `json:"metadata"`
+}
+
+// SetObjectTagsRequest 替换对象标签，为空时清除
+type SetObjectTagsRequest struct {
+	Tags map[string]string `json:"tags"`
+}
+
+// TransferObjectRequest 复制 / 移动（重命名）对象
+type TransferObjectRequest struct {
+	// Key 源对象，以 / 结尾时处理该“文件夹”下的全部对象
+	Key string `json:"key" binding:"required,max=1024"`
+	// DestBucket 目标 bucket，为空表示同一 bucket
+	DestBucket string `json:"dest_bucket" binding:"omitempty,max=63"`
+	// DestKey 目标对象名；以 / 结尾或为空时放入该“文件夹”（或根目录）并保留原文件名
+	DestKey string `json:"dest_key" binding:"max=1024"`
+}
+
+// TransferObjectResponse 复制 / 移动结果
+type TransferObjectResponse struct {
+	Objects int `json:"objects"` // 处理的对象数
+}
+
+// DeleteObjectsRequest 批量删除对象
+type DeleteObjectsRequest struct {
+	Keys []string `json:"keys" binding:"required,min=1,max=1000,dive,required"`
+}
+
+// ObjectError 单个对象的失败原因
+type ObjectError struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// DeleteObjectsResponse 批量删除结果
+type DeleteObjectsResponse struct {
+	Deleted int           `json:"deleted"`
+	Failed  []ObjectError `json:"failed"`
+}
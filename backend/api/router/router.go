@@ -21,6 +21,13 @@ type Router struct {
 	chatHub           *chathdl.Hub
 	storageHandler    *handler.StorageHandler
 	momentHandler     *handler.MomentHandler
+	moderationHandler *handler.ModerationHandler
+	reportHandler     *handler.ReportHandler
+	notifyHandler     *handler.NotificationHandler
+	pushHandler       *handler.PushHandler
+	uploadHandler     *handler.UploadHandler
+	fileHandler       *handler.FileHandler
+	quotaHandler      *handler.QuotaHandler
 }
 
 func NewRouter(
@@ -32,8 +39,16 @@ func NewRouter(
 ) *Router {
 	// 初始化聊天 Hub（基于应用层 ChatSvc）
 	hub := chathdl.NewHub(application.ChatSvc, application.AppUserSvc)
+	application.Realtime.Attach(hub)
 	storageHandler := handler.NewStorageHandler()
 	momentHandler := handler.NewMomentHandler(application.MomentSvc)
+	moderationHandler := handler.NewModerationHandler(application.ModerationSvc)
+	reportHandler := handler.NewReportHandler(application.ReportSvc)
+	notifyHandler := handler.NewNotificationHandler(application.NotificationSvc)
+	pushHandler := handler.NewPushHandler(application.PushSvc)
+	uploadHandler := handler.NewUploadHandler(application.UploadSvc)
+	fileHandler := handler.NewFileHandler()
+	quotaHandler := handler.NewQuotaHandler(application.QuotaSvc, application.AppUserSvc)
 	return &Router{
 		userHandler:       userHandler,
 		appUserHandler:    appUserHandler,
@@ -43,17 +58,35 @@ func NewRouter(
 		chatHub:           hub,
 		storageHandler:    storageHandler,
 		momentHandler:     momentHandler,
+		moderationHandler: moderationHandler,
+		reportHandler:     reportHandler,
+		notifyHandler:     notifyHandler,
+		pushHandler:       pushHandler,
+		uploadHandler:     uploadHandler,
+		fileHandler:       fileHandler,
+		quotaHandler:      quotaHandler,
 	}
 }
 
 func (r *Router) SetupRoutes() *gin.Engine {
 	router := gin.New()
+	// 超过该大小的表单文件由 net/http 暂存到磁盘，上传时直接流式写入对象存储
+	router.MaxMultipartMemory = 8 << 20
 
 	// 全局中间件
 	router.Use(middleware.LoggerMiddleware())
 	router.Use(middleware.CORSMiddleware())
 	router.Use(gin.Recovery())
 
+	// local / memory 存储驱动的对象访问与直传（storage.base-url 指向此处），鉴权依赖签名链接
+	files := router.Group("/files")
+	{
+		files.GET("/:bucket/*object", r.fileHandler.Get)
+		files.HEAD("/:bucket/*object", r.fileHandler.Get)
+		files.PUT("/:bucket/*object", r.fileHandler.Put)
+		files.POST("/:bucket", r.fileHandler.Post)
+	}
+
 	// API路由组 (添加公共与受保护子组)
 	v1 := router.Group("/api/v1")
 
@@ -94,15 +127,49 @@ func (r *Router) SetupRoutes() *gin.Engine {
 		{
 			buckets := storage.Group("/buckets")
 			{
-				buckets.GET("", r.storageHandler.ListBuckets)
-				buckets.POST(":bucket", r.storageHandler.CreateBucket)
-				buckets.DELETE(":bucket", r.storageHandler.DeleteBucket)
-				buckets.GET(":bucket/objects", r.storageHandler.ListObjects)
-				buckets.POST(":bucket/objects", r.storageHandler.UploadObject)
-				buckets.DELETE(":bucket/objects/:object", r.storageHandler.DeleteObject)
-				buckets.GET(":bucket/objects/:object/url", r.storageHandler.GetObjectPresigned)
-				buckets.POST(":bucket/public", r.storageHandler.SetBucketPublic)
+				buckets.GET("", middleware.RequirePerm(application.PermissionSvc, "system:storage:bucket:list"), r.storageHandler.ListBuckets)
+				buckets.POST(":bucket", middleware.RequirePerm(application.PermissionSvc, "system:storage:bucket:create"), r.storageHandler.CreateBucket)
+				buckets.DELETE(":bucket", middleware.RequirePerm(application.PermissionSvc, "system:storage:bucket:delete"), r.storageHandler.DeleteBucket)
+				buckets.POST(":bucket/public", middleware.RequirePerm(application.PermissionSvc, "system:storage:bucket:update"), r.storageHandler.SetBucketPublic)
+				buckets.GET(":bucket/objects", middleware.RequirePerm(application.PermissionSvc, "system:storage:object:list"), r.storageHandler.ListObjects)
+				buckets.POST(":bucket/objects", middleware.RequirePerm(application.PermissionSvc, "system:storage:object:upload"), r.storageHandler.UploadObject)
+				buckets.POST(":bucket/objects/delete", middleware.RequirePerm(application.PermissionSvc, "system:storage:object:delete"), r.storageHandler.DeleteObjects)
+				buckets.DELETE(":bucket/objects/:object", middleware.RequirePerm(application.PermissionSvc, "system:storage:object:delete"), r.storageHandler.DeleteObject)
+				buckets.GET(":bucket/objects/:object/url", middleware.RequirePerm(application.PermissionSvc, "system:storage:object:get"), r.storageHandler.GetObjectPresigned)
+				// 单个对象：对象名（可含 /）通过查询参数 key 传递
+				buckets.GET(":bucket/object", middleware.RequirePerm(application.PermissionSvc, "system:storage:object:get"), r.storageHandler.GetObject)
+				buckets.GET(":bucket/object/url", middleware.RequirePerm(application.PermissionSvc, "system:storage:object:get"), r.storageHandler.GetObjectPresigned)
+				buckets.DELETE(":bucket/object", middleware.RequirePerm(application.PermissionSvc, "system:storage:object:delete"), r.storageHandler.DeleteObject)
+				buckets.PUT(":bucket/object/metadata", middleware.RequirePerm(application.PermissionSvc, "system:storage:object:update"), r.storageHandler.UpdateObjectMetadata)
+				buckets.PUT(":bucket/object/tags", middleware.RequirePerm(application.PermissionSvc, "system:storage:object:update"), r.storageHandler.SetObjectTags)
+				buckets.POST(":bucket/object/copy", middleware.RequirePerm(application.PermissionSvc, "system:storage:object:copy"), r.storageHandler.CopyObject)
+				buckets.POST(":bucket/object/move", middleware.RequirePerm(application.PermissionSvc, "system:storage:object:move"), r.storageHandler.MoveObject)
 			}
+			storage.POST("/lifecycle/run", middleware.RequirePerm(application.PermissionSvc, "system:storage:lifecycle"), r.storageHandler.RunLifecycle)
+			storage.GET("/quotas/:user_id", middleware.RequirePerm(application.PermissionSvc, "system:storage:quota:list"), r.quotaHandler.GetQuota)
+			storage.PUT("/quotas/:user_id", middleware.RequirePerm(application.PermissionSvc, "system:storage:quota:update"), r.quotaHandler.SetQuota)
+		}
+
+		// 内容审核（敏感词 + 待审队列）
+		moderation := protected.Group("/moderation")
+		{
+			moderation.GET("/words", middleware.RequirePerm(application.PermissionSvc, "system:moderation:word:list"), r.moderationHandler.ListWords)
+			moderation.POST("/words", middleware.RequirePerm(application.PermissionSvc, "system:moderation:word:create"), r.moderationHandler.AddWords)
+			moderation.POST("/words/reload", middleware.RequirePerm(application.PermissionSvc, "system:moderation:word:update"), r.moderationHandler.ReloadWords)
+			moderation.PUT("/words/:id", middleware.RequirePerm(application.PermissionSvc, "system:moderation:word:update"), r.moderationHandler.UpdateWord)
+			moderation.DELETE("/words/:id", middleware.RequirePerm(application.PermissionSvc, "system:moderation:word:delete"), r.moderationHandler.DeleteWord)
+			moderation.GET("/flags", middleware.RequirePerm(application.PermissionSvc, "system:moderation:flag:list"), r.moderationHandler.ListFlags)
+			moderation.POST("/flags/:id/review", middleware.RequirePerm(application.PermissionSvc, "system:moderation:flag:review"), r.moderationHandler.ReviewFlag)
+
+			// 举报处理
+			moderation.GET("/reports", middleware.RequirePerm(application.PermissionSvc, "system:moderation:report:list"), r.reportHandler.ListReports)
+			moderation.GET("/reports/:id", middleware.RequirePerm(application.PermissionSvc, "system:moderation:report:list"), r.reportHandler.GetReport)
+			moderation.POST("/reports/:id/triage", middleware.RequirePerm(application.PermissionSvc, "system:moderation:report:triage"), r.reportHandler.TriageReport)
+			moderation.POST("/reports/:id/resolve", middleware.RequirePerm(application.PermissionSvc, "system:moderation:report:resolve"), r.reportHandler.ResolveReport)
+			moderation.POST("/reports/:id/delete-content", middleware.RequirePerm(application.PermissionSvc, "system:moderation:content:delete"), r.reportHandler.DeleteReportedContent)
+			moderation.POST("/app-users/:user_id/ban", middleware.RequirePerm(application.PermissionSvc, "system:moderation:user:ban"), r.reportHandler.BanAppUser)
+			moderation.POST("/app-users/:user_id/unban", middleware.RequirePerm(application.PermissionSvc, "system:moderation:user:ban"), r.reportHandler.UnbanAppUser)
+			moderation.GET("/audit-logs", middleware.RequirePerm(application.PermissionSvc, "system:moderation:audit:list"), r.reportHandler.ListAuditLogs)
 		}
 
 	}
@@ -114,28 +181,81 @@ func (r *Router) SetupRoutes() *gin.Engine {
 		app.POST("/login", r.appUserHandler.AppLogin)
 
 		appProtected := app.Group("")
-		appProtected.Use(middleware.AppJWTAuth())
+		appProtected.Use(middleware.AppJWTAuth(), middleware.RequireActiveAppUser(application.AppUserSvc))
 		{
 			appProtected.GET("/profile", r.appUserHandler.AppProfile)
 			appProtected.PUT("/profile", r.appUserHandler.AppUpdateProfile)
 			appProtected.POST("/profile/avatar", r.appUserHandler.AppUploadAvatar)
+			appProtected.PUT("/profile/handle", r.appUserHandler.AppUpdateHandle)
+			appProtected.GET("/profile/privacy", r.appUserHandler.AppGetPrivacy)
+			appProtected.PUT("/profile/privacy", r.appUserHandler.AppUpdatePrivacy)
+			appProtected.GET("/users/search", r.appUserHandler.SearchUsers)
 			appProtected.POST("/friends/request", r.appUserHandler.RequestFriend)
 			appProtected.GET("/friends", r.appUserHandler.ListFriends)
+			appProtected.GET("/friends/suggestions", r.appUserHandler.ListFriendSuggestions)
 			appProtected.GET("/friends/requests", r.appUserHandler.ListPendingRequests)
+			appProtected.GET("/friends/requests/outgoing", r.appUserHandler.ListOutgoingRequests)
 			appProtected.POST("/friends/requests/:request_id/accept", r.appUserHandler.AcceptFriendRequest)
 			appProtected.POST("/friends/requests/:request_id/decline", r.appUserHandler.DeclineFriendRequest)
+			appProtected.POST("/friends/requests/:request_id/cancel", r.appUserHandler.CancelFriendRequest)
+			appProtected.DELETE("/friends/:friend_id", r.appUserHandler.RemoveFriend)
+			appProtected.PUT("/friends/:friend_id/remark", r.appUserHandler.SetFriendRemark)
+			appProtected.GET("/friends/tags", r.appUserHandler.ListFriendTags)
+			appProtected.POST("/friends/tags", r.appUserHandler.CreateFriendTag)
+			appProtected.PUT("/friends/tags/:tag_id", r.appUserHandler.UpdateFriendTag)
+			appProtected.DELETE("/friends/tags/:tag_id", r.appUserHandler.DeleteFriendTag)
+			appProtected.PUT("/friends/tags/:tag_id/members", r.appUserHandler.SetFriendTagMembers)
+			appProtected.POST("/blocks", r.appUserHandler.BlockUser)
+			appProtected.GET("/blocks", r.appUserHandler.ListBlocked)
+			appProtected.DELETE("/blocks/:user_id", r.appUserHandler.UnblockUser)
 
 			// Moments
 			appProtected.POST("/moments", r.momentHandler.PostMoment)
 			appProtected.GET("/moments", r.momentHandler.ListMoments)
+			appProtected.GET("/moments/public", r.momentHandler.ListPublicMoments)
+			appProtected.GET("/moments/:moment_id", r.momentHandler.GetMoment)
 			appProtected.DELETE("/moments/:moment_id", r.momentHandler.DeleteMoment)
 			appProtected.POST("/moments/images", r.momentHandler.UploadImage)
+			appProtected.POST("/moments/videos", r.momentHandler.UploadVideo)
+			// 大文件分片上传（断点续传）
+			appProtected.POST("/uploads/multipart", r.uploadHandler.InitMultipart)
+			appProtected.GET("/uploads/multipart/:upload_id", r.uploadHandler.GetMultipart)
+			appProtected.PUT("/uploads/multipart/:upload_id/parts/:part_number", r.uploadHandler.UploadPart)
+			appProtected.POST("/uploads/multipart/:upload_id/complete", r.uploadHandler.CompleteMultipart)
+			appProtected.DELETE("/uploads/multipart/:upload_id", r.uploadHandler.AbortUpload)
+			// 客户端直传对象存储
+			appProtected.POST("/uploads/presigned", r.uploadHandler.PresignUpload)
+			appProtected.POST("/uploads/presigned/:upload_id/complete", r.uploadHandler.CompletePresigned)
+			appProtected.DELETE("/uploads/presigned/:upload_id", r.uploadHandler.AbortUpload)
+			appProtected.GET("/storage/usage", r.quotaHandler.MyUsage)
 			appProtected.POST("/moments/:moment_id/like", r.momentHandler.LikeMoment)
 			appProtected.DELETE("/moments/:moment_id/like", r.momentHandler.UnlikeMoment)
 			appProtected.POST("/moments/:moment_id/comments", r.momentHandler.AddComment)
 			appProtected.GET("/moments/:moment_id/comments", r.momentHandler.ListComments)
+			appProtected.DELETE("/moments/:moment_id/comments/:comment_id", r.momentHandler.DeleteComment)
+			appProtected.GET("/moments/:moment_id/comments/:comment_id/replies", r.momentHandler.ListCommentReplies)
 			appProtected.GET("/users/:user_id/moments", r.momentHandler.ListUserMoments)
 
+			// Reports
+			appProtected.POST("/reports", r.reportHandler.CreateReport)
+
+			// Notifications
+			appProtected.GET("/notifications", r.notifyHandler.ListNotifications)
+			appProtected.GET("/notifications/grouped", r.notifyHandler.ListNotificationGroups)
+			appProtected.GET("/notifications/unread-count", r.notifyHandler.UnreadCount)
+			appProtected.POST("/notifications/read", r.notifyHandler.MarkRead)
+			appProtected.POST("/notifications/read-all", r.notifyHandler.MarkAllRead)
+			appProtected.GET("/notifications/preferences", r.notifyHandler.GetPreferences)
+			appProtected.PUT("/notifications/preferences", r.notifyHandler.UpdatePreferences)
+
+			// Push devices & mute settings
+			appProtected.POST("/push/devices", r.pushHandler.RegisterDevice)
+			appProtected.GET("/push/devices", r.pushHandler.ListDevices)
+			appProtected.DELETE("/push/devices/:token", r.pushHandler.UnregisterDevice)
+			appProtected.GET("/push/mutes", r.pushHandler.ListMutes)
+			appProtected.PUT("/push/mutes", r.pushHandler.Mute)
+			appProtected.DELETE("/push/mutes/:target_type/:target_id", r.pushHandler.Unmute)
+
 			// Chat routes
 			chat := appProtected.Group("/chat")
 			{
@@ -145,6 +265,8 @@ func (r *Router) SetupRoutes() *gin.Engine {
 				chat.GET("/conversations", r.chatHub.Conversations)
 				chat.POST("/images", r.chatHub.UploadImage)
 				chat.POST("/videos", r.chatHub.UploadVideo)
+				chat.GET("/media/:media_id", r.chatHub.Media)
+				chat.POST("/messages/:message_id/recall", r.chatHub.Recall)
 
 				// Group chat
 				gh := chathdl.NewGroupHandler()
@@ -152,6 +274,7 @@ func (r *Router) SetupRoutes() *gin.Engine {
 				chat.GET("/groups/search", gh.SearchGroups)
 				chat.POST("/groups/:group_id/join", gh.JoinGroup)
 				chat.GET("/groups/:group_id/messages", gh.GroupMessages)
+				chat.POST("/groups/:group_id/messages/:message_id/recall", gh.RecallGroupMessage)
 				chat.POST("/groups/read", gh.MarkReadGroup)
 				chat.PUT("/groups/:group_id", gh.UpdateGroup)
 				chat.GET("/groups/:group_id/members", gh.ListMembers)
@@ -2,11 +2,16 @@ package application
 
 import (
 	"context"
+	"time"
 
 	appfriendservice "alice/domain/appfriend/service"
 	appuserservice "alice/domain/appuser/service"
 	chatservice "alice/domain/chat/service"
+	mediaservice "alice/domain/media/service"
+	moderationservice "alice/domain/moderation/service"
 	momentservice "alice/domain/moment/service"
+	notifyservice "alice/domain/notification/service"
+	pushservice "alice/domain/push/service"
 	rbacService "alice/domain/rbac/service"
 	"alice/domain/user/service"
 	"alice/infra/config"
@@ -15,6 +20,9 @@ import (
 	chatrepo "alice/infra/repository/chat"
 	"alice/infra/storage"
 	"alice/pkg/logger"
+	"alice/pkg/push"
+	"alice/pkg/realtime"
+	"alice/pkg/scan"
 )
 
 var (
@@ -28,6 +36,20 @@ var (
 	GroupSvc   chatservice.GroupService
 	MomentSvc  momentservice.MomentService
 
+	// MediaSvc 媒体附件元数据（动态图片、聊天图片/视频）
+	MediaSvc mediaservice.MediaService
+	// QuotaSvc App 用户存储用量与配额
+	QuotaSvc mediaservice.QuotaService
+
+	// NotificationSvc 通知中心（好友/动态/群聊等服务向其投递）
+	NotificationSvc notifyservice.NotificationService
+	// PushSvc 离线推送（挂在 Realtime 上，用户不在线时触发）
+	PushSvc pushservice.PushService
+
+	// 内容审核
+	ModerationSvc moderationservice.ModerationService
+	ReportSvc     moderationservice.ReportService
+
 	// RBAC 服务实例
 	RoleSvc       rbacService.RoleService
 	PermissionSvc rbacService.PermissionService
@@ -35,6 +57,15 @@ var (
 
 	// 对象存储
 	ObjectStore storage.ObjectStorage
+	// UploadSvc 统一上传入口（按 upload-profiles 场景配置）
+	UploadSvc mediaservice.UploadService
+	// LifecycleSvc 对象生命周期（未引用对象清理与按 bucket 过期）
+	LifecycleSvc mediaservice.LifecycleService
+	// URLs 相对路径 /bucket/object 转完整访问 URL
+	URLs *storage.URLResolver
+
+	// Realtime 实时事件分发（WebSocket Hub 在路由初始化时挂载）
+	Realtime = realtime.NewDispatcher()
 )
 
 // Init 初始化应用
@@ -52,6 +83,11 @@ func Init(ctx context.Context, cfg *config.Config) error {
 	momentRepo := repository.NewMomentRepository(db)
 	msgRepo := chatrepo.NewMessageRepository(db)
 	groupRepo := chatrepo.NewGroupRepository(db)
+	moderationRepo := repository.NewModerationRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	pushRepo := repository.NewPushRepository(db)
+	mediaRepo := repository.NewMediaRepository(db)
 
 	// 初始化RBAC仓储
 	roleRepo := repository.NewRoleRepository(db)
@@ -60,29 +96,132 @@ func Init(ctx context.Context, cfg *config.Config) error {
 
 	// 初始化服务
 	UserSvc = service.NewUserService(userRepo)
-	AppUserSvc = appuserservice.NewAppUserService(appUserRepo)
-	FriendSvc = appfriendservice.NewFriendService(appUserRepo, friendRepo)
-	ChatSvc = chatservice.NewChatService(msgRepo, friendRepo)
-	GroupSvc = chatservice.NewGroupService(groupRepo)
-	MomentSvc = momentservice.NewMomentService(momentRepo)
+	ModerationSvc = moderationservice.NewModerationService(moderationRepo, cfg.Moderation.WordsFile)
+	MediaSvc = mediaservice.NewMediaService(mediaRepo)
+	QuotaSvc = mediaservice.NewQuotaService(mediaRepo, cfg)
+	AppUserSvc = appuserservice.NewAppUserService(appUserRepo, ModerationSvc, MediaSvc)
+	NotificationSvc = notifyservice.NewNotificationService(notificationRepo, appUserRepo, friendRepo, Realtime)
+	FriendSvc = appfriendservice.NewFriendService(appUserRepo, friendRepo, Realtime, NotificationSvc, time.Duration(cfg.Friend.RequestTTLHours)*time.Hour)
+	ChatSvc = chatservice.NewChatService(msgRepo, groupRepo, friendRepo, ModerationSvc, MediaSvc)
+	GroupSvc = chatservice.NewGroupService(groupRepo, msgRepo, friendRepo, ModerationSvc, NotificationSvc, MediaSvc)
+	MomentSvc = momentservice.NewMomentService(momentRepo, friendRepo, ModerationSvc, NotificationSvc, MediaSvc, cfg.Moment.FanoutMaxFriends)
+	FriendSvc.AddObserver(MomentSvc)
+	PushSvc = pushservice.NewPushService(pushRepo, groupRepo, newPushProvider(cfg.Push), time.Duration(cfg.Push.TimeoutSeconds)*time.Second)
+	Realtime.OnOffline(PushSvc)
+	ReportSvc = moderationservice.NewReportService(reportRepo, appUserRepo, msgRepo, groupRepo, momentRepo, MediaSvc)
+
+	// 敏感词库热加载
+	go ModerationSvc.Watch(ctx, time.Duration(cfg.Moderation.ReloadIntervalSeconds)*time.Second)
+
+	// 后台定时任务
+	startJobs(ctx, cfg)
 
 	// 初始化RBAC服务
 	RoleSvc = rbacService.NewRoleService(roleRepo)
 	PermissionSvc = rbacService.NewPermissionService(permissionRepo)
 	MenuSvc = rbacService.NewMenuService(menuRepo, permissionRepo)
 
-	// 初始化对象存储（MinIO）
-	if cfg.Minio.Endpoint != "" {
-		minioCli, err := storage.NewMinio(cfg.Minio)
-		if err != nil {
-			logger.Errorf("init minio failed: %v", err)
-		} else {
-			ObjectStore = minioCli
-			_ = minioCli.HealthCheck(ctx)
-			logger.Info("MinIO storage initialized")
+	// 初始化对象存储（按 storage.driver 选择 MinIO / 本地目录 / 内存）
+	var baseURL string
+	ObjectStore, baseURL = newObjectStore(ctx, cfg)
+	URLs = storage.NewURLResolver(baseURL)
+	// 私有 bucket（聊天媒体）：移除公共读策略，读取时签发短时有效的预签名地址
+	URLs.SignPrivate(ObjectStore, cfg.PrivateBuckets(), time.Duration(cfg.Minio.SignedURLTTLSeconds)*time.Second)
+	if ObjectStore != nil {
+		for _, b := range cfg.PrivateBuckets() {
+			if err := ObjectStore.CreateBucket(ctx, b); err != nil {
+				logger.Errorf("create private bucket %s failed: %v", b, err)
+				continue
+			}
+			if err := ObjectStore.SetBucketPublic(ctx, b, false); err != nil {
+				logger.Warnf("set bucket %s private failed: %v", b, err)
+			}
 		}
 	}
+	UploadSvc = mediaservice.NewUploadService(ObjectStore, mediaRepo, newVirusScanner(cfg.Minio), QuotaSvc, cfg)
+	LifecycleSvc = mediaservice.NewLifecycleService(ObjectStore, mediaRepo, cfg)
 
 	logger.Info("Application initialized successfully")
 	return nil
 }
+
+// newObjectStore 按配置选择对象存储驱动，返回实例与公共读对象的基础地址；初始化失败时实例为 nil，上传接口返回存储未初始化
+func newObjectStore(ctx context.Context, cfg *config.Config) (storage.ObjectStorage, string) {
+	switch cfg.Storage.Driver {
+	case "local", "memory":
+		var store interface {
+			storage.ObjectStorage
+			storage.LinkServer
+		}
+		if cfg.Storage.Driver == "local" {
+			local, err := storage.NewLocal(cfg.Storage.LocalRoot, cfg.Storage.BaseURL, []byte(cfg.Storage.SigningSecret))
+			if err != nil {
+				logger.Errorf("init local storage failed: %v", err)
+				return nil, cfg.Storage.BaseURL
+			}
+			store = local
+		} else {
+			store = storage.NewMemory(cfg.Storage.BaseURL, []byte(cfg.Storage.SigningSecret))
+		}
+		// 开发驱动下非私有场景的 bucket 默认公共读，与生产 MinIO 上配置的策略一致
+		for _, p := range cfg.UploadProfiles {
+			if p.Bucket == "" || p.Private {
+				continue
+			}
+			if err := store.CreateBucket(ctx, p.Bucket); err == nil {
+				_ = store.SetBucketPublic(ctx, p.Bucket, true)
+			}
+		}
+		logger.Infof("%s storage initialized", cfg.Storage.Driver)
+		return store, cfg.Storage.BaseURL
+	case "minio", "":
+	default:
+		logger.Errorf("unknown storage driver %q, falling back to minio", cfg.Storage.Driver)
+	}
+	base := storage.MinioBaseURL(cfg.Minio)
+	if cfg.Minio.Endpoint == "" {
+		return nil, base
+	}
+	minioCli, err := storage.NewMinio(cfg.Minio)
+	if err != nil {
+		logger.Errorf("init minio failed: %v", err)
+		return nil, base
+	}
+	_ = minioCli.HealthCheck(ctx)
+	logger.Info("MinIO storage initialized")
+	return minioCli, base
+}
+
+// newVirusScanner minio.enable-virus-scan 开启时按配置选择扫描引擎，未开启返回 nil；未知引擎回退为 clamd，避免静默放行
+func newVirusScanner(cfg config.MinioConfig) scan.Scanner {
+	if !cfg.EnableVirusScan {
+		return nil
+	}
+	switch cfg.VirusScanner {
+	case "eicar":
+		logger.Warnf("virus scanner eicar only detects the EICAR test file, do not use in production")
+		return scan.NewEICARScanner()
+	case "clamd", "":
+	default:
+		logger.Errorf("unknown virus scanner %q, using clamd", cfg.VirusScanner)
+	}
+	return scan.NewClamdScanner(cfg.ClamdAddress, time.Duration(cfg.VirusScanTimeoutSeconds)*time.Second)
+}
+
+// newPushProvider 按配置选择推送通道，none 或未知值时不推送
+func newPushProvider(cfg config.PushConfig) push.Provider {
+	switch cfg.Provider {
+	case "http":
+		if cfg.Endpoint == "" {
+			logger.Errorf("push provider http requires endpoint, push disabled")
+			return nil
+		}
+		return push.NewHTTPProvider(cfg.Endpoint, time.Duration(cfg.TimeoutSeconds)*time.Second)
+	case "recording":
+		return push.NewRecordingProvider()
+	case "none", "":
+		return nil
+	}
+	logger.Errorf("unknown push provider %q, push disabled", cfg.Provider)
+	return nil
+}
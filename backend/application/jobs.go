@@ -0,0 +1,86 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"alice/infra/config"
+	"alice/pkg/logger"
+)
+
+// startJobs 启动后台定时任务，随 ctx 取消而退出
+func startJobs(ctx context.Context, cfg *config.Config) {
+	go every(ctx, 10*time.Minute, "expire friend requests", func() error {
+		n, err := FriendSvc.ExpireRequests()
+		if err == nil && n > 0 {
+			logger.Infof("expired %d friend requests", n)
+		}
+		return err
+	})
+	go every(ctx, time.Duration(cfg.Friend.SuggestionRefreshMinutes)*time.Minute, "refresh friend suggestions", func() error {
+		n, err := FriendSvc.RefreshSuggestions()
+		if err == nil {
+			logger.Infof("refreshed friend suggestions for %d users", n)
+		}
+		return err
+	})
+	go every(ctx, time.Hour, "clean up expired uploads", func() error {
+		n, err := UploadSvc.CleanupExpired(ctx)
+		if err == nil && n > 0 {
+			logger.Infof("cleaned up %d expired uploads", n)
+		}
+		return err
+	})
+	go every(ctx, time.Hour, "collect orphaned media", func() error {
+		n, err := UploadSvc.CollectOrphans(ctx)
+		if err == nil && n > 0 {
+			logger.Infof("collected %d orphaned media objects", n)
+		}
+		return err
+	})
+	if lc := cfg.Storage.Lifecycle; lc.Enabled {
+		go every(ctx, time.Duration(lc.IntervalMinutes)*time.Minute, "storage lifecycle", func() error {
+			report, err := LifecycleSvc.Run(ctx, lc.DryRun)
+			if err != nil {
+				return err
+			}
+			for _, b := range report.Buckets {
+				if b.Error != "" {
+					logger.Errorf("storage lifecycle %s: %s", b.Bucket, b.Error)
+					continue
+				}
+				if b.Orphaned+b.Expired == 0 {
+					continue
+				}
+				if report.DryRun {
+					// 试运行：列出将被删除的对象，便于确认后关闭 dry-run
+					logger.Infof("storage lifecycle (dry run) %s: scanned %d, would delete %d orphaned and %d expired objects (%d bytes)",
+						b.Bucket, b.Scanned, b.Orphaned, b.Expired, b.Bytes)
+					for _, it := range b.Items {
+						logger.Infof("  %s %s/%s (%d bytes, %s)", it.Reason, b.Bucket, it.Key, it.Size, it.LastModified.Format(time.RFC3339))
+					}
+					continue
+				}
+				logger.Infof("storage lifecycle %s: scanned %d, deleted %d of %d orphaned and %d expired objects (%d bytes), %d failed",
+					b.Bucket, b.Scanned, b.Deleted, b.Orphaned, b.Expired, b.Bytes, b.Failed)
+			}
+			return nil
+		})
+	}
+}
+
+// every 按固定周期执行 fn，出错仅记录日志
+func every(ctx context.Context, interval time.Duration, name string, fn func() error) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := fn(); err != nil {
+				logger.Errorf("job %s failed: %v", name, err)
+			}
+		}
+	}
+}
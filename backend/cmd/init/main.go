@@ -214,9 +214,31 @@ func initPermissions(ctx context.Context, db *gorm.DB, permissionService rbacSer
 		{Name: "存储-Bucket列表", Code: "system:storage:bucket:list", MenuID: getMenuID("system:storage"), Resource: "storage_bucket", Action: "list", Status: entity.PermissionStatusActive},
 		{Name: "存储-Bucket创建", Code: "system:storage:bucket:create", MenuID: getMenuID("system:storage"), Resource: "storage_bucket", Action: "create", Status: entity.PermissionStatusActive},
 		{Name: "存储-Bucket删除", Code: "system:storage:bucket:delete", MenuID: getMenuID("system:storage"), Resource: "storage_bucket", Action: "delete", Status: entity.PermissionStatusActive},
+		{Name: "存储-Bucket访问策略", Code: "system:storage:bucket:update", MenuID: getMenuID("system:storage"), Resource: "storage_bucket", Action: "update", Status: entity.PermissionStatusActive},
 		{Name: "存储-对象列表", Code: "system:storage:object:list", MenuID: getMenuID("system:storage"), Resource: "storage_object", Action: "list", Status: entity.PermissionStatusActive},
+		{Name: "存储-对象详情与下载", Code: "system:storage:object:get", MenuID: getMenuID("system:storage"), Resource: "storage_object", Action: "get", Status: entity.PermissionStatusActive},
 		{Name: "存储-对象上传", Code: "system:storage:object:upload", MenuID: getMenuID("system:storage"), Resource: "storage_object", Action: "upload", Status: entity.PermissionStatusActive},
 		{Name: "存储-对象删除", Code: "system:storage:object:delete", MenuID: getMenuID("system:storage"), Resource: "storage_object", Action: "delete", Status: entity.PermissionStatusActive},
+		{Name: "存储-对象元数据与标签", Code: "system:storage:object:update", MenuID: getMenuID("system:storage"), Resource: "storage_object", Action: "update", Status: entity.PermissionStatusActive},
+		{Name: "存储-对象复制", Code: "system:storage:object:copy", MenuID: getMenuID("system:storage"), Resource: "storage_object", Action: "copy", Status: entity.PermissionStatusActive},
+		{Name: "存储-对象移动与重命名", Code: "system:storage:object:move", MenuID: getMenuID("system:storage"), Resource: "storage_object", Action: "move", Status: entity.PermissionStatusActive},
+		{Name: "存储-生命周期清理", Code: "system:storage:lifecycle", MenuID: getMenuID("system:storage"), Resource: "storage_lifecycle", Action: "run", Status: entity.PermissionStatusActive},
+		{Name: "存储-配额查看", Code: "system:storage:quota:list", MenuID: getMenuID("system:storage"), Resource: "storage_quota", Action: "list", Status: entity.PermissionStatusActive},
+		{Name: "存储-配额调整", Code: "system:storage:quota:update", MenuID: getMenuID("system:storage"), Resource: "storage_quota", Action: "update", Status: entity.PermissionStatusActive},
+
+		// 内容审核 (system:moderation)
+		{Name: "审核-敏感词列表", Code: "system:moderation:word:list", MenuID: getMenuID("system:moderation"), Resource: "sensitive_word", Action: "list", Status: entity.PermissionStatusActive},
+		{Name: "审核-敏感词添加", Code: "system:moderation:word:create", MenuID: getMenuID("system:moderation"), Resource: "sensitive_word", Action: "create", Status: entity.PermissionStatusActive},
+		{Name: "审核-敏感词更新", Code: "system:moderation:word:update", MenuID: getMenuID("system:moderation"), Resource: "sensitive_word", Action: "update", Status: entity.PermissionStatusActive},
+		{Name: "审核-敏感词删除", Code: "system:moderation:word:delete", MenuID: getMenuID("system:moderation"), Resource: "sensitive_word", Action: "delete", Status: entity.PermissionStatusActive},
+		{Name: "审核-待审列表", Code: "system:moderation:flag:list", MenuID: getMenuID("system:moderation"), Resource: "moderation_flag", Action: "list", Status: entity.PermissionStatusActive},
+		{Name: "审核-待审复核", Code: "system:moderation:flag:review", MenuID: getMenuID("system:moderation"), Resource: "moderation_flag", Action: "review", Status: entity.PermissionStatusActive},
+		{Name: "审核-举报列表", Code: "system:moderation:report:list", MenuID: getMenuID("system:moderation"), Resource: "report", Action: "list", Status: entity.PermissionStatusActive},
+		{Name: "审核-举报受理", Code: "system:moderation:report:triage", MenuID: getMenuID("system:moderation"), Resource: "report", Action: "triage", Status: entity.PermissionStatusActive},
+		{Name: "审核-举报处理", Code: "system:moderation:report:resolve", MenuID: getMenuID("system:moderation"), Resource: "report", Action: "resolve", Status: entity.PermissionStatusActive},
+		{Name: "审核-删除违规内容", Code: "system:moderation:content:delete", MenuID: getMenuID("system:moderation"), Resource: "reported_content", Action: "delete", Status: entity.PermissionStatusActive},
+		{Name: "审核-封禁用户", Code: "system:moderation:user:ban", MenuID: getMenuID("system:moderation"), Resource: "app_user", Action: "ban", Status: entity.PermissionStatusActive},
+		{Name: "审核-操作日志", Code: "system:moderation:audit:list", MenuID: getMenuID("system:moderation"), Resource: "moderation_audit", Action: "list", Status: entity.PermissionStatusActive},
 	}
 
 	for _, req := range permissions {
@@ -358,6 +380,21 @@ func initMenus(ctx context.Context, db *gorm.DB, menuService rbacService.MenuSer
 		return fmt.Errorf("创建文件管理失败: %w", err)
 	}
 
+	// 内容审核
+	_, err = menuService.CreateMenu(ctx, &rbacService.CreateMenuRequest{
+		ParentID: &systemGroup.ID,
+		Name:     "内容审核",
+		Code:     "system:moderation",
+		Path:     stringPtr("/system/moderation"),
+		Type:     entity.MenuTypeMenu,
+		Order:    6,
+		Status:   entity.MenuStatusActive,
+		Meta:     entity.MenuMeta{Component: stringPtr("views/management/moderation/ModerationManagement")},
+	})
+	if err != nil {
+		return fmt.Errorf("创建内容审核菜单失败: %w", err)
+	}
+
 	fmt.Println("初始化菜单完成(精简版)")
 	return nil
 }
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// UserBlock 拉黑关系（单向）：UserID 拉黑了 BlockedID。
+// 被拉黑方不会收到任何提示，其私聊消息/好友请求/@提醒会被静默丢弃。
+type UserBlock struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:ux_user_block_pair,priority:1"`
+	BlockedID uint      `json:"blocked_id" gorm:"not null;index;uniqueIndex:ux_user_block_pair,priority:2"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (UserBlock) TableName() string { return "app_user_blocks" }
@@ -9,6 +9,8 @@ type FriendRelation struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
 	UserID    uint      `json:"user_id" gorm:"not null;index:idx_user_friend,priority:1;uniqueIndex:ux_user_friend_pair,priority:1"`
 	FriendID  uint      `json:"friend_id" gorm:"not null;index:idx_user_friend,priority:2;uniqueIndex:ux_user_friend_pair,priority:2"`
+	Alias     string    `json:"alias" gorm:"type:varchar(64);default:''"`  // UserID 给 FriendID 设置的备注名，仅自己可见
+	Notes     string    `json:"notes" gorm:"type:varchar(500);default:''"` // 备注描述
 	CreatedAt time.Time `json:"created_at"`
 }
 
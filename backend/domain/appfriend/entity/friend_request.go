@@ -5,18 +5,47 @@ import "time"
 type FriendRequestStatus string
 
 const (
-	FriendRequestPending  FriendRequestStatus = "pending"
-	FriendRequestAccepted FriendRequestStatus = "accepted"
-	FriendRequestDeclined FriendRequestStatus = "declined"
+	FriendRequestPending   FriendRequestStatus = "pending"
+	FriendRequestAccepted  FriendRequestStatus = "accepted"
+	FriendRequestDeclined  FriendRequestStatus = "declined"
+	FriendRequestCancelled FriendRequestStatus = "cancelled" // 申请人撤回
+	FriendRequestExpired   FriendRequestStatus = "expired"   // 超时未处理
 )
 
+// FriendRequestSource 好友申请来源
+type FriendRequestSource string
+
+const (
+	FriendSourceSearch FriendRequestSource = "search" // 搜索邮箱/账号
+	FriendSourceGroup  FriendRequestSource = "group"  // 群聊成员
+	FriendSourceQR     FriendRequestSource = "qr"     // 扫码
+	FriendSourceCard   FriendRequestSource = "card"   // 名片分享
+)
+
+// Valid 判断来源是否合法
+func (s FriendRequestSource) Valid() bool {
+	switch s {
+	case FriendSourceSearch, FriendSourceGroup, FriendSourceQR, FriendSourceCard:
+		return true
+	}
+	return false
+}
+
 type FriendRequest struct {
 	ID          uint                `json:"id" gorm:"primaryKey"`
 	RequesterID uint                `json:"requester_id" gorm:"not null;index"`
 	AddresseeID uint                `json:"addressee_id" gorm:"not null;index"`
 	Status      FriendRequestStatus `json:"status" gorm:"type:varchar(16);not null;default:'pending'"`
+	Message     string              `json:"message" gorm:"type:varchar(200);default:''"` // 验证消息/打招呼
+	Source      FriendRequestSource `json:"source" gorm:"type:varchar(16);not null;default:'search'"`
+	ExpiresAt   *time.Time          `json:"expires_at" gorm:"index"` // 为空表示不过期（兼容旧数据）
 	CreatedAt   time.Time           `json:"created_at"`
 	UpdatedAt   time.Time           `json:"updated_at"`
 }
 
 func (FriendRequest) TableName() string { return "app_friend_requests" }
+
+// IsExpired 是否已过期（仍为 pending 但超过有效期）
+func (r *FriendRequest) IsExpired(now time.Time) bool {
+	return r.ExpiresAt != nil && !r.ExpiresAt.After(now)
+}
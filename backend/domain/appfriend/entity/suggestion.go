@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// FriendSuggestion “可能认识的人”预计算结果，由定时任务按用户批量刷新
+type FriendSuggestion struct {
+	UserID        uint      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	CandidateID   uint      `json:"candidate_id" gorm:"primaryKey;autoIncrement:false"`
+	MutualFriends int       `json:"mutual_friends" gorm:"not null;default:0"`
+	SharedGroups  int       `json:"shared_groups" gorm:"not null;default:0"`
+	Score         int       `json:"score" gorm:"not null;default:0;index"`
+	ComputedAt    time.Time `json:"computed_at"`
+}
+
+func (FriendSuggestion) TableName() string { return "app_friend_suggestions" }
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// FriendTag 用户自定义的好友标签（分组），仅自己可见
+type FriendTag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:ux_friend_tag_name,priority:1"`
+	Name      string    `json:"name" gorm:"type:varchar(32);not null;uniqueIndex:ux_friend_tag_name,priority:2"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (FriendTag) TableName() string { return "app_friend_tags" }
+
+// FriendTagMember 标签下的好友
+type FriendTagMember struct {
+	TagID     uint      `json:"tag_id" gorm:"primaryKey;autoIncrement:false"`
+	FriendID  uint      `json:"friend_id" gorm:"primaryKey;autoIncrement:false;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (FriendTagMember) TableName() string { return "app_friend_tag_members" }
@@ -1,16 +1,63 @@
 package repository
 
+import (
+	"time"
+
+	friendentity "alice/domain/appfriend/entity"
+)
+
 type FriendRepository interface {
 	AddRelation(userID, friendID uint) error
 	RemoveRelation(userID, friendID uint) error
 	ListFriends(userID uint, offset, limit int) ([]uint, int64, error)
+	ListFriendsByTag(userID, tagID uint, offset, limit int) ([]uint, int64, error)
+	GetRelations(userID uint, friendIDs []uint) ([]*friendentity.FriendRelation, error)
+	UpdateRemark(userID, friendID uint, alias, notes string) (bool, error)
 
 	// Friend Requests
-	CreateRequest(requesterID, addresseeID uint) error
-	AcceptRequest(requestID uint) (requesterID uint, addresseeID uint, err error)
-	DeclineRequest(requestID uint) error
-	GetPendingRequests(addresseeID uint, offset, limit int) ([]uint, []uint, int64, error)
+	// SaveRequest 创建申请；同一方向已有未处理申请时刷新其消息/来源/有效期（沿用原 ID）
+	SaveRequest(req *friendentity.FriendRequest) error
+	GetRequest(id uint) (*friendentity.FriendRequest, error)
+	// FindPendingRequest 查找 requester -> addressee 方向未过期的待处理申请
+	FindPendingRequest(requesterID, addresseeID uint) (*friendentity.FriendRequest, error)
+	// TransitionRequest 仅当申请仍为 pending 时更新状态，返回是否更新成功（避免并发重复处理）
+	TransitionRequest(id uint, to friendentity.FriendRequestStatus) (bool, error)
+	ListIncomingRequests(addresseeID uint, offset, limit int) ([]*friendentity.FriendRequest, int64, error)
+	ListOutgoingRequests(requesterID uint, offset, limit int) ([]*friendentity.FriendRequest, int64, error)
+	// ExpireRequests 将已过有效期的 pending 申请标记为 expired
+	ExpireRequests(now time.Time) (int64, error)
 
 	// Relationship check
 	AreFriends(a, b uint) (bool, error)
+
+	// Block list
+	Block(userID, blockedID uint) error
+	Unblock(userID, blockedID uint) error
+	ListBlocked(userID uint, offset, limit int) ([]uint, int64, error)
+	// IsBlocked userID 是否拉黑了 targetID
+	IsBlocked(userID, targetID uint) (bool, error)
+	// ListBlockRelated 与 userID 存在任一方向拉黑关系的用户
+	ListBlockRelated(userID uint) ([]uint, error)
+	// ShareGroup a 与 b 是否同在某个群聊中
+	ShareGroup(a, b uint) (bool, error)
+
+	// 可能认识的人
+	// ComputeSuggestions 实时计算 userID 的推荐（按共同好友、共同群排序，最多 limit 条）
+	ComputeSuggestions(userID uint, limit int) ([]*friendentity.FriendSuggestion, error)
+	// ListSuggestions 读取预计算结果（读取时再次过滤已成为好友/拉黑/拒绝的用户）
+	ListSuggestions(userID uint, offset, limit int) ([]*friendentity.FriendSuggestion, int64, error)
+	// RefreshSuggestions 按用户分批重建预计算结果，返回处理的用户数
+	RefreshSuggestions(batchSize, perUser int) (int, error)
+
+	// Friend tags
+	CreateTag(t *friendentity.FriendTag) error
+	GetTag(id uint) (*friendentity.FriendTag, error)
+	GetTagByName(userID uint, name string) (*friendentity.FriendTag, error)
+	UpdateTag(t *friendentity.FriendTag) error
+	DeleteTag(id uint) error
+	ListTags(userID uint) ([]*friendentity.FriendTag, error)
+	SetTagMembers(tagID uint, friendIDs []uint) error
+	ListTagMembers(tagIDs []uint) ([]*friendentity.FriendTagMember, error)
+	// RemoveFromTags 将 friendID 从 userID 的全部标签中移除（删除好友时调用）
+	RemoveFromTags(userID, friendID uint) error
 }
@@ -2,67 +2,353 @@ package service
 
 import (
 	"errors"
+	"strconv"
 	"strings"
+	"time"
 
+	friendentity "alice/domain/appfriend/entity"
 	friendrepo "alice/domain/appfriend/repository"
 	appentity "alice/domain/appuser/entity"
 	apprepo "alice/domain/appuser/repository"
+	notifyentity "alice/domain/notification/entity"
+	notifysvc "alice/domain/notification/service"
+	"alice/pkg/realtime"
+)
+
+// 好友相关实时事件
+const (
+	EventFriendRequest          = "friend_request"
+	EventFriendAccepted         = "friend_accepted"
+	EventFriendRequestCancelled = "friend_request_cancelled"
+	EventFriendRemoved          = "friend_removed"
 )
 
 var (
 	ErrFriendUserNotFound = errors.New("user not found")
+	ErrUserBlocked        = errors.New("you have blocked this user")
+	ErrNotFriend          = errors.New("not friends")
+	ErrTagNotFound        = errors.New("tag not found")
+	ErrTagExists          = errors.New("tag already exists")
+	ErrSelfRequest        = errors.New("cannot add yourself")
+	ErrAlreadyFriends     = errors.New("already friends")
+	ErrInvalidSource      = errors.New("invalid request source")
+	ErrRequestNotFound    = errors.New("friend request not found")
+	ErrRequestClosed      = errors.New("friend request already handled")
+	ErrRequestExpired     = errors.New("friend request expired")
+	ErrNotDiscoverable    = errors.New("user cannot be added this way")
+	ErrQueryTooShort      = errors.New("search query too short")
 )
 
+// FriendshipObserver 好友关系变更观察者（如动态时间线回填/清理），回调在关系写入后同步执行
+type FriendshipObserver interface {
+	FriendAdded(userID, friendID uint)
+	FriendRemoved(userID, friendID uint)
+}
+
+// Suggestion “可能认识的人”
+type Suggestion struct {
+	User          *appentity.AppUser
+	MutualFriends int
+	SharedGroups  int
+	Reason        string // 推荐理由，如 "5 mutual friends"
+}
+
+// 推荐条数上限（预计算与实时计算共用）
+const maxSuggestions = 100
+
+// SearchResult 用户搜索结果
+type SearchResult struct {
+	User     *appentity.AppUser
+	IsFriend bool
+}
+
+// FriendDetail 好友资料 + 自己设置的备注与标签
+type FriendDetail struct {
+	User   *appentity.AppUser
+	Alias  string
+	Notes  string
+	TagIDs []uint
+}
+
+// TagDetail 标签及其成员
+type TagDetail struct {
+	Tag       *friendentity.FriendTag
+	FriendIDs []uint
+}
+
 type FriendService interface {
-	RequestFriend(userID uint, friendEmail string) error
+	RequestFriend(userID uint, friendEmail, message string, source friendentity.FriendRequestSource) (*friendentity.FriendRequest, error)
+	// SendRequest 按用户 ID 发起申请，按 source 校验对方的可发现性设置（qr/card 为对方主动分享，不受限制）
+	SendRequest(userID, targetID uint, message string, source friendentity.FriendRequestSource) (*friendentity.FriendRequest, error)
+	// SearchUsers 按 handle / 昵称 / 完整邮箱搜索用户（排除自己及黑名单双方）
+	SearchUsers(viewerID uint, query string, page, pageSize int) ([]*SearchResult, int64, error)
+	// Suggestions 可能认识的人：优先读取预计算结果，缺失时实时计算
+	Suggestions(userID uint, page, pageSize int) ([]*Suggestion, int64, error)
+	// RefreshSuggestions 重建全部用户的预计算推荐（由定时任务调用），返回处理的用户数
+	RefreshSuggestions() (int, error)
 	AcceptRequest(userID uint, requestID uint) error
 	DeclineRequest(userID uint, requestID uint) error
-	ListPending(userID uint, page, pageSize int) ([]uint, []uint, int64, error)
+	CancelRequest(userID uint, requestID uint) error
+	// ListPending 收到的待处理申请（不含已过期、已拉黑用户的申请）
+	ListPending(userID uint, page, pageSize int) ([]*friendentity.FriendRequest, int64, error)
+	// ListOutgoing 自己发出且仍在等待的申请
+	ListOutgoing(userID uint, page, pageSize int) ([]*friendentity.FriendRequest, int64, error)
+	// ExpireRequests 将过期的待处理申请标记为 expired（由定时任务调用）
+	ExpireRequests() (int64, error)
 	RemoveFriend(userID uint, friendID uint) error
 	ListFriendIDs(userID uint, page, pageSize int) ([]uint, int64, error)
-	ListFriendDetails(userID uint, page, pageSize int) ([]*appentity.AppUser, int64, error)
+	// ListFriendDetails tagID 非 0 时只返回该标签下的好友
+	ListFriendDetails(userID, tagID uint, page, pageSize int) ([]*FriendDetail, int64, error)
+	SetRemark(userID, friendID uint, alias, notes string) error
+
+	// 好友标签
+	ListTags(userID uint) ([]*TagDetail, error)
+	CreateTag(userID uint, name string, friendIDs []uint) (*TagDetail, error)
+	RenameTag(userID, tagID uint, name string) (*friendentity.FriendTag, error)
+	DeleteTag(userID, tagID uint) error
+	SetTagMembers(userID, tagID uint, friendIDs []uint) (*TagDetail, error)
+	// ExpandTags 将 userID 的若干标签展开为好友 ID（去重，用于动态可见范围等）
+	ExpandTags(userID uint, tagIDs []uint) ([]uint, error)
+
+	// 黑名单
+	Block(userID, targetID uint) error
+	Unblock(userID, targetID uint) error
+	ListBlocked(userID uint, page, pageSize int) ([]*appentity.AppUser, int64, error)
+
+	// AddObserver 注册好友关系变更观察者（应用初始化阶段调用）
+	AddObserver(o FriendshipObserver)
 }
 
 type friendServiceImpl struct {
 	appUserRepo apprepo.AppUserRepository
 	repo        friendrepo.FriendRepository
+	rt          realtime.Emitter
+	notifier    notifysvc.Notifier
+	requestTTL  time.Duration
+	observers   []FriendshipObserver
 }
 
-func NewFriendService(appUserRepo apprepo.AppUserRepository, repo friendrepo.FriendRepository) FriendService {
-	return &friendServiceImpl{appUserRepo: appUserRepo, repo: repo}
+// NewFriendService requestTTL 为好友申请有效期，<=0 表示不过期
+func NewFriendService(appUserRepo apprepo.AppUserRepository, repo friendrepo.FriendRepository, rt realtime.Emitter, notifier notifysvc.Notifier, requestTTL time.Duration) FriendService {
+	return &friendServiceImpl{appUserRepo: appUserRepo, repo: repo, rt: rt, notifier: notifier, requestTTL: requestTTL}
 }
 
-func (s *friendServiceImpl) RequestFriend(userID uint, friendEmail string) error {
+func (s *friendServiceImpl) RequestFriend(userID uint, friendEmail, message string, source friendentity.FriendRequestSource) (*friendentity.FriendRequest, error) {
 	email := strings.ToLower(strings.TrimSpace(friendEmail))
 	f, err := s.appUserRepo.GetByEmail(email)
 	if err != nil || f == nil {
-		return ErrFriendUserNotFound
+		return nil, ErrFriendUserNotFound
 	}
-	return s.repo.CreateRequest(userID, f.ID)
+	return s.send(userID, f.ID, message, source, true)
+}
+
+func (s *friendServiceImpl) SendRequest(userID, targetID uint, message string, source friendentity.FriendRequestSource) (*friendentity.FriendRequest, error) {
+	return s.send(userID, targetID, message, source, false)
+}
+
+// reachable 校验对方隐私设置是否允许以该方式添加；byEmail 表示通过完整邮箱发起
+func (s *friendServiceImpl) reachable(userID, targetID uint, source friendentity.FriendRequestSource, byEmail bool) error {
+	p, err := s.appUserRepo.GetPrivacy(targetID)
+	if err != nil {
+		return err
+	}
+	if byEmail {
+		if !p.FindableByEmail {
+			// 与用户不存在返回相同错误，避免探测邮箱是否注册
+			return ErrFriendUserNotFound
+		}
+		return nil
+	}
+	switch source {
+	case friendentity.FriendSourceSearch:
+		if !p.FindableByHandle {
+			return ErrNotDiscoverable
+		}
+	case friendentity.FriendSourceGroup:
+		if !p.FindableViaGroups {
+			return ErrNotDiscoverable
+		}
+		if shared, err := s.repo.ShareGroup(userID, targetID); err != nil {
+			return err
+		} else if !shared {
+			return ErrNotDiscoverable
+		}
+	}
+	return nil
+}
+
+// send 发起好友申请：
+//   - 对方已向自己发起过待处理申请时直接合并为互加好友（返回状态为 accepted 的对方申请）
+//   - 同方向重复申请会刷新原申请的消息与有效期
+//   - 对方拉黑了自己时照常返回成功，但对方收不到通知也看不到该申请
+func (s *friendServiceImpl) send(userID, targetID uint, message string, source friendentity.FriendRequestSource, byEmail bool) (*friendentity.FriendRequest, error) {
+	if userID == 0 || targetID == 0 {
+		return nil, errors.New("invalid params")
+	}
+	if userID == targetID {
+		return nil, ErrSelfRequest
+	}
+	if source == "" {
+		source = friendentity.FriendSourceSearch
+	}
+	if !source.Valid() {
+		return nil, ErrInvalidSource
+	}
+	if u, err := s.appUserRepo.GetByID(targetID); err != nil || u == nil || !u.IsActive() {
+		return nil, ErrFriendUserNotFound
+	}
+	if ok, err := s.repo.AreFriends(userID, targetID); err != nil {
+		return nil, err
+	} else if ok {
+		return nil, ErrAlreadyFriends
+	}
+	if err := s.reachable(userID, targetID, source, byEmail); err != nil {
+		return nil, err
+	}
+	// 自己拉黑了对方：明确提示
+	if blocked, err := s.repo.IsBlocked(userID, targetID); err != nil {
+		return nil, err
+	} else if blocked {
+		return nil, ErrUserBlocked
+	}
+	silent, err := s.repo.IsBlocked(targetID, userID)
+	if err != nil {
+		return nil, err
+	}
+	// 双向申请：对方已在等待我通过，直接成为好友
+	if !silent {
+		reverse, err := s.repo.FindPendingRequest(targetID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if reverse != nil {
+			if err := s.accept(reverse); err != nil {
+				return nil, err
+			}
+			return reverse, nil
+		}
+	}
+	req := &friendentity.FriendRequest{
+		RequesterID: userID,
+		AddresseeID: targetID,
+		Status:      friendentity.FriendRequestPending,
+		Message:     strings.TrimSpace(message),
+		Source:      source,
+	}
+	if s.requestTTL > 0 {
+		exp := time.Now().Add(s.requestTTL)
+		req.ExpiresAt = &exp
+	}
+	if err := s.repo.SaveRequest(req); err != nil {
+		return nil, err
+	}
+	if !silent {
+		payload := map[string]any{"request_id": req.ID, "requester_id": userID, "message": req.Message, "source": req.Source, "expires_at": req.ExpiresAt}
+		if u, _ := s.appUserRepo.GetByID(userID); u != nil {
+			payload["nickname"] = u.Nickname
+		}
+		s.rt.Emit(targetID, EventFriendRequest, payload)
+		s.notifier.Notify(&notifyentity.Notification{UserID: targetID, ActorID: userID, Type: notifyentity.TypeFriendRequest, SubjectID: req.ID, Content: req.Message})
+	}
+	return req, nil
+}
+
+// pendingFor 读取申请并校验仍可处理
+func (s *friendServiceImpl) pendingFor(requestID uint) (*friendentity.FriendRequest, error) {
+	req, err := s.repo.GetRequest(requestID)
+	if err != nil || req == nil {
+		return nil, ErrRequestNotFound
+	}
+	if req.Status != friendentity.FriendRequestPending {
+		return nil, ErrRequestClosed
+	}
+	if req.IsExpired(time.Now()) {
+		_, _ = s.repo.TransitionRequest(req.ID, friendentity.FriendRequestExpired)
+		return nil, ErrRequestExpired
+	}
+	return req, nil
 }
 
 func (s *friendServiceImpl) AcceptRequest(userID uint, requestID uint) error {
-	requesterID, addresseeID, err := s.repo.AcceptRequest(requestID)
+	req, err := s.pendingFor(requestID)
+	if err != nil {
+		return err
+	}
+	// 先校验身份再改状态，避免非收件人把申请标记为已接受
+	if req.AddresseeID != userID {
+		return ErrRequestNotFound
+	}
+	return s.accept(req)
+}
+
+// accept 建立双向好友关系并通知申请人
+func (s *friendServiceImpl) accept(req *friendentity.FriendRequest) error {
+	ok, err := s.repo.TransitionRequest(req.ID, friendentity.FriendRequestAccepted)
 	if err != nil {
 		return err
 	}
-	if addresseeID != userID {
-		return errors.New("permission denied")
+	if !ok {
+		return ErrRequestClosed
 	}
-	if err := s.repo.AddRelation(requesterID, addresseeID); err != nil {
+	req.Status = friendentity.FriendRequestAccepted
+	if err := s.repo.AddRelation(req.RequesterID, req.AddresseeID); err != nil {
 		return err
 	}
-	if err := s.repo.AddRelation(addresseeID, requesterID); err != nil {
+	if err := s.repo.AddRelation(req.AddresseeID, req.RequesterID); err != nil {
 		return err
 	}
+	for _, o := range s.observers {
+		o.FriendAdded(req.RequesterID, req.AddresseeID)
+	}
+	// 反方向若也有待处理申请，一并视为已通过
+	if reverse, _ := s.repo.FindPendingRequest(req.AddresseeID, req.RequesterID); reverse != nil {
+		_, _ = s.repo.TransitionRequest(reverse.ID, friendentity.FriendRequestAccepted)
+	}
+	s.rt.Emit(req.RequesterID, EventFriendAccepted, map[string]any{"request_id": req.ID, "user_id": req.AddresseeID})
+	s.rt.Emit(req.AddresseeID, EventFriendAccepted, map[string]any{"request_id": req.ID, "user_id": req.RequesterID})
+	s.notifier.Notify(&notifyentity.Notification{UserID: req.RequesterID, ActorID: req.AddresseeID, Type: notifyentity.TypeFriendAccepted, SubjectID: req.ID})
 	return nil
 }
 
 func (s *friendServiceImpl) DeclineRequest(userID uint, requestID uint) error {
-	return s.repo.DeclineRequest(requestID)
+	req, err := s.pendingFor(requestID)
+	if err != nil {
+		return err
+	}
+	if req.AddresseeID != userID {
+		return ErrRequestNotFound
+	}
+	// 拒绝不通知申请人
+	ok, err := s.repo.TransitionRequest(req.ID, friendentity.FriendRequestDeclined)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrRequestClosed
+	}
+	return nil
+}
+
+func (s *friendServiceImpl) CancelRequest(userID uint, requestID uint) error {
+	req, err := s.pendingFor(requestID)
+	if err != nil {
+		return err
+	}
+	if req.RequesterID != userID {
+		return ErrRequestNotFound
+	}
+	ok, err := s.repo.TransitionRequest(req.ID, friendentity.FriendRequestCancelled)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrRequestClosed
+	}
+	s.rt.Emit(req.AddresseeID, EventFriendRequestCancelled, map[string]any{"request_id": req.ID, "requester_id": userID})
+	return nil
 }
 
-func (s *friendServiceImpl) ListPending(userID uint, page, pageSize int) ([]uint, []uint, int64, error) {
+func (s *friendServiceImpl) ListPending(userID uint, page, pageSize int) ([]*friendentity.FriendRequest, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -70,16 +356,45 @@ func (s *friendServiceImpl) ListPending(userID uint, page, pageSize int) ([]uint
 		pageSize = 20
 	}
 	offset := (page - 1) * pageSize
-	return s.repo.GetPendingRequests(userID, offset, pageSize)
+	return s.repo.ListIncomingRequests(userID, offset, pageSize)
+}
+
+func (s *friendServiceImpl) ListOutgoing(userID uint, page, pageSize int) ([]*friendentity.FriendRequest, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+	return s.repo.ListOutgoingRequests(userID, offset, pageSize)
+}
+
+func (s *friendServiceImpl) ExpireRequests() (int64, error) {
+	return s.repo.ExpireRequests(time.Now())
 }
 
 func (s *friendServiceImpl) RemoveFriend(userID uint, friendID uint) error {
+	ok, err := s.repo.AreFriends(userID, friendID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFriend
+	}
 	if err := s.repo.RemoveRelation(userID, friendID); err != nil {
 		return err
 	}
 	if err := s.repo.RemoveRelation(friendID, userID); err != nil {
 		return err
 	}
+	// 双方标签中的对方一并移除（备注随关系记录删除）
+	_ = s.repo.RemoveFromTags(userID, friendID)
+	_ = s.repo.RemoveFromTags(friendID, userID)
+	for _, o := range s.observers {
+		o.FriendRemoved(userID, friendID)
+	}
+	s.rt.Emit(friendID, EventFriendRemoved, map[string]any{"user_id": userID})
 	return nil
 }
 
@@ -94,9 +409,272 @@ func (s *friendServiceImpl) ListFriendIDs(userID uint, page, pageSize int) ([]ui
 	return s.repo.ListFriends(userID, offset, pageSize)
 }
 
-// ListFriendDetails 先取 ID 再批量查询资料
-func (s *friendServiceImpl) ListFriendDetails(userID uint, page, pageSize int) ([]*appentity.AppUser, int64, error) {
-	ids, total, err := s.ListFriendIDs(userID, page, pageSize)
+// ListFriendDetails 先取 ID 再批量查询资料、备注与标签
+func (s *friendServiceImpl) ListFriendDetails(userID, tagID uint, page, pageSize int) ([]*FriendDetail, int64, error) {
+	var ids []uint
+	var total int64
+	var err error
+	if tagID == 0 {
+		ids, total, err = s.ListFriendIDs(userID, page, pageSize)
+	} else {
+		if _, err = s.ownTag(userID, tagID); err != nil {
+			return nil, 0, err
+		}
+		if page < 1 {
+			page = 1
+		}
+		if pageSize <= 0 || pageSize > 100 {
+			pageSize = 20
+		}
+		ids, total, err = s.repo.ListFriendsByTag(userID, tagID, (page-1)*pageSize, pageSize)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	users, err := s.appUserRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	rels, err := s.repo.GetRelations(userID, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	relMap := make(map[uint]*friendentity.FriendRelation, len(rels))
+	for _, r := range rels {
+		relMap[r.FriendID] = r
+	}
+	tagsOf, err := s.friendTagIndex(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	userMap := make(map[uint]*appentity.AppUser, len(users))
+	for _, u := range users {
+		userMap[u.ID] = u
+	}
+	out := make([]*FriendDetail, 0, len(ids))
+	for _, id := range ids { // 保持关系表顺序
+		u, ok := userMap[id]
+		if !ok {
+			continue
+		}
+		d := &FriendDetail{User: u, TagIDs: tagsOf[id]}
+		if d.TagIDs == nil {
+			d.TagIDs = []uint{}
+		}
+		if r, ok := relMap[id]; ok {
+			d.Alias, d.Notes = r.Alias, r.Notes
+		}
+		out = append(out, d)
+	}
+	return out, total, nil
+}
+
+func (s *friendServiceImpl) SetRemark(userID, friendID uint, alias, notes string) error {
+	ok, err := s.repo.UpdateRemark(userID, friendID, strings.TrimSpace(alias), strings.TrimSpace(notes))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFriend
+	}
+	return nil
+}
+
+// friendTagIndex 好友 ID -> 所属标签 ID
+func (s *friendServiceImpl) friendTagIndex(userID uint) (map[uint][]uint, error) {
+	tags, err := s.repo.ListTags(userID)
+	if err != nil {
+		return nil, err
+	}
+	tagIDs := make([]uint, 0, len(tags))
+	for _, t := range tags {
+		tagIDs = append(tagIDs, t.ID)
+	}
+	members, err := s.repo.ListTagMembers(tagIDs)
+	if err != nil {
+		return nil, err
+	}
+	idx := make(map[uint][]uint)
+	for _, m := range members {
+		idx[m.FriendID] = append(idx[m.FriendID], m.TagID)
+	}
+	return idx, nil
+}
+
+func (s *friendServiceImpl) ownTag(userID, tagID uint) (*friendentity.FriendTag, error) {
+	t, err := s.repo.GetTag(tagID)
+	if err != nil || t == nil || t.UserID != userID {
+		return nil, ErrTagNotFound
+	}
+	return t, nil
+}
+
+// onlyFriends 过滤掉非好友与重复 ID
+func (s *friendServiceImpl) onlyFriends(userID uint, ids []uint) ([]uint, error) {
+	rels, err := s.repo.GetRelations(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]uint, 0, len(rels))
+	seen := make(map[uint]struct{}, len(rels))
+	for _, r := range rels {
+		if _, ok := seen[r.FriendID]; ok {
+			continue
+		}
+		seen[r.FriendID] = struct{}{}
+		out = append(out, r.FriendID)
+	}
+	return out, nil
+}
+
+func (s *friendServiceImpl) ListTags(userID uint) ([]*TagDetail, error) {
+	tags, err := s.repo.ListTags(userID)
+	if err != nil {
+		return nil, err
+	}
+	tagIDs := make([]uint, 0, len(tags))
+	for _, t := range tags {
+		tagIDs = append(tagIDs, t.ID)
+	}
+	members, err := s.repo.ListTagMembers(tagIDs)
+	if err != nil {
+		return nil, err
+	}
+	byTag := make(map[uint][]uint, len(tags))
+	for _, m := range members {
+		byTag[m.TagID] = append(byTag[m.TagID], m.FriendID)
+	}
+	out := make([]*TagDetail, 0, len(tags))
+	for _, t := range tags {
+		ids := byTag[t.ID]
+		if ids == nil {
+			ids = []uint{}
+		}
+		out = append(out, &TagDetail{Tag: t, FriendIDs: ids})
+	}
+	return out, nil
+}
+
+func (s *friendServiceImpl) CreateTag(userID uint, name string, friendIDs []uint) (*TagDetail, error) {
+	name = strings.TrimSpace(name)
+	if userID == 0 || name == "" {
+		return nil, errors.New("invalid params")
+	}
+	if t, err := s.repo.GetTagByName(userID, name); err != nil {
+		return nil, err
+	} else if t != nil {
+		return nil, ErrTagExists
+	}
+	t := &friendentity.FriendTag{UserID: userID, Name: name}
+	if err := s.repo.CreateTag(t); err != nil {
+		return nil, err
+	}
+	ids, err := s.onlyFriends(userID, friendIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetTagMembers(t.ID, ids); err != nil {
+		return nil, err
+	}
+	return &TagDetail{Tag: t, FriendIDs: ids}, nil
+}
+
+func (s *friendServiceImpl) RenameTag(userID, tagID uint, name string) (*friendentity.FriendTag, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("invalid params")
+	}
+	t, err := s.ownTag(userID, tagID)
+	if err != nil {
+		return nil, err
+	}
+	if t.Name == name {
+		return t, nil
+	}
+	if other, err := s.repo.GetTagByName(userID, name); err != nil {
+		return nil, err
+	} else if other != nil {
+		return nil, ErrTagExists
+	}
+	t.Name = name
+	if err := s.repo.UpdateTag(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *friendServiceImpl) DeleteTag(userID, tagID uint) error {
+	if _, err := s.ownTag(userID, tagID); err != nil {
+		return err
+	}
+	return s.repo.DeleteTag(tagID)
+}
+
+func (s *friendServiceImpl) SetTagMembers(userID, tagID uint, friendIDs []uint) (*TagDetail, error) {
+	t, err := s.ownTag(userID, tagID)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := s.onlyFriends(userID, friendIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetTagMembers(tagID, ids); err != nil {
+		return nil, err
+	}
+	return &TagDetail{Tag: t, FriendIDs: ids}, nil
+}
+
+func (s *friendServiceImpl) ExpandTags(userID uint, tagIDs []uint) ([]uint, error) {
+	if len(tagIDs) == 0 {
+		return []uint{}, nil
+	}
+	owned := make([]uint, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		if _, err := s.ownTag(userID, id); err != nil {
+			return nil, err
+		}
+		owned = append(owned, id)
+	}
+	members, err := s.repo.ListTagMembers(owned)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[uint]struct{}, len(members))
+	out := make([]uint, 0, len(members))
+	for _, m := range members {
+		if _, ok := seen[m.FriendID]; ok {
+			continue
+		}
+		seen[m.FriendID] = struct{}{}
+		out = append(out, m.FriendID)
+	}
+	return out, nil
+}
+
+func (s *friendServiceImpl) Block(userID, targetID uint) error {
+	if userID == 0 || targetID == 0 || userID == targetID {
+		return errors.New("invalid params")
+	}
+	if u, err := s.appUserRepo.GetByID(targetID); err != nil || u == nil {
+		return ErrFriendUserNotFound
+	}
+	return s.repo.Block(userID, targetID)
+}
+
+func (s *friendServiceImpl) Unblock(userID, targetID uint) error {
+	return s.repo.Unblock(userID, targetID)
+}
+
+func (s *friendServiceImpl) ListBlocked(userID uint, page, pageSize int) ([]*appentity.AppUser, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+	ids, total, err := s.repo.ListBlocked(userID, offset, pageSize)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -106,3 +684,109 @@ func (s *friendServiceImpl) ListFriendDetails(userID uint, page, pageSize int) (
 	}
 	return users, total, nil
 }
+
+func (s *friendServiceImpl) AddObserver(o FriendshipObserver) {
+	s.observers = append(s.observers, o)
+}
+
+func (s *friendServiceImpl) SearchUsers(viewerID uint, query string, page, pageSize int) ([]*SearchResult, int64, error) {
+	query = strings.TrimSpace(query)
+	if len([]rune(query)) < 2 {
+		return nil, 0, ErrQueryTooShort
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 50 {
+		pageSize = 20
+	}
+	exclude, err := s.repo.ListBlockRelated(viewerID)
+	if err != nil {
+		return nil, 0, err
+	}
+	exclude = append(exclude, viewerID)
+	users, total, err := s.appUserRepo.Search(query, exclude, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make([]*SearchResult, 0, len(users))
+	for _, u := range users {
+		isFriend, _ := s.repo.AreFriends(viewerID, u.ID)
+		out = append(out, &SearchResult{User: u, IsFriend: isFriend})
+	}
+	return out, total, nil
+}
+
+func (s *friendServiceImpl) Suggestions(userID uint, page, pageSize int) ([]*Suggestion, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 50 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+	rows, total, err := s.repo.ListSuggestions(userID, offset, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		// 尚未预计算（新用户或新建立的关系），实时计算后内存分页
+		all, err := s.repo.ComputeSuggestions(userID, maxSuggestions)
+		if err != nil {
+			return nil, 0, err
+		}
+		total = int64(len(all))
+		if offset >= len(all) {
+			all = nil
+		} else {
+			all = all[offset:]
+		}
+		if len(all) > pageSize {
+			all = all[:pageSize]
+		}
+		rows = all
+	}
+	ids := make([]uint, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.CandidateID)
+	}
+	users, err := s.appUserRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uint]*appentity.AppUser, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+	out := make([]*Suggestion, 0, len(rows))
+	for _, r := range rows {
+		u, ok := byID[r.CandidateID]
+		if !ok {
+			continue
+		}
+		out = append(out, &Suggestion{User: u, MutualFriends: r.MutualFriends, SharedGroups: r.SharedGroups, Reason: suggestionReason(r.MutualFriends, r.SharedGroups)})
+	}
+	return out, total, nil
+}
+
+func (s *friendServiceImpl) RefreshSuggestions() (int, error) {
+	return s.repo.RefreshSuggestions(500, maxSuggestions)
+}
+
+// suggestionReason 生成推荐理由
+func suggestionReason(mutual, groups int) string {
+	plural := func(n int, one, many string) string {
+		if n == 1 {
+			return "1 " + one
+		}
+		return strconv.Itoa(n) + " " + many
+	}
+	parts := make([]string, 0, 2)
+	if mutual > 0 {
+		parts = append(parts, plural(mutual, "mutual friend", "mutual friends"))
+	}
+	if groups > 0 {
+		parts = append(parts, plural(groups, "shared group", "shared groups"))
+	}
+	return strings.Join(parts, " · ")
+}
@@ -13,8 +13,9 @@ const (
 
 // AppUser 移动端用户表（独立于后台管理用户）
 type AppUser struct {
-	ID           uint          `json:"id" gorm:"primaryKey"`
-	Username     string        `json:"username" gorm:"default:''"`
+	ID uint `json:"id" gorm:"primaryKey"`
+	// Username 用户唯一标识（handle），小写存储；为空表示尚未设置
+	Username     string        `json:"username" gorm:"default:'';uniqueIndex:idx_app_users_username,where:username <> ''"`
 	Email        string        `json:"email" gorm:"uniqueIndex;not null"`
 	PasswordHash string        `json:"-" gorm:"not null"`
 	Nickname     string        `json:"nickname" gorm:"default:''"`
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// AppUserPrivacy 用户可被发现性设置；无记录时视为全部允许（见 DefaultPrivacy）
+type AppUserPrivacy struct {
+	UserID uint `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	// FindableByEmail 允许他人通过完整邮箱搜索到自己 / 通过邮箱发起好友申请
+	FindableByEmail bool `json:"findable_by_email" gorm:"not null"`
+	// FindableByHandle 允许他人通过 handle 或昵称搜索到自己
+	FindableByHandle bool `json:"findable_by_handle" gorm:"not null"`
+	// FindableViaGroups 允许共同群成员通过群发起好友申请
+	FindableViaGroups bool      `json:"findable_via_groups" gorm:"not null"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+func (AppUserPrivacy) TableName() string { return "app_user_privacy" }
+
+// DefaultPrivacy 未设置时的默认隐私配置
+func DefaultPrivacy(userID uint) *AppUserPrivacy {
+	return &AppUserPrivacy{UserID: userID, FindableByEmail: true, FindableByHandle: true, FindableViaGroups: true}
+}
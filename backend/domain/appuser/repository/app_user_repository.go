@@ -12,4 +12,12 @@ type AppUserRepository interface {
 	Update(user *appentity.AppUser) error
 	Delete(id uint) error
 	List(offset, limit int) ([]*appentity.AppUser, int64, error)
+	// GetByUsername 按 handle 精确查找
+	GetByUsername(username string) (*appentity.AppUser, error)
+	// Search 按 handle / 昵称 / 完整邮箱搜索活跃用户，遵循对方的隐私设置；excludeIDs 中的用户不返回
+	Search(query string, excludeIDs []uint, offset, limit int) ([]*appentity.AppUser, int64, error)
+
+	// 隐私设置
+	GetPrivacy(userID uint) (*appentity.AppUserPrivacy, error)
+	SavePrivacy(p *appentity.AppUserPrivacy) error
 }
@@ -2,6 +2,7 @@ package service
 
 import (
 	"errors"
+	"regexp"
 	"strings"
 	"time"
 
@@ -10,7 +11,11 @@ import (
 
 	appentity "alice/domain/appuser/entity"
 	apprepo "alice/domain/appuser/repository"
+	mediasvc "alice/domain/media/service"
+	modentity "alice/domain/moderation/entity"
+	modsvc "alice/domain/moderation/service"
 	"alice/infra/config"
+	"alice/pkg/logger"
 )
 
 var (
@@ -18,22 +23,34 @@ var (
 	ErrAppUserExists         = errors.New("app user already exists")
 	ErrAppInvalidCredentials = errors.New("invalid credentials")
 	ErrAppUserInactive       = errors.New("user is inactive")
+	ErrInvalidHandle         = errors.New("handle must be 4-32 chars of a-z, 0-9 or _, starting with a letter")
+	ErrHandleTaken           = errors.New("handle already taken")
 )
 
+// handlePattern handle 规则：字母开头，4-32 位小写字母/数字/下划线
+var handlePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{3,31}$`)
+
 type AppUserService interface {
 	Register(email, password, nickname string) (*appentity.AppUser, error)
 	Login(email, password string) (string, error)
 	GetByID(id uint) (*appentity.AppUser, error)
 	UpdateProfile(id uint, nickname, avatar, gender, bio string) (*appentity.AppUser, error)
 	GetByIDs(ids []uint) ([]*appentity.AppUser, error)
+	// SetHandle 设置唯一 handle（大小写不敏感）
+	SetHandle(id uint, handle string) (*appentity.AppUser, error)
+	GetPrivacy(id uint) (*appentity.AppUserPrivacy, error)
+	// UpdatePrivacy 更新可发现性设置，nil 表示不修改
+	UpdatePrivacy(id uint, byEmail, byHandle, viaGroups *bool) (*appentity.AppUserPrivacy, error)
 }
 
 type appUserServiceImpl struct {
-	repo apprepo.AppUserRepository
+	repo      apprepo.AppUserRepository
+	moderator modsvc.ModerationService
+	media     mediasvc.MediaService
 }
 
-func NewAppUserService(repo apprepo.AppUserRepository) AppUserService {
-	return &appUserServiceImpl{repo: repo}
+func NewAppUserService(repo apprepo.AppUserRepository, moderator modsvc.ModerationService, media mediasvc.MediaService) AppUserService {
+	return &appUserServiceImpl{repo: repo, moderator: moderator, media: media}
 }
 
 func (s *appUserServiceImpl) Register(email, password, nickname string) (*appentity.AppUser, error) {
@@ -41,14 +58,19 @@ func (s *appUserServiceImpl) Register(email, password, nickname string) (*appent
 	if u, _ := s.repo.GetByEmail(email); u != nil {
 		return nil, ErrAppUserExists
 	}
+	verdict, err := s.moderator.Check(modentity.SceneNickname, 0, nickname)
+	if err != nil {
+		return nil, err
+	}
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
 	}
-	user := &appentity.AppUser{Email: email, PasswordHash: string(hash), Nickname: nickname, Status: appentity.AppUserStatusActive}
+	user := &appentity.AppUser{Email: email, PasswordHash: string(hash), Nickname: verdict.Text, Status: appentity.AppUserStatusActive}
 	if err := s.repo.Create(user); err != nil {
 		return nil, err
 	}
+	s.moderator.Flag(modentity.SceneNickname, user.ID, user.ID, verdict)
 	return user, nil
 }
 
@@ -80,9 +102,14 @@ func (s *appUserServiceImpl) UpdateProfile(id uint, nickname, avatar, gender, bi
 	if err != nil || u == nil {
 		return nil, ErrAppUserNotFound
 	}
+	var nickVerdict, bioVerdict *modsvc.Result
 	if nickname != "" {
-		u.Nickname = nickname
+		if nickVerdict, err = s.moderator.Check(modentity.SceneNickname, id, nickname); err != nil {
+			return nil, err
+		}
+		u.Nickname = nickVerdict.Text
 	}
+	oldAvatar := u.Avatar
 	if avatar != "" {
 		u.Avatar = avatar
 	}
@@ -93,18 +120,95 @@ func (s *appUserServiceImpl) UpdateProfile(id uint, nickname, avatar, gender, bi
 		}
 	}
 	if bio != "" {
-		u.Bio = bio
+		if bioVerdict, err = s.moderator.Check(modentity.SceneBio, id, bio); err != nil {
+			return nil, err
+		}
+		u.Bio = bioVerdict.Text
 	}
 	if err := s.repo.Update(u); err != nil {
 		return nil, err
 	}
+	if u.Avatar != oldAvatar {
+		s.swapAvatarRef(oldAvatar, u.Avatar)
+	}
+	s.moderator.Flag(modentity.SceneNickname, id, id, nickVerdict)
+	s.moderator.Flag(modentity.SceneBio, id, id, bioVerdict)
 	return u, nil
 }
 
+// swapAvatarRef 头像变更后转移对象引用，旧头像引用归零后由后台回收；失败只记录日志，最坏情况是旧对象晚回收或不回收
+func (s *appUserServiceImpl) swapAvatarRef(oldPath, newPath string) {
+	if err := s.media.Retain(newPath); err != nil {
+		logger.Warnf("retain avatar %s failed: %v", newPath, err)
+		return
+	}
+	if err := s.media.Release(oldPath); err != nil {
+		logger.Warnf("release avatar %s failed: %v", oldPath, err)
+	}
+}
+
 func (s *appUserServiceImpl) GetByIDs(ids []uint) ([]*appentity.AppUser, error) {
 	return s.repo.GetByIDs(ids)
 }
 
+func (s *appUserServiceImpl) SetHandle(id uint, handle string) (*appentity.AppUser, error) {
+	handle = strings.ToLower(strings.TrimSpace(handle))
+	if !handlePattern.MatchString(handle) {
+		return nil, ErrInvalidHandle
+	}
+	u, err := s.repo.GetByID(id)
+	if err != nil || u == nil {
+		return nil, ErrAppUserNotFound
+	}
+	if u.Username == handle {
+		return u, nil
+	}
+	if other, _ := s.repo.GetByUsername(handle); other != nil {
+		return nil, ErrHandleTaken
+	}
+	// handle 不做替换处理，命中任何敏感词直接拒绝
+	verdict, err := s.moderator.Check(modentity.SceneNickname, id, handle)
+	if err != nil {
+		return nil, err
+	}
+	if verdict.Text != handle || verdict.Flagged {
+		return nil, modsvc.ErrContentBlocked
+	}
+	u.Username = handle
+	if err := s.repo.Update(u); err != nil {
+		// 并发设置同一 handle 时由唯一索引兜底
+		if existing, _ := s.repo.GetByUsername(handle); existing != nil && existing.ID != id {
+			return nil, ErrHandleTaken
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *appUserServiceImpl) GetPrivacy(id uint) (*appentity.AppUserPrivacy, error) {
+	return s.repo.GetPrivacy(id)
+}
+
+func (s *appUserServiceImpl) UpdatePrivacy(id uint, byEmail, byHandle, viaGroups *bool) (*appentity.AppUserPrivacy, error) {
+	p, err := s.repo.GetPrivacy(id)
+	if err != nil {
+		return nil, err
+	}
+	if byEmail != nil {
+		p.FindableByEmail = *byEmail
+	}
+	if byHandle != nil {
+		p.FindableByHandle = *byHandle
+	}
+	if viaGroups != nil {
+		p.FindableViaGroups = *viaGroups
+	}
+	if err := s.repo.SavePrivacy(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
 func (s *appUserServiceImpl) generateToken(userID uint) (string, error) {
 	cfg := config.Load()
 	claims := jwt.MapClaims{
@@ -1,6 +1,10 @@
 package entity
 
-import "time"
+import (
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Group 群聊信息
 type Group struct {
@@ -26,16 +30,33 @@ func (GroupMember) TableName() string { return "app_chat_group_members" }
 
 // GroupMessage 群消息
 type GroupMessage struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	GroupID   uint      `json:"group_id" gorm:"not null;index"`
-	SenderID  uint      `json:"sender_id" gorm:"not null;index"`
-	Type      string    `json:"type" gorm:"type:varchar(20);not null;default:'text'"`
-	Content   string    `json:"content" gorm:"type:text;not null"`
-	CreatedAt time.Time `json:"created_at"`
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	GroupID  uint   `json:"group_id" gorm:"not null;index"`
+	SenderID uint   `json:"sender_id" gorm:"not null;index"`
+	Type     string `json:"type" gorm:"type:varchar(20);not null;default:'text'"`
+	Content  string `json:"content" gorm:"type:text;not null"`
+	Mentions string `json:"-" gorm:"type:text;default:''"` // 被 @ 的成员 ID，逗号分隔
+	// RecalledAt 发送方撤回的时间：撤回后内容与 @ 清空、附件删除，消息保留为占位
+	RecalledAt *time.Time `json:"recalled_at"`
+	CreatedAt  time.Time  `json:"created_at"`
 }
 
 func (GroupMessage) TableName() string { return "app_chat_group_messages" }
 
+// MentionIDs 解析被 @ 的成员 ID
+func (m *GroupMessage) MentionIDs() []uint {
+	ids := []uint{}
+	if m.Mentions == "" {
+		return ids
+	}
+	for _, p := range strings.Split(m.Mentions, ",") {
+		if v, err := strconv.ParseUint(strings.TrimSpace(p), 10, 64); err == nil && v > 0 {
+			ids = append(ids, uint(v))
+		}
+	}
+	return ids
+}
+
 // GroupReadCursor 记录成员在群里的最后已读消息 ID
 type GroupReadCursor struct {
 	GroupID       uint      `json:"group_id" gorm:"primaryKey;autoIncrement:false"`
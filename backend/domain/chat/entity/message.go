@@ -11,6 +11,10 @@ type Message struct {
 	Content    string     `json:"content" gorm:"type:text;not null"`
 	IsRead     bool       `json:"is_read" gorm:"not null;default:false;index"`
 	ReadAt     *time.Time `json:"read_at"`
+	// Dropped 接收方已拉黑发送方：消息只对发送方可见，不投递也不计入接收方未读
+	Dropped bool `json:"-" gorm:"not null;default:false"`
+	// RecalledAt 发送方撤回的时间：撤回后内容清空、附件删除，消息保留为占位
+	RecalledAt *time.Time `json:"recalled_at"`
 	CreatedAt  time.Time  `json:"created_at"`
 }
 
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	chatentity "alice/domain/chat/entity"
 )
 
@@ -17,4 +19,8 @@ type GroupRepository interface {
 	UpdateLastRead(groupID, userID, msgID uint) error
 	CountUnread(groupID, userID uint) (int64, error)
 	RemoveMember(groupID, userID uint) error
+	GetMessage(id uint) (*chatentity.GroupMessage, error)
+	DeleteMessage(id uint) error
+	// RecallMessage 清空内容与 @ 并记录撤回时间
+	RecallMessage(id uint, at time.Time) error
 }
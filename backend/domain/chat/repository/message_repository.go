@@ -1,11 +1,17 @@
 package repository
 
 import (
+	"time"
+
 	chatentity "alice/domain/chat/entity"
 )
 
 type MessageRepository interface {
 	Save(msg *chatentity.Message) error
+	Get(id uint) (*chatentity.Message, error)
+	Delete(id uint) error
+	// Recall 清空内容并记录撤回时间
+	Recall(id uint, at time.Time) error
 	ListConversation(a, b uint, offset, limit int) ([]*chatentity.Message, int64, error)
 	MarkRead(a, b uint, beforeID uint) error
 	ListRecentConversations(self uint, offset, limit int) ([]*chatentity.Conversation, int64, error)
@@ -2,30 +2,53 @@ package service
 
 import (
 	"errors"
+	"time"
 
 	friendrepo "alice/domain/appfriend/repository"
 	chatentity "alice/domain/chat/entity"
 	chatrepo "alice/domain/chat/repository"
+	mediaentity "alice/domain/media/entity"
+	mediasvc "alice/domain/media/service"
+	modentity "alice/domain/moderation/entity"
+	modsvc "alice/domain/moderation/service"
+	"alice/pkg/logger"
 )
 
 var (
-	ErrNotFriends = errors.New("not friends")
+	ErrNotFriends      = errors.New("not friends")
+	ErrUserBlocked     = errors.New("you have blocked this user")
+	ErrMessageNotFound = errors.New("message not found")
+	ErrNotSender       = errors.New("only the sender can recall this message")
+)
+
+// 撤回事件：通知会话另一方（或群成员）将消息替换为撤回占位
+const (
+	EventMessageRecalled      = "message_recalled"
+	EventGroupMessageRecalled = "group_message_recalled"
 )
 
 type ChatService interface {
 	Send(senderID, receiverID uint, content string, msgType string) (*chatentity.Message, error)
 	History(a, b uint, page, pageSize int) ([]*chatentity.Message, int64, error)
+	GetMessage(id uint) (*chatentity.Message, error)
 	MarkRead(a, b uint, beforeID uint) error
+	// Recall 发送方撤回消息：清空内容并删除附件（释放对象引用），重复撤回直接返回
+	Recall(userID, id uint) (*chatentity.Message, error)
 	RecentConversations(self uint, page, pageSize int) ([]*chatentity.Conversation, int64, error)
+	// CanViewMedia 聊天附件对 userID 是否可见，访问媒体与转发共用同一规则
+	CanViewMedia(userID uint, a *mediaentity.Attachment) bool
 }
 
 type chatServiceImpl struct {
 	repo       chatrepo.MessageRepository
+	groupRepo  chatrepo.GroupRepository
 	friendRepo friendrepo.FriendRepository
+	moderator  modsvc.ModerationService
+	media      mediasvc.MediaService
 }
 
-func NewChatService(repo chatrepo.MessageRepository, friendRepo friendrepo.FriendRepository) ChatService {
-	return &chatServiceImpl{repo: repo, friendRepo: friendRepo}
+func NewChatService(repo chatrepo.MessageRepository, groupRepo chatrepo.GroupRepository, friendRepo friendrepo.FriendRepository, moderator modsvc.ModerationService, media mediasvc.MediaService) ChatService {
+	return &chatServiceImpl{repo: repo, groupRepo: groupRepo, friendRepo: friendRepo, moderator: moderator, media: media}
 }
 
 func (s *chatServiceImpl) Send(senderID, receiverID uint, content string, msgType string) (*chatentity.Message, error) {
@@ -39,15 +62,44 @@ func (s *chatServiceImpl) Send(senderID, receiverID uint, content string, msgTyp
 	if !ok {
 		return nil, ErrNotFriends
 	}
+	if blocked, err := s.friendRepo.IsBlocked(senderID, receiverID); err != nil {
+		return nil, err
+	} else if blocked {
+		return nil, ErrUserBlocked
+	}
+	// 对方拉黑了发送方：照常落库并返回成功，但标记为丢弃，不让发送方察觉
+	dropped, err := s.friendRepo.IsBlocked(receiverID, senderID)
+	if err != nil {
+		return nil, err
+	}
+	msgType = firstNonEmpty(msgType, "text")
+	// 仅文本消息需要审核；图片/视频消息内容为对象路径
+	var verdict *modsvc.Result
+	if msgType == "text" {
+		verdict, err = s.moderator.Check(modentity.SceneChatMessage, senderID, content)
+		if err != nil {
+			return nil, err
+		}
+		content = verdict.Text
+	}
 	m := &chatentity.Message{
 		SenderID:   senderID,
 		ReceiverID: receiverID,
-		Type:       firstNonEmpty(msgType, "text"),
+		Type:       msgType,
 		Content:    content,
+		Dropped:    dropped,
 	}
 	if err := s.repo.Save(m); err != nil {
 		return nil, err
 	}
+	if err := attachMedia(s.media, mediaViewer(s.repo, s.groupRepo, senderID), senderID, mediaentity.RefMessage, m.ID, msgType, content); err != nil {
+		// Attach 失败时整体回滚，没有指向该消息的附件，只需删除消息
+		if derr := s.repo.Delete(m.ID); derr != nil {
+			logger.Errorf("delete message %d after attach failure failed: %v", m.ID, derr)
+		}
+		return nil, err
+	}
+	s.moderator.Flag(modentity.SceneChatMessage, senderID, m.ID, verdict)
 	return m, nil
 }
 
@@ -62,10 +114,38 @@ func (s *chatServiceImpl) History(a, b uint, page, pageSize int) ([]*chatentity.
 	return s.repo.ListConversation(a, b, offset, pageSize)
 }
 
+func (s *chatServiceImpl) GetMessage(id uint) (*chatentity.Message, error) { return s.repo.Get(id) }
+
 func (s *chatServiceImpl) MarkRead(a, b uint, beforeID uint) error {
 	return s.repo.MarkRead(a, b, beforeID)
 }
 
+func (s *chatServiceImpl) Recall(userID, id uint) (*chatentity.Message, error) {
+	m, err := s.repo.Get(id)
+	if err != nil || m == nil {
+		return nil, ErrMessageNotFound
+	}
+	// 接收方看不到被丢弃的消息，与不存在一致
+	if m.ReceiverID == userID && !m.Dropped {
+		return nil, ErrNotSender
+	}
+	if m.SenderID != userID {
+		return nil, ErrMessageNotFound
+	}
+	if m.RecalledAt != nil {
+		return m, nil
+	}
+	now := time.Now()
+	if err := s.repo.Recall(id, now); err != nil {
+		return nil, err
+	}
+	if err := s.media.DeleteByRef(mediaentity.RefMessage, id); err != nil {
+		logger.Warnf("delete media of recalled message %d failed: %v", id, err)
+	}
+	m.Content, m.RecalledAt = "", &now
+	return m, nil
+}
+
 func (s *chatServiceImpl) RecentConversations(self uint, page, pageSize int) ([]*chatentity.Conversation, int64, error) {
 	if page < 1 {
 		page = 1
@@ -77,6 +157,44 @@ func (s *chatServiceImpl) RecentConversations(self uint, page, pageSize int) ([]
 	return s.repo.ListRecentConversations(self, offset, pageSize)
 }
 
+// attachMedia 图片/视频消息的内容为对象路径，关联到媒体附件；路径须为发送方本人上传或可见的已有附件（转发）
+func attachMedia(media mediasvc.MediaService, canView mediasvc.CanView, senderID uint, refType mediaentity.RefType, refID uint, msgType, content string) error {
+	if msgType != "image" && msgType != "video" {
+		return nil
+	}
+	_, err := media.Attach(senderID, mediaentity.KindChat, refType, refID, []string{content}, canView)
+	return err
+}
+
+func (s *chatServiceImpl) CanViewMedia(userID uint, a *mediaentity.Attachment) bool {
+	return mediaViewer(s.repo, s.groupRepo, userID)(a)
+}
+
+// mediaViewer 聊天附件的可见性：私聊消息的收发双方（被拉黑丢弃的消息对接收方不可见）、群消息所在群的成员；
+// 尚未发送的附件仅上传者可见
+func mediaViewer(msgs chatrepo.MessageRepository, groups chatrepo.GroupRepository, userID uint) mediasvc.CanView {
+	return func(a *mediaentity.Attachment) bool {
+		switch a.RefType {
+		case mediaentity.RefMessage:
+			m, err := msgs.Get(a.RefID)
+			if err != nil || m == nil {
+				return false
+			}
+			return m.SenderID == userID || (m.ReceiverID == userID && !m.Dropped)
+		case mediaentity.RefGroupMessage:
+			m, err := groups.GetMessage(a.RefID)
+			if err != nil || m == nil {
+				return false
+			}
+			ok, err := groups.IsMember(m.GroupID, userID)
+			return err == nil && ok
+		case mediaentity.RefNone:
+			return a.OwnerID == userID
+		}
+		return false
+	}
+}
+
 func firstNonEmpty(vals ...string) string {
 	for _, v := range vals {
 		if v != "" {
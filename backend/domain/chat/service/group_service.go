@@ -3,11 +3,20 @@ package service
 import (
 	"context"
 	"errors"
+	"strconv"
 	"strings"
 	"time"
 
+	friendrepo "alice/domain/appfriend/repository"
 	chatentity "alice/domain/chat/entity"
 	chatrepo "alice/domain/chat/repository"
+	mediaentity "alice/domain/media/entity"
+	mediasvc "alice/domain/media/service"
+	modentity "alice/domain/moderation/entity"
+	modsvc "alice/domain/moderation/service"
+	notifyentity "alice/domain/notification/entity"
+	notifysvc "alice/domain/notification/service"
+	"alice/pkg/logger"
 )
 
 type GroupService interface {
@@ -15,8 +24,12 @@ type GroupService interface {
 	Search(name string, limit int) ([]*chatentity.Group, error)
 	Join(groupID, userID uint) error
 	ListMessages(groupID uint, page, pageSize int) ([]*chatentity.GroupMessage, int64, error)
-	SendMessage(groupID, senderID uint, msgType, content string) (*chatentity.GroupMessage, error)
+	// SendMessage mentions 为被 @ 的成员；非成员及拉黑了发送方的成员会被静默剔除
+	SendMessage(groupID, senderID uint, msgType, content string, mentions []uint) (*chatentity.GroupMessage, error)
 	IsMember(groupID, userID uint) (bool, error)
+	GetMessage(id uint) (*chatentity.GroupMessage, error)
+	// RecallMessage 发送方撤回群消息：清空内容并删除附件（释放对象引用），重复撤回直接返回
+	RecallMessage(userID, id uint) (*chatentity.GroupMessage, error)
 	Get(groupID uint) (*chatentity.Group, error)
 	UpdateGroup(operatorID, groupID uint, name, avatar string) (*chatentity.Group, error)
 	ListUserGroups(userID uint, page, pageSize int) ([]*chatentity.Group, int64, error)
@@ -28,9 +41,18 @@ type GroupService interface {
 	ListMembers(groupID uint) ([]uint, error)
 }
 
-type groupServiceImpl struct{ repo chatrepo.GroupRepository }
+type groupServiceImpl struct {
+	repo       chatrepo.GroupRepository
+	msgRepo    chatrepo.MessageRepository
+	friendRepo friendrepo.FriendRepository
+	moderator  modsvc.ModerationService
+	notifier   notifysvc.Notifier
+	media      mediasvc.MediaService
+}
 
-func NewGroupService(r chatrepo.GroupRepository) GroupService { return &groupServiceImpl{repo: r} }
+func NewGroupService(r chatrepo.GroupRepository, msgRepo chatrepo.MessageRepository, friendRepo friendrepo.FriendRepository, moderator modsvc.ModerationService, notifier notifysvc.Notifier, media mediasvc.MediaService) GroupService {
+	return &groupServiceImpl{repo: r, msgRepo: msgRepo, friendRepo: friendRepo, moderator: moderator, notifier: notifier, media: media}
+}
 
 func (s *groupServiceImpl) Create(ownerID uint, name string, memberIDs []uint, avatar string) (*chatentity.Group, error) {
 	name = strings.TrimSpace(name)
@@ -51,10 +73,19 @@ func (s *groupServiceImpl) Create(ownerID uint, name string, memberIDs []uint, a
 	if len(memberIDs) < 3 { // owner + at least 2 others as requirement
 		return nil, errors.New("at least 3 members including owner")
 	}
-	g := &chatentity.Group{Name: name, OwnerID: ownerID, Avatar: avatar}
+	verdict, err := s.moderator.Check(modentity.SceneGroupName, ownerID, name)
+	if err != nil {
+		return nil, err
+	}
+	g := &chatentity.Group{Name: verdict.Text, OwnerID: ownerID, Avatar: avatar}
 	if err := s.repo.Create(g, memberIDs); err != nil {
 		return nil, err
 	}
+	if err := s.media.Retain(avatar); err != nil {
+		logger.Warnf("retain group avatar %s failed: %v", avatar, err)
+	}
+	s.moderator.Flag(modentity.SceneGroupName, ownerID, g.ID, verdict)
+	s.notifyInvited(ownerID, g.ID, memberIDs)
 	return g, nil
 }
 
@@ -90,7 +121,7 @@ func (s *groupServiceImpl) ListMessages(groupID uint, page, pageSize int) ([]*ch
 	return nil, 0, errors.New("messages not supported")
 }
 
-func (s *groupServiceImpl) SendMessage(groupID, senderID uint, msgType, content string) (*chatentity.GroupMessage, error) {
+func (s *groupServiceImpl) SendMessage(groupID, senderID uint, msgType, content string, mentions []uint) (*chatentity.GroupMessage, error) {
 	if groupID == 0 || senderID == 0 || content == "" {
 		return nil, errors.New("invalid params")
 	}
@@ -101,7 +132,16 @@ func (s *groupServiceImpl) SendMessage(groupID, senderID uint, msgType, content
 	if !ok {
 		return nil, errors.New("not a member")
 	}
-	m := &chatentity.GroupMessage{GroupID: groupID, SenderID: senderID, Type: firstNonEmpty(msgType, "text"), Content: content, CreatedAt: time.Now()}
+	msgType = firstNonEmpty(msgType, "text")
+	var verdict *modsvc.Result
+	if msgType == "text" {
+		verdict, err = s.moderator.Check(modentity.SceneGroupMessage, senderID, content)
+		if err != nil {
+			return nil, err
+		}
+		content = verdict.Text
+	}
+	m := &chatentity.GroupMessage{GroupID: groupID, SenderID: senderID, Type: msgType, Content: content, Mentions: s.filterMentions(groupID, senderID, mentions), CreatedAt: time.Now()}
 	type msgRepo interface {
 		SaveMessage(m *chatentity.GroupMessage) error
 	}
@@ -112,12 +152,55 @@ func (s *groupServiceImpl) SendMessage(groupID, senderID uint, msgType, content
 	} else {
 		return nil, errors.New("save not supported")
 	}
+	if err := attachMedia(s.media, mediaViewer(s.msgRepo, s.repo, senderID), senderID, mediaentity.RefGroupMessage, m.ID, msgType, content); err != nil {
+		// Attach 失败时整体回滚，没有指向该消息的附件，只需删除消息
+		if derr := s.repo.DeleteMessage(m.ID); derr != nil {
+			logger.Errorf("delete group message %d after attach failure failed: %v", m.ID, derr)
+		}
+		return nil, err
+	}
+	s.moderator.Flag(modentity.SceneGroupMessage, senderID, m.ID, verdict)
+	if m.Mentions != "" {
+		for _, p := range strings.Split(m.Mentions, ",") {
+			id, _ := strconv.ParseUint(p, 10, 64)
+			s.notifier.Notify(&notifyentity.Notification{UserID: uint(id), ActorID: senderID, Type: notifyentity.TypeMention, TargetID: groupID, SubjectID: m.ID, Content: mentionExcerpt(msgType, content)})
+		}
+	}
 	return m, nil
 }
 
 func (s *groupServiceImpl) IsMember(groupID, userID uint) (bool, error) {
 	return s.repo.IsMember(groupID, userID)
 }
+func (s *groupServiceImpl) GetMessage(id uint) (*chatentity.GroupMessage, error) {
+	return s.repo.GetMessage(id)
+}
+func (s *groupServiceImpl) RecallMessage(userID, id uint) (*chatentity.GroupMessage, error) {
+	m, err := s.repo.GetMessage(id)
+	if err != nil || m == nil {
+		return nil, ErrMessageNotFound
+	}
+	if m.SenderID != userID {
+		// 非成员看不到群消息，与不存在一致
+		if ok, _ := s.repo.IsMember(m.GroupID, userID); !ok {
+			return nil, ErrMessageNotFound
+		}
+		return nil, ErrNotSender
+	}
+	if m.RecalledAt != nil {
+		return m, nil
+	}
+	now := time.Now()
+	if err := s.repo.RecallMessage(id, now); err != nil {
+		return nil, err
+	}
+	if err := s.media.DeleteByRef(mediaentity.RefGroupMessage, id); err != nil {
+		logger.Warnf("delete media of recalled group message %d failed: %v", id, err)
+	}
+	m.Content, m.Mentions, m.RecalledAt = "", "", &now
+	return m, nil
+}
+
 func (s *groupServiceImpl) Get(groupID uint) (*chatentity.Group, error) { return s.repo.Get(groupID) }
 func (s *groupServiceImpl) UpdateGroup(operatorID, groupID uint, name, avatar string) (*chatentity.Group, error) {
 	g, err := s.repo.Get(groupID)
@@ -128,8 +211,14 @@ func (s *groupServiceImpl) UpdateGroup(operatorID, groupID uint, name, avatar st
 		return nil, errors.New("no permission")
 	}
 	changed := false
+	oldAvatar := g.Avatar
+	var verdict *modsvc.Result
 	if name = strings.TrimSpace(name); name != "" && name != g.Name {
-		g.Name = name
+		verdict, err = s.moderator.Check(modentity.SceneGroupName, operatorID, name)
+		if err != nil {
+			return nil, err
+		}
+		g.Name = verdict.Text
 		changed = true
 	}
 	if avatar != "" && avatar != g.Avatar {
@@ -142,6 +231,15 @@ func (s *groupServiceImpl) UpdateGroup(operatorID, groupID uint, name, avatar st
 	if err := s.repo.Update(g); err != nil {
 		return nil, err
 	}
+	if g.Avatar != oldAvatar {
+		// 先引用新头像再释放旧头像，旧头像引用归零后由后台回收
+		if err := s.media.Retain(g.Avatar); err != nil {
+			logger.Warnf("retain group avatar %s failed: %v", g.Avatar, err)
+		} else if err := s.media.Release(oldAvatar); err != nil {
+			logger.Warnf("release group avatar %s failed: %v", oldAvatar, err)
+		}
+	}
+	s.moderator.Flag(modentity.SceneGroupName, operatorID, g.ID, verdict)
 	return g, nil
 }
 func (s *groupServiceImpl) ListUserGroups(userID uint, page, pageSize int) ([]*chatentity.Group, int64, error) {
@@ -178,7 +276,18 @@ func (s *groupServiceImpl) AddMembers(operatorID, groupID uint, userIDs []uint)
 	if g.OwnerID != operatorID {
 		return errors.New("no permission")
 	}
-	return s.repo.AddMembers(groupID, userIDs)
+	if err := s.repo.AddMembers(groupID, userIDs); err != nil {
+		return err
+	}
+	s.notifyInvited(operatorID, groupID, userIDs)
+	return nil
+}
+
+// notifyInvited 通知被拉入群聊的成员（不含操作者）
+func (s *groupServiceImpl) notifyInvited(operatorID, groupID uint, userIDs []uint) {
+	for _, id := range userIDs {
+		s.notifier.Notify(&notifyentity.Notification{UserID: id, ActorID: operatorID, Type: notifyentity.TypeGroupInvite, TargetID: groupID})
+	}
 }
 
 func (s *groupServiceImpl) RemoveMember(operatorID, groupID, targetUserID uint) error {
@@ -198,3 +307,37 @@ func (s *groupServiceImpl) RemoveMember(operatorID, groupID, targetUserID uint)
 func (s *groupServiceImpl) ListMembers(groupID uint) ([]uint, error) {
 	return s.repo.ListMemberIDs(groupID)
 }
+
+// filterMentions 保留群成员，剔除拉黑了发送方的成员（被拉黑方无感知）
+func (s *groupServiceImpl) filterMentions(groupID, senderID uint, mentions []uint) string {
+	if len(mentions) == 0 {
+		return ""
+	}
+	seen := make(map[uint]struct{}, len(mentions))
+	parts := make([]string, 0, len(mentions))
+	for _, id := range mentions {
+		if id == 0 || id == senderID {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		if ok, _ := s.repo.IsMember(groupID, id); !ok {
+			continue
+		}
+		if blocked, _ := s.friendRepo.IsBlocked(id, senderID); blocked {
+			continue
+		}
+		parts = append(parts, strconv.FormatUint(uint64(id), 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+// mentionExcerpt @ 通知的摘要：文本取原文，其它类型显示占位
+func mentionExcerpt(msgType, content string) string {
+	if msgType == "text" {
+		return content
+	}
+	return "[" + msgType + "]"
+}
@@ -0,0 +1,49 @@
+package entity
+
+import "time"
+
+// Kind 上传场景
+type Kind string
+
+const (
+	KindMoment Kind = "moment"
+	KindChat   Kind = "chat"
+)
+
+// RefType 媒体关联的业务对象类型；空值表示已上传但尚未被引用
+type RefType string
+
+const (
+	RefNone         RefType = ""
+	RefMoment       RefType = "moment"
+	RefMessage      RefType = "message"       // 私聊消息
+	RefGroupMessage RefType = "group_message" // 群聊消息
+)
+
+// Attachment 媒体附件：上传时登记元数据，发布动态/发送消息时按顺序关联到业务对象
+type Attachment struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	OwnerID  uint   `json:"owner_id" gorm:"not null;index"`
+	Kind     Kind   `json:"kind" gorm:"type:varchar(16);not null"`
+	Path     string `json:"path" gorm:"type:varchar(255);not null;index"` // 相对路径 /bucket/object
+	Mime     string `json:"mime" gorm:"type:varchar(100);default:''"`
+	Width    int    `json:"width" gorm:"not null;default:0"`
+	Height   int    `json:"height" gorm:"not null;default:0"`
+	Size     int64  `json:"size" gorm:"not null;default:0"`
+	Blurhash string `json:"blurhash" gorm:"type:varchar(64);default:''"` // 加载前的模糊占位
+	// ThumbPath / MediumPath 服务端生成的缩略图与中图（视频为封面图的衍生图）
+	ThumbPath  string `json:"thumb_path" gorm:"type:varchar(255);default:''"`
+	MediumPath string `json:"medium_path" gorm:"type:varchar(255);default:''"`
+	// DurationMs / PosterPath 视频时长（毫秒）与封面图，客户端先展示封面再拉流
+	DurationMs int64  `json:"duration_ms" gorm:"not null;default:0"`
+	PosterPath string `json:"poster_path" gorm:"type:varchar(255);default:''"`
+	// RefType / RefID / Position 关联的业务对象及其中的顺序
+	RefType   RefType   `json:"ref_type" gorm:"type:varchar(16);not null;default:'';index:idx_media_ref,priority:1"`
+	RefID     uint      `json:"ref_id" gorm:"not null;default:0;index:idx_media_ref,priority:2"`
+	Position  int       `json:"position" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at"`
+	// Reservation 上传时预占的配额（见 StorageReservation），登记时在同一事务内释放；不入库
+	Reservation uint `json:"-" gorm:"-"`
+}
+
+func (Attachment) TableName() string { return "app_media_attachments" }
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// Blob 按内容寻址存储的对象：同一 bucket 内相同内容（客户端上传原文的 SHA-256）只存一份，重复上传复用已有对象。
+// RefCount 为引用该对象的业务数据数量（动态、消息附件、用户与群头像、视频封面），归零且超过宽限期后由后台回收对象及其衍生图
+type Blob struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	Bucket string `json:"bucket" gorm:"type:varchar(64);not null;uniqueIndex:idx_media_blob_hash,priority:1"`
+	SHA256 string `json:"sha256" gorm:"column:sha256;type:char(64);not null;uniqueIndex:idx_media_blob_hash,priority:2"`
+	Path   string `json:"path" gorm:"type:varchar(255);not null;uniqueIndex"` // 相对路径 /bucket/object
+	Mime   string `json:"mime" gorm:"type:varchar(100);default:''"`
+	Size   int64  `json:"size" gorm:"not null;default:0"`
+	// ThumbPath / MediumPath 随对象一同回收的衍生图
+	ThumbPath  string    `json:"thumb_path" gorm:"type:varchar(255);default:''"`
+	MediumPath string    `json:"medium_path" gorm:"type:varchar(255);default:''"`
+	RefCount   int       `json:"ref_count" gorm:"not null;default:0;index:idx_media_blob_orphan,priority:1"`
+	CreatedAt  time.Time `json:"created_at"`
+	// UpdatedAt 最近一次上传命中或引用变化的时间，回收宽限期从此计算
+	UpdatedAt time.Time `json:"updated_at" gorm:"index:idx_media_blob_orphan,priority:2"`
+}
+
+func (Blob) TableName() string { return "app_media_blobs" }
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// QuarantinedFile 病毒扫描命中的上传：内容移入隔离 bucket（私有，不对外签发地址），原位置不保留，供管理员复核后处置
+type QuarantinedFile struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	OwnerID   uint   `json:"owner_id" gorm:"not null;index"`
+	Profile   string `json:"profile" gorm:"type:varchar(32);not null"`
+	Filename  string `json:"filename" gorm:"type:varchar(255);default:''"`
+	Mime      string `json:"mime" gorm:"type:varchar(100);default:''"`
+	Size      int64  `json:"size" gorm:"not null;default:0"`
+	Signature string `json:"signature" gorm:"type:varchar(255);not null"` // 扫描引擎报告的病毒特征名
+	// Path 隔离后的相对路径 /{quarantine-bucket}/{原 bucket}/{原对象名}
+	Path      string    `json:"path" gorm:"type:varchar(512);not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (QuarantinedFile) TableName() string { return "app_media_quarantine" }
@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// StorageQuota 管理员为 App 用户单独设置的存储配额；没有记录的用户使用默认档位
+type StorageQuota struct {
+	UserID uint `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	// Tier 配额档位（见配置 quota.tiers），为空表示默认档位
+	Tier string `json:"tier" gorm:"type:varchar(32);not null;default:''"`
+	// LimitMB 覆盖档位的总量上限（MB），为空沿用档位，0 表示不限制
+	LimitMB   *int64    `json:"limit_mb"`
+	UpdatedBy uint      `json:"updated_by" gorm:"not null;default:0"` // 最后修改的管理员
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (StorageQuota) TableName() string { return "app_storage_quotas" }
+
+// StorageReservation 上传进行中预占的配额空间：写入对象前按大小预占，登记为附件时在同一事务内释放；
+// 上传失败或会话中止时释放，遗留的记录过期后不再计入
+type StorageReservation struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	Category  string    `json:"category" gorm:"type:varchar(16);not null"`
+	Size      int64     `json:"size" gorm:"not null;default:0"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (StorageReservation) TableName() string { return "app_storage_reservations" }
@@ -0,0 +1,45 @@
+package entity
+
+import "time"
+
+// UploadStatus 分片上传会话状态
+type UploadStatus string
+
+const (
+	UploadUploading UploadStatus = "uploading"
+	UploadCompleted UploadStatus = "completed"
+	UploadAborted   UploadStatus = "aborted"
+)
+
+// UploadMode 上传方式
+type UploadMode string
+
+const (
+	UploadMultipart UploadMode = "multipart" // 经服务端分片上传
+	UploadPresigned UploadMode = "presigned" // 客户端凭预签名地址直传对象存储
+)
+
+// UploadSession 大文件上传会话：multipart 对应对象存储的一次 multipart upload，客户端中断后凭 ID 查询已上传分片继续上传；
+// presigned 记录已签发的直传地址，客户端上传后回调完成，超时未完成的由后台删除对象
+type UploadSession struct {
+	ID       uint         `json:"id" gorm:"primaryKey"`
+	Mode     UploadMode   `json:"mode" gorm:"type:varchar(16);not null;default:'multipart'"`
+	OwnerID  uint         `json:"owner_id" gorm:"not null;index"`
+	Profile  string       `json:"profile" gorm:"type:varchar(32);not null"`
+	Bucket   string       `json:"bucket" gorm:"type:varchar(64);not null"`
+	Object   string       `json:"object" gorm:"type:varchar(255);not null"`
+	UploadID string       `json:"-" gorm:"type:varchar(255);not null;default:''"` // 对象存储的 multipart upload ID，直传为空
+	Filename string       `json:"filename" gorm:"type:varchar(255);default:''"`
+	Mime     string       `json:"mime" gorm:"type:varchar(100);default:''"` // 客户端声明的类型，完成时按内容校验
+	Size     int64        `json:"size" gorm:"not null;default:0"`           // 客户端声明的总大小
+	Status   UploadStatus `json:"status" gorm:"type:varchar(16);not null;default:'uploading';index:idx_upload_session_expire,priority:1"`
+	// ExpiresAt 超时未完成的会话由后台任务中止并清理已上传的分片或对象
+	ExpiresAt time.Time `json:"expires_at" gorm:"index:idx_upload_session_expire,priority:2"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Reservation 会话期间按声明大小预占的配额，完成时按实际大小重新预占，中止时释放
+	Reservation uint `json:"-" gorm:"not null;default:0"`
+}
+
+func (UploadSession) TableName() string { return "app_media_upload_sessions" }
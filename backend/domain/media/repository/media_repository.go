@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"time"
+
+	mediaentity "alice/domain/media/entity"
+)
+
+type MediaRepository interface {
+	// Create 登记附件；a.Reservation 不为 0 时在同一事务内释放该预占
+	Create(a *mediaentity.Attachment) error
+	// Get 不存在时返回 nil
+	Get(id uint) (*mediaentity.Attachment, error)
+	Save(a *mediaentity.Attachment) error
+	// SaveAttached 在同一事务内保存已关联的附件并按 paths 增加对象引用，失败时全部回滚
+	SaveAttached(list []*mediaentity.Attachment, paths []string) error
+	// FindUnattached ownerID 上传且尚未被引用的附件，不存在时返回 nil
+	FindUnattached(ownerID uint, path string) (*mediaentity.Attachment, error)
+	// FindOwned ownerID 上传的该路径最近一条附件（不论是否已被引用），不存在时返回 nil
+	FindOwned(ownerID uint, path string) (*mediaentity.Attachment, error)
+	// ListByPath 该路径已关联到业务对象的附件，按登记时间倒序，至多 limit 条（用于转发时查找可见的来源）
+	ListByPath(path string, limit int) ([]*mediaentity.Attachment, error)
+	// ListByRefs 批量读取若干业务对象的附件，按 position 排序
+	ListByRefs(refType mediaentity.RefType, refIDs []uint) ([]*mediaentity.Attachment, error)
+	DeleteByRef(refType mediaentity.RefType, refID uint) error
+
+	// 分片上传会话
+	CreateSession(s *mediaentity.UploadSession) error
+	SaveSession(s *mediaentity.UploadSession) error
+	// GetSession 不存在时返回 nil
+	GetSession(id uint) (*mediaentity.UploadSession, error)
+	// ListExpiredSessions before 之前过期且仍在上传中的会话
+	ListExpiredSessions(before time.Time, limit int) ([]*mediaentity.UploadSession, error)
+
+	// 内容寻址对象
+	// TouchBlob 查找 bucket 内内容相同的对象并刷新其时间（推迟回收），不存在时返回 nil
+	TouchBlob(bucket, sha256 string) (*mediaentity.Blob, error)
+	// EnsureBlob 登记对象；bucket 内已有相同内容时不写入，返回已有的一条
+	EnsureBlob(b *mediaentity.Blob) (*mediaentity.Blob, error)
+	// AdjustBlobRefs 按路径增减引用计数（不低于 0），未登记的路径（如历史对象、外部地址）忽略
+	AdjustBlobRefs(paths []string, delta int) error
+	// ListOrphanBlobs 引用为 0 且 before 之后未被使用的对象
+	ListOrphanBlobs(before time.Time, limit int) ([]*mediaentity.Blob, error)
+	// CollectBlob 在事务内锁定仍满足回收条件的对象，执行 fn（删除存储中的对象）成功后删除记录；不再满足条件时返回 false
+	CollectBlob(id uint, before time.Time, fn func(b *mediaentity.Blob) error) (bool, error)
+	// DeleteUnattachedByPath 删除指向该路径且未被引用的附件登记
+	DeleteUnattachedByPath(path string) error
+	// DeleteBlobByPath 对象被生命周期策略删除后移除其登记，避免后续上传复用已不存在的对象
+	DeleteBlobByPath(path string) error
+
+	// ListReferencedPaths 返回业务数据中引用的、位于 bucket 内且等于 paths 之一或去掉扩展名后等于 stems 之一的对象路径。
+	// 引用来源：附件（含衍生图与封面）、内容寻址对象、进行中的上传会话、隔离登记、用户与群头像、动态图片与视频、消息内容；
+	// 生命周期任务按页传入列举到的对象，结果规模不超过传入的数量
+	ListReferencedPaths(bucket string, paths, stems []string) ([]string, error)
+
+	// CreateQuarantine 登记被隔离的感染文件
+	CreateQuarantine(q *mediaentity.QuarantinedFile) error
+
+	// 存储配额
+	// UsageByOwner 用户名下的存储用量（字节），按类别：chat / moment 为本人上传的附件，avatar 为当前头像
+	UsageByOwner(ownerID uint) (map[string]int64, error)
+	// GetQuota 不存在时返回 nil
+	GetQuota(userID uint) (*mediaentity.StorageQuota, error)
+	SaveQuota(q *mediaentity.StorageQuota) error
+	// ReserveQuota 在事务内锁定用户的配额记录（不存在时插入空记录，等同默认档位），同一用户的预占因此串行执行；
+	// 以已登记用量加未过期的预占（不含 replace）调用 fn，通过后删除 replace 并写入 res（res 为 nil 时只校验）
+	ReserveQuota(userID, replace uint, res *mediaentity.StorageReservation, fn func(q *mediaentity.StorageQuota, used map[string]int64) error) error
+	// DeleteReservation 释放预占，不存在时忽略
+	DeleteReservation(id uint) error
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"time"
+
+	mediaentity "alice/domain/media/entity"
+	"alice/infra/storage"
+	"alice/pkg/logger"
+)
+
+// orphanGrace 引用归零（或上传后始终未被引用）的对象保留时长，覆盖“先上传、后发布”的间隔
+const orphanGrace = 24 * time.Hour
+
+// hashSource 计算内容的 SHA-256（十六进制）
+func hashSource(src io.ReaderAt, size int64) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(src, 0, size)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// reuseBlob 以已有对象填充上传结果；宽高、时长等仍取本次解析的结果
+func reuseBlob(up *Upload, b *mediaentity.Blob) {
+	_, up.Object, _ = storage.SplitObjectPath(b.Path)
+	up.Path, up.Size, up.ContentType = b.Path, b.Size, b.Mime
+	up.Variants = nil
+	for name, p := range map[string]string{"thumb": b.ThumbPath, "medium": b.MediumPath} {
+		if p != "" {
+			if up.Variants == nil {
+				up.Variants = map[string]string{}
+			}
+			up.Variants[name] = p
+		}
+	}
+}
+
+// registerBlob 登记新写入的对象；并发上传了相同内容、或分片/直传命中已有内容时，删除本次写入的对象并复用已有的一份
+func (s *uploadServiceImpl) registerBlob(ctx context.Context, up *Upload) error {
+	b, err := s.repo.EnsureBlob(&mediaentity.Blob{Bucket: up.Bucket, SHA256: up.SHA256, Path: up.Path, Mime: up.ContentType, Size: up.Size,
+		ThumbPath: up.Variants["thumb"], MediumPath: up.Variants["medium"]})
+	if err != nil {
+		return err
+	}
+	if b.Path != up.Path {
+		s.deleteObjects(ctx, up.Path, up.Variants["thumb"], up.Variants["medium"])
+		reuseBlob(up, b)
+	}
+	return nil
+}
+
+// deleteObjects 按相对路径删除对象，失败只记录日志
+func (s *uploadServiceImpl) deleteObjects(ctx context.Context, paths ...string) {
+	for _, p := range paths {
+		bucket, object, ok := storage.SplitObjectPath(p)
+		if !ok {
+			continue
+		}
+		if err := s.store.DeleteObject(ctx, bucket, object); err != nil {
+			logger.Warnf("delete object %s failed: %v", p, err)
+		}
+	}
+}
+
+func (s *uploadServiceImpl) CollectOrphans(ctx context.Context) (int, error) {
+	if s.store == nil {
+		return 0, nil
+	}
+	before := time.Now().Add(-orphanGrace)
+	list, err := s.repo.ListOrphanBlobs(before, 200)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, b := range list {
+		ok, err := s.repo.CollectBlob(b.ID, before, func(b *mediaentity.Blob) error {
+			bucket, object, _ := storage.SplitObjectPath(b.Path)
+			if err := s.store.DeleteObject(ctx, bucket, object); err != nil {
+				return err
+			}
+			s.deleteObjects(ctx, b.ThumbPath, b.MediumPath)
+			return nil
+		})
+		if err != nil {
+			logger.Warnf("collect orphaned object %s failed: %v", b.Path, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := s.repo.DeleteUnattachedByPath(b.Path); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	mediarepo "alice/domain/media/repository"
+	"alice/infra/config"
+	"alice/infra/storage"
+	"alice/pkg/imaging"
+	"alice/pkg/logger"
+)
+
+// ErrLifecycleRunning 已有任务在执行（定时任务与手动触发不并发）
+var ErrLifecycleRunning = errors.New("lifecycle sweep already running")
+
+// 生命周期报告中对象被删除的原因
+const (
+	ReasonOrphaned = "orphaned" // 超过宽限期仍未被任何业务数据引用
+	ReasonExpired  = "expired"  // 超过 bucket 的过期天数
+)
+
+const (
+	// lifecyclePageSize 每次列举的对象数
+	lifecyclePageSize = 1000
+	// maxReportItems 报告中每个 bucket 列出的对象上限，计数不受影响
+	maxReportItems = 500
+)
+
+// LifecycleItem 待删除（或已删除）的对象
+type LifecycleItem struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+	Reason       string    `json:"reason"`
+}
+
+// BucketReport 单个 bucket 的处理结果
+type BucketReport struct {
+	Bucket   string `json:"bucket"`
+	Scanned  int    `json:"scanned"`
+	Orphaned int    `json:"orphaned"`
+	Expired  int    `json:"expired"`
+	// Bytes 待删除对象的总大小
+	Bytes   int64 `json:"bytes"`
+	Deleted int   `json:"deleted"`
+	Failed  int   `json:"failed"`
+	// Items 待删除的对象，至多 maxReportItems 条
+	Items []LifecycleItem `json:"items"`
+	Error string          `json:"error,omitempty"`
+}
+
+// LifecycleReport 一次生命周期任务的报告
+type LifecycleReport struct {
+	DryRun     bool            `json:"dry_run"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+	Buckets    []*BucketReport `json:"buckets"`
+}
+
+// LifecycleService 对象生命周期：删除长期未被引用的对象（被删除的动态、被替换的头像、上传后未发布的文件等），
+// 并按 bucket 策略删除过期对象。与 UploadService.CollectOrphans 互补：后者只回收登记过的内容寻址对象
+type LifecycleService interface {
+	// Run 逐个 bucket 扫描；dryRun 时只生成报告不删除
+	Run(ctx context.Context, dryRun bool) (*LifecycleReport, error)
+}
+
+type lifecycleServiceImpl struct {
+	running  sync.Mutex
+	store    storage.ObjectStorage
+	repo     mediarepo.MediaRepository
+	buckets  []string
+	grace    time.Duration
+	policies map[string]config.BucketPolicy
+}
+
+// NewLifecycleService store 为空时 Run 返回 ErrStorageUnavailable
+func NewLifecycleService(store storage.ObjectStorage, repo mediarepo.MediaRepository, cfg *config.Config) LifecycleService {
+	return &lifecycleServiceImpl{store: store, repo: repo, buckets: cfg.LifecycleBuckets(),
+		grace: time.Duration(cfg.Storage.Lifecycle.OrphanGraceHours) * time.Hour, policies: cfg.Storage.Lifecycle.Buckets}
+}
+
+func (s *lifecycleServiceImpl) Run(ctx context.Context, dryRun bool) (*LifecycleReport, error) {
+	if s.store == nil {
+		return nil, ErrStorageUnavailable
+	}
+	if !s.running.TryLock() {
+		return nil, ErrLifecycleRunning
+	}
+	defer s.running.Unlock()
+	report := &LifecycleReport{DryRun: dryRun, StartedAt: time.Now()}
+	for _, b := range s.buckets {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		report.Buckets = append(report.Buckets, s.sweep(ctx, b, dryRun))
+	}
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// sweep 逐页处理：按本页对象查询引用筛出待删除对象，删除前只对这些对象重新读取引用，
+// 避免新增的引用（如转发旧图）指向被删除的对象；内存占用与数据库查询规模只与页大小有关
+func (s *lifecycleServiceImpl) sweep(ctx context.Context, bucket string, dryRun bool) *BucketReport {
+	r := &BucketReport{Bucket: bucket}
+	policy := s.policies[bucket]
+	grace := s.grace
+	if policy.OrphanGraceHours > 0 {
+		grace = time.Duration(policy.OrphanGraceHours) * time.Hour
+	}
+	orphans := policy.OrphanGraceHours >= 0
+	expireBefore := time.Time{}
+	if policy.ExpireDays > 0 {
+		expireBefore = time.Now().AddDate(0, 0, -policy.ExpireDays)
+	}
+	orphanBefore := time.Now().Add(-grace)
+
+	token := ""
+	for {
+		page, err := s.store.ListObjectPage(ctx, bucket, "", true, token, lifecyclePageSize)
+		if errors.Is(err, storage.ErrBucketNotFound) { // 尚未有过上传
+			return r
+		}
+		if err != nil {
+			r.Error = err.Error()
+			return r
+		}
+		var candidates, unreferenced []LifecycleItem
+		for _, obj := range page.Objects {
+			r.Scanned++
+			item := LifecycleItem{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified}
+			switch {
+			case !expireBefore.IsZero() && obj.LastModified.Before(expireBefore):
+				item.Reason = ReasonExpired
+				candidates = append(candidates, item)
+			case orphans && obj.LastModified.Before(orphanBefore):
+				item.Reason = ReasonOrphaned
+				unreferenced = append(unreferenced, item)
+			}
+		}
+		if unreferenced, err = s.unreferenced(bucket, unreferenced); err != nil {
+			r.Error = err.Error()
+			return r
+		}
+		// 删除前只复查本页筛出的对象
+		if !dryRun {
+			if unreferenced, err = s.unreferenced(bucket, unreferenced); err != nil {
+				r.Error = err.Error()
+				return r
+			}
+		}
+		candidates = append(candidates, unreferenced...)
+		s.remove(ctx, r, bucket, candidates, dryRun)
+		if page.NextToken == "" {
+			return r
+		}
+		token = page.NextToken
+	}
+}
+
+// unreferenced 过滤掉仍被业务数据引用的对象
+func (s *lifecycleServiceImpl) unreferenced(bucket string, items []LifecycleItem) ([]LifecycleItem, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	paths := make([]string, 0, len(items))
+	var stems []string
+	for _, item := range items {
+		p := storage.ObjectPath(bucket, item.Key)
+		paths = append(paths, p)
+		if main, ok := variantStem(p); ok {
+			stems = append(stems, main)
+		}
+	}
+	found, err := s.repo.ListReferencedPaths(bucket, paths, stems)
+	if err != nil {
+		return nil, err
+	}
+	refs := newReferenceSet(found)
+	out := items[:0]
+	for _, item := range items {
+		if !refs.has(storage.ObjectPath(bucket, item.Key)) {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+// remove 记录并删除（dryRun 时只记录）待删除对象
+func (s *lifecycleServiceImpl) remove(ctx context.Context, r *BucketReport, bucket string, items []LifecycleItem, dryRun bool) {
+	for _, item := range items {
+		p := storage.ObjectPath(bucket, item.Key)
+		if item.Reason == ReasonOrphaned {
+			r.Orphaned++
+		} else {
+			r.Expired++
+		}
+		r.Bytes += item.Size
+		if len(r.Items) < maxReportItems {
+			r.Items = append(r.Items, item)
+		}
+		if dryRun {
+			continue
+		}
+		if err := s.store.DeleteObject(ctx, bucket, item.Key); err != nil {
+			logger.Warnf("lifecycle delete %s failed: %v", p, err)
+			r.Failed++
+			continue
+		}
+		r.Deleted++
+		// 过期删除的可能是内容寻址对象，移除登记以免后续上传复用
+		if item.Reason == ReasonExpired {
+			if err := s.repo.DeleteBlobByPath(p); err != nil {
+				logger.Warnf("lifecycle remove blob %s failed: %v", p, err)
+			}
+		}
+	}
+}
+
+// referenceSet 被引用的对象路径；衍生图（{主对象}_{规格}{ext}）随主对象视为被引用，兼容未登记衍生图路径的头像
+type referenceSet struct {
+	paths map[string]bool
+	stems map[string]bool
+}
+
+func newReferenceSet(paths []string) *referenceSet {
+	rs := &referenceSet{paths: make(map[string]bool, len(paths)), stems: make(map[string]bool, len(paths))}
+	for _, p := range paths {
+		rs.paths[p] = true
+		rs.stems[strings.TrimSuffix(p, path.Ext(p))] = true
+	}
+	return rs
+}
+
+func (rs *referenceSet) has(p string) bool {
+	if rs.paths[p] {
+		return true
+	}
+	main, ok := variantStem(p)
+	return ok && rs.stems[main]
+}
+
+// variantStem 衍生图路径对应的主对象路径（不含扩展名）
+func variantStem(p string) (string, bool) {
+	stem := strings.TrimSuffix(p, path.Ext(p))
+	for _, v := range imaging.DefaultVariants {
+		if main, ok := strings.CutSuffix(stem, "_"+v.Name); ok {
+			return main, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+	"image"
+
+	mediaentity "alice/domain/media/entity"
+	mediarepo "alice/domain/media/repository"
+	"alice/pkg/blurhash"
+)
+
+// 占位图分量数（横 x 纵）
+const blurX, blurY = 4, 3
+
+// forwardCandidates 转发时检查的来源附件数上限
+const forwardCandidates = 20
+
+// ErrMediaNotAccessible 引用的路径既不是本人上传，也不是本人可见的已有附件
+var ErrMediaNotAccessible = errors.New("media not found or not accessible")
+
+// CanView 判断用户能否查看已关联到业务对象的附件，用于转发
+type CanView func(a *mediaentity.Attachment) bool
+
+type MediaService interface {
+	// Register 上传完成后登记媒体元数据，此时尚未关联业务对象；img 非空时据此填充缺失的宽高与模糊占位（视频传封面图）
+	Register(a *mediaentity.Attachment, img image.Image) error
+	// Attach 按顺序将路径关联到业务对象并增加对象引用。路径须为 ownerID 本人上传的对象；
+	// canView 非空时也接受 ownerID 可见的已有附件（转发），复用其元数据。
+	// 任一路径不满足时不做任何关联，返回 ErrMediaNotAccessible；写入在同一事务内完成，失败时同样不留下关联
+	Attach(ownerID uint, kind mediaentity.Kind, refType mediaentity.RefType, refID uint, paths []string, canView CanView) ([]*mediaentity.Attachment, error)
+	// Get 不存在时返回 nil
+	Get(id uint) (*mediaentity.Attachment, error)
+	ListByRef(refType mediaentity.RefType, refID uint) ([]*mediaentity.Attachment, error)
+	// ListByRefs 批量读取，按业务对象 ID 分组
+	ListByRefs(refType mediaentity.RefType, refIDs []uint) (map[uint][]*mediaentity.Attachment, error)
+	// DeleteByRef 删除业务对象的附件并减少对象引用，引用归零的对象由后台回收
+	DeleteByRef(refType mediaentity.RefType, refID uint) error
+	// Retain / Release 增减不经附件登记的引用（用户与群头像）
+	Retain(paths ...string) error
+	Release(paths ...string) error
+}
+
+type mediaServiceImpl struct {
+	repo mediarepo.MediaRepository
+}
+
+func NewMediaService(repo mediarepo.MediaRepository) MediaService {
+	return &mediaServiceImpl{repo: repo}
+}
+
+func (s *mediaServiceImpl) Register(a *mediaentity.Attachment, img image.Image) error {
+	if img != nil {
+		if a.Width == 0 || a.Height == 0 {
+			b := img.Bounds()
+			a.Width, a.Height = b.Dx(), b.Dy()
+		}
+		a.Blurhash, _ = blurhash.Encode(blurX, blurY, img)
+	}
+	return s.repo.Create(a)
+}
+
+func (s *mediaServiceImpl) Attach(ownerID uint, kind mediaentity.Kind, refType mediaentity.RefType, refID uint, paths []string, canView CanView) ([]*mediaentity.Attachment, error) {
+	// 先全部解析再写入，避免部分路径被拒绝时留下半关联的附件
+	out := make([]*mediaentity.Attachment, 0, len(paths))
+	for _, p := range paths {
+		a, err := s.resolve(ownerID, kind, p, canView)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	var refs []string
+	for i, a := range out {
+		a.RefType, a.RefID, a.Position = refType, refID, i
+		refs = append(refs, attachmentPaths(a)...)
+	}
+	if err := s.repo.SaveAttached(out, refs); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// resolve 待关联的附件：优先使用本人尚未引用的上传；本人上传过但已被引用（重复发送）或可见的已有附件（转发）复制元数据新建一条
+func (s *mediaServiceImpl) resolve(ownerID uint, kind mediaentity.Kind, p string, canView CanView) (*mediaentity.Attachment, error) {
+	a, err := s.repo.FindUnattached(ownerID, p)
+	if err != nil || a != nil {
+		return a, err
+	}
+	src, err := s.repo.FindOwned(ownerID, p)
+	if err != nil {
+		return nil, err
+	}
+	if src == nil && canView != nil {
+		list, err := s.repo.ListByPath(p, forwardCandidates)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range list {
+			if canView(c) {
+				src = c
+				break
+			}
+		}
+	}
+	if src == nil {
+		return nil, ErrMediaNotAccessible
+	}
+	return &mediaentity.Attachment{
+		OwnerID: ownerID, Kind: kind, Path: p,
+		Mime: src.Mime, Width: src.Width, Height: src.Height, Size: src.Size, Blurhash: src.Blurhash,
+		ThumbPath: src.ThumbPath, MediumPath: src.MediumPath,
+		DurationMs: src.DurationMs, PosterPath: src.PosterPath,
+	}, nil
+}
+
+func (s *mediaServiceImpl) Get(id uint) (*mediaentity.Attachment, error) {
+	return s.repo.Get(id)
+}
+
+func (s *mediaServiceImpl) ListByRef(refType mediaentity.RefType, refID uint) ([]*mediaentity.Attachment, error) {
+	return s.repo.ListByRefs(refType, []uint{refID})
+}
+
+func (s *mediaServiceImpl) ListByRefs(refType mediaentity.RefType, refIDs []uint) (map[uint][]*mediaentity.Attachment, error) {
+	list, err := s.repo.ListByRefs(refType, refIDs)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[uint][]*mediaentity.Attachment, len(refIDs))
+	for _, a := range list {
+		out[a.RefID] = append(out[a.RefID], a)
+	}
+	return out, nil
+}
+
+func (s *mediaServiceImpl) DeleteByRef(refType mediaentity.RefType, refID uint) error {
+	list, err := s.repo.ListByRefs(refType, []uint{refID})
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteByRef(refType, refID); err != nil {
+		return err
+	}
+	var paths []string
+	for _, a := range list {
+		paths = append(paths, attachmentPaths(a)...)
+	}
+	return s.repo.AdjustBlobRefs(paths, -1)
+}
+
+func (s *mediaServiceImpl) Retain(paths ...string) error {
+	return s.repo.AdjustBlobRefs(paths, 1)
+}
+
+func (s *mediaServiceImpl) Release(paths ...string) error {
+	return s.repo.AdjustBlobRefs(paths, -1)
+}
+
+// attachmentPaths 附件引用的对象：主对象与视频封面（封面单独上传）；缩略图、中图随主对象回收，不单独计数
+func attachmentPaths(a *mediaentity.Attachment) []string {
+	return []string{a.Path, a.PosterPath}
+}
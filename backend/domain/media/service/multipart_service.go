@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	mediaentity "alice/domain/media/entity"
+	"alice/infra/config"
+	"alice/infra/storage"
+	"alice/pkg/logger"
+)
+
+var (
+	ErrChunkedNotSupported = errors.New("chunked upload not supported for this profile")
+	ErrSessionNotFound     = errors.New("upload session not found")
+	ErrSessionClosed       = errors.New("upload session already closed")
+	ErrSessionExpired      = errors.New("upload session expired")
+	ErrInvalidPart         = errors.New("invalid part number or size")
+	ErrNoParts             = errors.New("no parts uploaded")
+	ErrSizeMismatch        = errors.New("uploaded size does not match declared size")
+)
+
+// DefaultPartSize 建议客户端使用的分片大小（S3 要求除最后一片外不小于 5MB）
+const DefaultPartSize = 8 << 20
+
+const (
+	// sessionTTL 分片上传会话有效期，超时未完成由后台任务中止
+	sessionTTL = 24 * time.Hour
+	// maxPartNumber S3 允许的最大分片号
+	maxPartNumber = 10000
+	// maxPartSize 单个分片上限，与 S3 一致
+	maxPartSize = 5 << 30
+)
+
+// chunkedAllowed 仅登记为附件（配置了 kind）且无需整图处理的场景支持分片上传
+func chunkedAllowed(p config.UploadProfile) bool {
+	return p.Kind != "" && !hasStep(p, StepImage)
+}
+
+func (s *uploadServiceImpl) InitMultipart(ctx context.Context, ownerID uint, profile, filename, contentType string, size int64) (*mediaentity.UploadSession, error) {
+	if ownerID == 0 || size <= 0 {
+		return nil, errors.New("invalid params")
+	}
+	p, bucket, err := s.profile(profile, "")
+	if err != nil {
+		return nil, err
+	}
+	if !chunkedAllowed(p) {
+		return nil, ErrChunkedNotSupported
+	}
+	if max := s.maxBytes(p); max > 0 && size > max {
+		return nil, ErrFileTooLarge
+	}
+	// 声明的类型先做一次快速校验，完成时再按内容确认
+	if contentType != "" && !s.mimeAllowed(p, contentType) {
+		return nil, ErrMimeNotAllowed
+	}
+	// 按声明的大小在会话有效期内预占配额，完成时再按实际大小确认
+	reservation, err := s.reserveQuota(p, ownerID, size, sessionTTL, 0)
+	if err != nil {
+		return nil, err
+	}
+	object := objectBase(p, ownerID) + fileExt(filename)
+	uploadID, err := s.store.NewMultipartUpload(ctx, bucket, object, contentType)
+	if err != nil {
+		s.releaseQuota(reservation)
+		return nil, err
+	}
+	sess := &mediaentity.UploadSession{Mode: mediaentity.UploadMultipart, OwnerID: ownerID, Profile: profile, Bucket: bucket, Object: object, UploadID: uploadID,
+		Filename: filename, Mime: contentType, Size: size, Reservation: reservation, Status: mediaentity.UploadUploading, ExpiresAt: time.Now().Add(sessionTTL)}
+	if err := s.repo.CreateSession(sess); err != nil {
+		_ = s.store.AbortMultipartUpload(ctx, bucket, object, uploadID)
+		s.releaseQuota(reservation)
+		return nil, err
+	}
+	return sess, nil
+}
+
+// session 查找本人指定上传方式的进行中会话
+func (s *uploadServiceImpl) session(ownerID, id uint, mode mediaentity.UploadMode) (*mediaentity.UploadSession, error) {
+	if s.store == nil {
+		return nil, ErrStorageUnavailable
+	}
+	sess, err := s.repo.GetSession(id)
+	if err != nil {
+		return nil, err
+	}
+	if sess == nil || sess.OwnerID != ownerID || sess.Mode != mode {
+		return nil, ErrSessionNotFound
+	}
+	if sess.Status != mediaentity.UploadUploading {
+		return nil, ErrSessionClosed
+	}
+	if time.Now().After(sess.ExpiresAt) {
+		return nil, ErrSessionExpired
+	}
+	return sess, nil
+}
+
+func (s *uploadServiceImpl) UploadPart(ctx context.Context, ownerID, sessionID uint, partNumber int, r io.Reader, size int64) (storage.Part, error) {
+	if partNumber < 1 || partNumber > maxPartNumber || size <= 0 || size > maxPartSize {
+		return storage.Part{}, ErrInvalidPart
+	}
+	sess, err := s.session(ownerID, sessionID, mediaentity.UploadMultipart)
+	if err != nil {
+		return storage.Part{}, err
+	}
+	if size > sess.Size {
+		return storage.Part{}, ErrFileTooLarge
+	}
+	return s.store.PutObjectPart(ctx, sess.Bucket, sess.Object, sess.UploadID, partNumber, r, size)
+}
+
+func (s *uploadServiceImpl) GetMultipart(ctx context.Context, ownerID, sessionID uint) (*mediaentity.UploadSession, []storage.Part, error) {
+	sess, err := s.session(ownerID, sessionID, mediaentity.UploadMultipart)
+	if err != nil {
+		return nil, nil, err
+	}
+	parts, err := s.store.ListObjectParts(ctx, sess.Bucket, sess.Object, sess.UploadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sess, parts, nil
+}
+
+func (s *uploadServiceImpl) CompleteMultipart(ctx context.Context, ownerID, sessionID uint) (*Upload, error) {
+	sess, err := s.session(ownerID, sessionID, mediaentity.UploadMultipart)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := s.profiles[sess.Profile]
+	if !ok {
+		return nil, ErrUnknownProfile
+	}
+	parts, err := s.store.ListObjectParts(ctx, sess.Bucket, sess.Object, sess.UploadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, ErrNoParts
+	}
+	var total int64
+	for _, part := range parts {
+		total += part.Size
+	}
+	if total != sess.Size {
+		return nil, ErrSizeMismatch
+	}
+	if err := s.store.CompleteMultipartUpload(ctx, sess.Bucket, sess.Object, sess.UploadID, parts); err != nil {
+		return nil, err
+	}
+	return s.finish(ctx, p, sess)
+}
+
+// finish 对象写入完成后按场景校验内容，失败则删除对象并关闭会话
+func (s *uploadServiceImpl) finish(ctx context.Context, p config.UploadProfile, sess *mediaentity.UploadSession) (*Upload, error) {
+	up, err := s.verifyObject(ctx, p, sess)
+	if err != nil {
+		_ = s.store.DeleteObject(ctx, sess.Bucket, sess.Object)
+		s.releaseQuota(sess.Reservation)
+		sess.Status = mediaentity.UploadAborted
+		_ = s.repo.SaveSession(sess)
+		return nil, err
+	}
+	// 会话的对象名在内容确定前生成，无法按摘要命名；登记后若已有相同内容则删除本次对象并复用已有的一份
+	if err := s.registerBlob(ctx, up); err != nil {
+		s.releaseQuota(up.Reservation)
+		return nil, err
+	}
+	sess.Status = mediaentity.UploadCompleted
+	if err := s.repo.SaveSession(sess); err != nil {
+		s.releaseQuota(up.Reservation)
+		return nil, err
+	}
+	return up, nil
+}
+
+// verifyObject 读取已写入的对象，按场景处理步骤解析类型与元数据、做病毒扫描、按实际大小重新预占配额并计算摘要；对象大小不得超过声明的大小
+func (s *uploadServiceImpl) verifyObject(ctx context.Context, p config.UploadProfile, sess *mediaentity.UploadSession) (*Upload, error) {
+	obj, size, err := s.store.GetObject(ctx, sess.Bucket, sess.Object)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	if size <= 0 || size > sess.Size {
+		return nil, ErrSizeMismatch
+	}
+	up := &Upload{Bucket: sess.Bucket, Object: sess.Object, Path: storage.ObjectPath(sess.Bucket, sess.Object), Size: size,
+		Kind: mediaentity.Kind(p.Kind), PosterProfile: p.PosterProfile}
+	if hasStep(p, StepVideo) {
+		if _, err := probeVideo(up, obj, size, p.MaxDurationSeconds); err != nil {
+			return nil, err
+		}
+	} else {
+		up.ContentType = firstNonEmpty(sess.Mime, sniff(obj, size))
+	}
+	if !s.mimeAllowed(p, up.ContentType) {
+		return nil, ErrMimeNotAllowed
+	}
+	if err := s.scan(ctx, obj, size, scanTarget{OwnerID: sess.OwnerID, Profile: sess.Profile, Filename: sess.Filename,
+		Mime: up.ContentType, Bucket: sess.Bucket, Object: sess.Object}); err != nil {
+		return nil, err
+	}
+	// 在同一事务内以实际大小的短期预占取代会话的预占，等待登记为附件
+	if up.Reservation, err = s.reserveQuota(p, sess.OwnerID, size, reservationTTL, sess.Reservation); err != nil {
+		return nil, err
+	}
+	sess.Reservation = up.Reservation
+	if up.SHA256, err = hashSource(obj, size); err != nil {
+		return nil, err
+	}
+	return up, nil
+}
+
+func (s *uploadServiceImpl) AbortUpload(ctx context.Context, ownerID, sessionID uint) error {
+	if s.store == nil {
+		return ErrStorageUnavailable
+	}
+	// 已过期但尚未清理的会话同样允许主动中止
+	sess, err := s.repo.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+	if sess == nil || sess.OwnerID != ownerID {
+		return ErrSessionNotFound
+	}
+	if sess.Status != mediaentity.UploadUploading {
+		return ErrSessionClosed
+	}
+	return s.abort(ctx, sess)
+}
+
+// abort 分片上传释放已上传的分片；直传删除可能已上传的对象
+func (s *uploadServiceImpl) abort(ctx context.Context, sess *mediaentity.UploadSession) error {
+	var err error
+	if sess.Mode == mediaentity.UploadPresigned {
+		err = s.store.DeleteObject(ctx, sess.Bucket, sess.Object)
+	} else {
+		err = s.store.AbortMultipartUpload(ctx, sess.Bucket, sess.Object, sess.UploadID)
+	}
+	if err != nil {
+		return err
+	}
+	s.releaseQuota(sess.Reservation)
+	sess.Status = mediaentity.UploadAborted
+	return s.repo.SaveSession(sess)
+}
+
+func (s *uploadServiceImpl) CleanupExpired(ctx context.Context) (int, error) {
+	if s.store == nil {
+		return 0, nil
+	}
+	sessions, err := s.repo.ListExpiredSessions(time.Now(), 200)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, sess := range sessions {
+		if err := s.abort(ctx, sess); err != nil {
+			logger.Warnf("abort expired %s upload %d failed: %v", sess.Mode, sess.ID, err)
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
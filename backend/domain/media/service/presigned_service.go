@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	mediaentity "alice/domain/media/entity"
+	"alice/infra/storage"
+)
+
+var (
+	ErrContentTypeRequired = errors.New("content type required")
+	ErrInvalidMethod       = errors.New("method must be PUT or POST")
+	ErrObjectMissing       = errors.New("object not uploaded")
+)
+
+const (
+	// presignExpiry 直传地址有效期
+	presignExpiry = 15 * time.Minute
+	// presignGrace 地址过期后仍允许回调完成的时间（覆盖临近过期开始的慢速上传），超过后由后台删除对象
+	presignGrace = time.Hour
+)
+
+// PresignedUpload 直传凭证
+type PresignedUpload struct {
+	Session *mediaentity.UploadSession
+	Method  string
+	URL     string
+	// Headers PUT 时必须携带的请求头（已参与签名）
+	Headers map[string]string
+	// FormData POST 时需原样提交的表单字段，文件字段 file 须放在最后
+	FormData  map[string]string
+	ExpiresAt time.Time
+}
+
+func (s *uploadServiceImpl) PresignUpload(ctx context.Context, ownerID uint, profile, filename, contentType string, size int64, method string) (*PresignedUpload, error) {
+	if ownerID == 0 || size <= 0 {
+		return nil, errors.New("invalid params")
+	}
+	method = strings.ToUpper(method)
+	if method == "" {
+		method = http.MethodPut
+	}
+	if method != http.MethodPut && method != http.MethodPost {
+		return nil, ErrInvalidMethod
+	}
+	p, bucket, err := s.profile(profile, "")
+	if err != nil {
+		return nil, err
+	}
+	if !chunkedAllowed(p) {
+		return nil, ErrChunkedNotSupported
+	}
+	if max := s.maxBytes(p); max > 0 && size > max {
+		return nil, ErrFileTooLarge
+	}
+	// 类型写入签名/策略，对象存储拒绝不一致的上传；完成时再按内容确认
+	if contentType == "" {
+		return nil, ErrContentTypeRequired
+	}
+	if !s.mimeAllowed(p, contentType) {
+		return nil, ErrMimeNotAllowed
+	}
+	// 签名限定了大小上限，按声明的大小在会话有效期内预占配额，回调时再按实际大小确认
+	now := time.Now()
+	reservation, err := s.reserveQuota(p, ownerID, size, presignExpiry+presignGrace, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	object := objectBase(p, ownerID) + fileExt(filename)
+	res := &PresignedUpload{Method: method, ExpiresAt: now.Add(presignExpiry)}
+	if method == http.MethodPut {
+		res.URL, err = s.store.PresignedPutURL(ctx, bucket, object, contentType, size, presignExpiry)
+		res.Headers = map[string]string{"Content-Type": contentType}
+	} else {
+		res.URL, res.FormData, err = s.store.PresignedPostPolicy(ctx, bucket, object, contentType, size, presignExpiry)
+	}
+	if err != nil {
+		s.releaseQuota(reservation)
+		return nil, err
+	}
+	res.Session = &mediaentity.UploadSession{Mode: mediaentity.UploadPresigned, OwnerID: ownerID, Profile: profile, Bucket: bucket, Object: object,
+		Filename: filename, Mime: contentType, Size: size, Reservation: reservation, Status: mediaentity.UploadUploading, ExpiresAt: now.Add(presignExpiry + presignGrace)}
+	if err := s.repo.CreateSession(res.Session); err != nil {
+		s.releaseQuota(reservation)
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *uploadServiceImpl) CompletePresigned(ctx context.Context, ownerID, sessionID uint) (*Upload, error) {
+	sess, err := s.session(ownerID, sessionID, mediaentity.UploadPresigned)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := s.profiles[sess.Profile]
+	if !ok {
+		return nil, ErrUnknownProfile
+	}
+	// 对象尚未上传时保留会话，客户端可重试回调
+	if _, err := s.store.StatObject(ctx, sess.Bucket, sess.Object); err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, ErrObjectMissing
+		}
+		return nil, err
+	}
+	return s.finish(ctx, p, sess)
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	mediaentity "alice/domain/media/entity"
+	mediarepo "alice/domain/media/repository"
+	"alice/infra/config"
+)
+
+// 配额类别（UploadProfile.Quota）
+const (
+	QuotaChat   = "chat"
+	QuotaMoment = "moment"
+	QuotaAvatar = "avatar"
+)
+
+// quotaCategories 用量报告中类别的顺序
+var quotaCategories = []string{QuotaChat, QuotaMoment, QuotaAvatar}
+
+var (
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+	ErrUnknownTier   = errors.New("unknown quota tier")
+	ErrInvalidLimit  = errors.New("limit must not be negative")
+)
+
+// QuotaExceededError 上传超出配额，errors.Is 匹配 ErrQuotaExceeded；Category 为 total 表示超出总量
+type QuotaExceededError struct {
+	Category string
+	Used     int64
+	Limit    int64
+	Size     int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	scope := "storage"
+	if e.Category != "total" {
+		scope = e.Category + " storage"
+	}
+	return fmt.Sprintf("%s quota exceeded: %s used of %s, upload needs %s", scope, formatMB(e.Used), formatMB(e.Limit), formatMB(e.Size))
+}
+
+func (e *QuotaExceededError) Is(target error) bool { return target == ErrQuotaExceeded }
+
+func formatMB(n int64) string {
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
+
+// CategoryUsage 单个类别的用量（字节），Limit 为 0 表示该类别不单独限制
+type CategoryUsage struct {
+	Category string `json:"category"`
+	Used     int64  `json:"used"`
+	Limit    int64  `json:"limit"`
+}
+
+// StorageUsage 用户的存储用量与配额（字节），Limit 为 0 表示不限制总量
+type StorageUsage struct {
+	UserID uint   `json:"user_id"`
+	Tier   string `json:"tier"`
+	// Enabled 配额是否强制执行；关闭时只统计不拒绝
+	Enabled bool  `json:"enabled"`
+	Used    int64 `json:"used"`
+	Limit   int64 `json:"limit"`
+	// LimitMB 管理员单独设置的总量上限（MB），为空表示沿用档位
+	LimitMB    *int64          `json:"limit_mb"`
+	Categories []CategoryUsage `json:"categories"`
+}
+
+// category 按类别查找用量
+func (u *StorageUsage) category(name string) *CategoryUsage {
+	for i := range u.Categories {
+		if u.Categories[i].Category == name {
+			return &u.Categories[i]
+		}
+	}
+	return nil
+}
+
+// check 校验向 category 新增 size 字节后是否超出配额；头像为替换语义（新头像取代当前头像）
+func (u *StorageUsage) check(category string, size int64) error {
+	c := u.category(category)
+	if c == nil {
+		return nil
+	}
+	used, total := c.Used, u.Used
+	if category == QuotaAvatar {
+		total -= used
+		used = 0
+	}
+	if c.Limit > 0 && used+size > c.Limit {
+		return &QuotaExceededError{Category: category, Used: used, Limit: c.Limit, Size: size}
+	}
+	if u.Limit > 0 && total+size > u.Limit {
+		return &QuotaExceededError{Category: "total", Used: total, Limit: u.Limit, Size: size}
+	}
+	return nil
+}
+
+// QuotaService App 用户存储配额：按类别统计用量（同一内容被多次上传时按次计入上传者），上传前预占空间，防止并发上传共同超出档位限制
+type QuotaService interface {
+	Usage(userID uint) (*StorageUsage, error)
+	// Reserve 在同一用户的锁内校验向 category 新增 size 字节后是否超出配额（已登记用量加进行中的预占），
+	// 通过则预占该空间至 ttl 后并返回预占 ID；replace 不为 0 时在同一事务内以本次预占取代它（上传完成时按实际大小重新确认）。
+	// 头像为替换语义，只校验不预占；配额未启用或 category 为空时不校验。不预占时返回 0，超出时返回 *QuotaExceededError
+	Reserve(userID uint, category string, size int64, ttl time.Duration, replace uint) (uint, error)
+	// Release 释放预占（上传失败、会话中止或内容不登记为附件），id 为 0 时忽略
+	Release(id uint) error
+	// SetQuota 管理员设置用户的档位（为空使用默认档位）与总量上限（为空沿用档位，0 不限制），返回更新后的用量
+	SetQuota(operatorID, userID uint, tier string, limitMB *int64) (*StorageUsage, error)
+}
+
+type quotaServiceImpl struct {
+	repo        mediarepo.MediaRepository
+	enabled     bool
+	defaultTier string
+	tiers       map[string]config.QuotaTier
+}
+
+func NewQuotaService(repo mediarepo.MediaRepository, cfg *config.Config) QuotaService {
+	return &quotaServiceImpl{repo: repo, enabled: cfg.Quota.Enabled, defaultTier: cfg.Quota.DefaultTier, tiers: cfg.Quota.Tiers}
+}
+
+func (s *quotaServiceImpl) Usage(userID uint) (*StorageUsage, error) {
+	q, err := s.repo.GetQuota(userID)
+	if err != nil {
+		return nil, err
+	}
+	used, err := s.repo.UsageByOwner(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.usage(userID, q, used), nil
+}
+
+// usage 按配额记录（可为空）与各类别用量计算档位限制
+func (s *quotaServiceImpl) usage(userID uint, q *mediaentity.StorageQuota, used map[string]int64) *StorageUsage {
+	// 单独设置的档位已从配置中移除时回落到默认档位
+	tierName := s.defaultTier
+	if q != nil && q.Tier != "" {
+		if _, ok := s.tiers[q.Tier]; ok {
+			tierName = q.Tier
+		}
+	}
+	tier := s.tiers[tierName]
+	u := &StorageUsage{UserID: userID, Tier: tierName, Enabled: s.enabled, Limit: tier.TotalMB << 20}
+	if q != nil && q.LimitMB != nil {
+		u.LimitMB = q.LimitMB
+		u.Limit = *q.LimitMB << 20
+	}
+	for _, c := range quotaCategories {
+		u.Categories = append(u.Categories, CategoryUsage{Category: c, Used: used[c], Limit: tier.CategoryMB[c] << 20})
+		u.Used += used[c]
+	}
+	return u
+}
+
+func (s *quotaServiceImpl) Reserve(userID uint, category string, size int64, ttl time.Duration, replace uint) (uint, error) {
+	if !s.enabled || category == "" {
+		return 0, s.Release(replace)
+	}
+	var res *mediaentity.StorageReservation
+	if category != QuotaAvatar {
+		res = &mediaentity.StorageReservation{UserID: userID, Category: category, Size: size, ExpiresAt: time.Now().Add(ttl)}
+	}
+	err := s.repo.ReserveQuota(userID, replace, res, func(q *mediaentity.StorageQuota, used map[string]int64) error {
+		return s.usage(userID, q, used).check(category, size)
+	})
+	if err != nil || res == nil {
+		return 0, err
+	}
+	return res.ID, nil
+}
+
+func (s *quotaServiceImpl) Release(id uint) error {
+	if id == 0 {
+		return nil
+	}
+	return s.repo.DeleteReservation(id)
+}
+
+func (s *quotaServiceImpl) SetQuota(operatorID, userID uint, tier string, limitMB *int64) (*StorageUsage, error) {
+	if tier != "" {
+		if _, ok := s.tiers[tier]; !ok {
+			return nil, ErrUnknownTier
+		}
+	}
+	if limitMB != nil && *limitMB < 0 {
+		return nil, ErrInvalidLimit
+	}
+	if err := s.repo.SaveQuota(&mediaentity.StorageQuota{UserID: userID, Tier: tier, LimitMB: limitMB, UpdatedBy: operatorID}); err != nil {
+		return nil, err
+	}
+	return s.Usage(userID)
+}
@@ -0,0 +1,412 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	mediaentity "alice/domain/media/entity"
+	mediarepo "alice/domain/media/repository"
+	"alice/infra/config"
+	"alice/infra/storage"
+	"alice/pkg/imaging"
+	"alice/pkg/logger"
+	"alice/pkg/scan"
+	"alice/pkg/videoprobe"
+)
+
+// 上传处理步骤（UploadProfile.Process）
+const (
+	StepImage    = "image"    // 按内容嗅探图片类型，去除元数据并按 EXIF 方向摆正
+	StepVariants = "variants" // 生成缩略图与中图，需与 image 同时配置
+	StepVideo    = "video"    // 解析 MP4/MOV 时长与尺寸，其他容器按内容嗅探
+)
+
+var (
+	ErrUnknownProfile     = errors.New("unknown upload profile")
+	ErrStorageUnavailable = errors.New("storage not initialized")
+	ErrBucketRequired     = errors.New("bucket required")
+	ErrFileTooLarge       = errors.New("file too large")
+	ErrMimeNotAllowed     = errors.New("mime not allowed")
+	ErrNotImage           = errors.New("only image allowed")
+	ErrNotVideo           = errors.New("only video allowed")
+	ErrUnsupportedVideo   = errors.New("only mp4/mov video allowed")
+	ErrVideoTooLong       = errors.New("video too long")
+)
+
+// reservationTTL 上传完成到登记为附件之间配额预占的有效期，调用方既未登记也未释放时过期后不再计入
+const reservationTTL = 10 * time.Minute
+
+// UploadRequest 一次上传
+type UploadRequest struct {
+	Profile string
+	// OwnerID 参与生成对象名的主体 ID（用户或群）
+	OwnerID uint
+	// Reader / Size 上传内容及其长度（-1 表示未知）；Reader 支持 io.ReaderAt（如 multipart 文件）时直接流式写入，不整体读入内存
+	Reader io.Reader
+	Size   int64
+	// Filename / ContentType 客户端提供的文件名与类型，仅未配置处理步骤的场景使用
+	Filename    string
+	ContentType string
+	// Bucket / Object 指定目标位置（管理端上传），为空时按场景配置生成
+	Bucket string
+	Object string
+}
+
+// Upload 上传结果
+type Upload struct {
+	Bucket      string
+	Object      string
+	Path        string // 相对路径 /bucket/object
+	ContentType string // 真实类型（配置了处理步骤时由内容判断）
+	Size        int64
+	Width       int
+	Height      int
+	DurationMs  int64
+	// Variants 衍生图规格名到相对路径（thumb / medium）
+	Variants map[string]string
+	// Image 摆正后的图像，用于登记时生成模糊占位
+	Image image.Image
+	// Kind / PosterProfile 场景配置的附件类型与封面图场景
+	Kind          mediaentity.Kind
+	PosterProfile string
+	// SHA256 上传原文的摘要，同一 bucket 内相同内容复用已有对象；管理端指定对象名的上传为空
+	SHA256 string
+	// Reservation 计入配额的上传预占的空间，登记为附件时释放；不登记的内容由调用方释放
+	Reservation uint
+}
+
+// Attachment 转为待登记的媒体附件
+func (u *Upload) Attachment(ownerID uint) *mediaentity.Attachment {
+	return &mediaentity.Attachment{OwnerID: ownerID, Kind: u.Kind, Path: u.Path, Mime: u.ContentType, Width: u.Width, Height: u.Height,
+		Size: u.Size, DurationMs: u.DurationMs, ThumbPath: u.Variants["thumb"], MediumPath: u.Variants["medium"], Reservation: u.Reservation}
+}
+
+// UploadService 统一的上传入口：按场景配置校验大小与类型、执行处理步骤并写入对象存储
+type UploadService interface {
+	Upload(ctx context.Context, req UploadRequest) (*Upload, error)
+
+	// 分片上传（断点续传），仅对配置了 kind 且无需整图处理的场景开放（如视频）
+	// InitMultipart 创建会话；size 为客户端声明的总大小
+	InitMultipart(ctx context.Context, ownerID uint, profile, filename, contentType string, size int64) (*mediaentity.UploadSession, error)
+	// UploadPart 上传一个分片（编号 1~10000，重复上传同一编号会覆盖）；除最后一片外每片不小于 5MB
+	UploadPart(ctx context.Context, ownerID, sessionID uint, partNumber int, r io.Reader, size int64) (storage.Part, error)
+	// GetMultipart 查询会话及已上传的分片，客户端据此跳过已完成的分片
+	GetMultipart(ctx context.Context, ownerID, sessionID uint) (*mediaentity.UploadSession, []storage.Part, error)
+	// CompleteMultipart 合并分片并按场景校验内容，校验失败时删除对象
+	CompleteMultipart(ctx context.Context, ownerID, sessionID uint) (*Upload, error)
+
+	// 客户端直传（预签名地址），适用场景与分片上传相同
+	// PresignUpload 签发直传地址：method 为 PUT（签名绑定类型与大小）或 POST（表单策略限定类型与大小上限）
+	PresignUpload(ctx context.Context, ownerID uint, profile, filename, contentType string, size int64, method string) (*PresignedUpload, error)
+	// CompletePresigned 客户端上传完成后回调：确认对象存在并按场景校验内容，校验失败时删除对象
+	CompletePresigned(ctx context.Context, ownerID, sessionID uint) (*Upload, error)
+
+	// AbortUpload 取消分片上传或直传会话
+	AbortUpload(ctx context.Context, ownerID, sessionID uint) error
+	// CleanupExpired 清理超时未完成的会话：中止分片上传，删除已直传但未回调的对象
+	CleanupExpired(ctx context.Context) (int, error)
+	// CollectOrphans 回收引用归零且超过宽限期的内容寻址对象（含衍生图）及其未引用的附件登记
+	CollectOrphans(ctx context.Context) (int, error)
+}
+
+type uploadServiceImpl struct {
+	store    storage.ObjectStorage
+	repo     mediarepo.MediaRepository
+	profiles map[string]config.UploadProfile
+	// 场景未配置时沿用的全局限制
+	maxSizeMB    int
+	allowedMIMEs []string
+	// scanner 为空时不做病毒扫描；命中的文件移入 quarantineBucket
+	scanner          scan.Scanner
+	quarantineBucket string
+	// quota 校验场景配置了配额类别的上传
+	quota QuotaService
+}
+
+// NewUploadService store 为空时所有上传返回 ErrStorageUnavailable；scanner 为空时不扫描
+func NewUploadService(store storage.ObjectStorage, repo mediarepo.MediaRepository, scanner scan.Scanner, quota QuotaService, cfg *config.Config) UploadService {
+	return &uploadServiceImpl{store: store, repo: repo, profiles: cfg.UploadProfiles, maxSizeMB: cfg.Minio.MaxFileSizeMB, allowedMIMEs: cfg.Minio.AllowedMIMEs,
+		scanner: scanner, quarantineBucket: cfg.Minio.QuarantineBucket, quota: quota}
+}
+
+// profile 查找场景并确定目标 bucket
+func (s *uploadServiceImpl) profile(name, bucket string) (config.UploadProfile, string, error) {
+	p, ok := s.profiles[name]
+	if !ok {
+		return p, "", ErrUnknownProfile
+	}
+	if s.store == nil {
+		return p, "", ErrStorageUnavailable
+	}
+	bucket = firstNonEmpty(bucket, p.Bucket)
+	if bucket == "" {
+		return p, "", ErrBucketRequired
+	}
+	return p, bucket, nil
+}
+
+// maxBytes 场景的单文件大小上限，0 表示不限制
+func (s *uploadServiceImpl) maxBytes(p config.UploadProfile) int64 {
+	if p.MaxSizeMB > 0 {
+		return int64(p.MaxSizeMB) * 1024 * 1024
+	}
+	return int64(s.maxSizeMB) * 1024 * 1024
+}
+
+// reserveQuota 场景计入配额时为上传者预占空间，返回预占 ID（不预占时为 0）；replace 为同一上传此前的预占
+func (s *uploadServiceImpl) reserveQuota(p config.UploadProfile, ownerID uint, size int64, ttl time.Duration, replace uint) (uint, error) {
+	if s.quota == nil || p.Quota == "" {
+		return 0, nil
+	}
+	return s.quota.Reserve(ownerID, p.Quota, size, ttl, replace)
+}
+
+// releaseQuota 上传未完成时释放预占，失败时由过期兜底
+func (s *uploadServiceImpl) releaseQuota(id uint) {
+	if s.quota == nil || id == 0 {
+		return
+	}
+	if err := s.quota.Release(id); err != nil {
+		logger.Warnf("release quota reservation %d failed: %v", id, err)
+	}
+}
+
+func (s *uploadServiceImpl) mimeAllowed(p config.UploadProfile, ct string) bool {
+	if len(p.AllowedMIMEs) > 0 {
+		return mimeAllowed(p.AllowedMIMEs, ct)
+	}
+	return mimeAllowed(s.allowedMIMEs, ct)
+}
+
+// objectBase 按场景前缀生成对象名（不含扩展名）：{prefix}-{id}-{时间戳}
+func objectBase(p config.UploadProfile, ownerID uint) string {
+	return firstNonEmpty(p.Prefix, "file") + "-" + strconv.FormatUint(uint64(ownerID), 10) + "-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+}
+
+func (s *uploadServiceImpl) Upload(ctx context.Context, req UploadRequest) (_ *Upload, err error) {
+	p, bucket, err := s.profile(req.Profile, req.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	src, size, err := openSource(req.Reader, req.Size, s.maxBytes(p))
+	if err != nil {
+		return nil, err
+	}
+
+	up := &Upload{Bucket: bucket, Kind: mediaentity.Kind(p.Kind), PosterProfile: p.PosterProfile}
+	srcSize := size
+	var body io.Reader = io.NewSectionReader(src, 0, size)
+	var img *imaging.Result
+	ext := ""
+	switch {
+	case hasStep(p, StepImage):
+		// 图片需整体解码，读入内存（受场景大小上限约束）
+		data := make([]byte, size)
+		if _, err := src.ReadAt(data, 0); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		specs := []imaging.Spec(nil)
+		if hasStep(p, StepVariants) {
+			specs = imaging.DefaultVariants
+		}
+		if img, err = imaging.Process(data, specs); err != nil {
+			if errors.Is(err, imaging.ErrTooLarge) {
+				return nil, err
+			}
+			return nil, ErrNotImage
+		}
+		body, size, ext = bytes.NewReader(img.Data), int64(len(img.Data)), img.Ext
+		up.ContentType, up.Width, up.Height, up.Image = img.ContentType, img.Width, img.Height, img.Image
+	case hasStep(p, StepVideo):
+		if ext, err = probeVideo(up, src, size, p.MaxDurationSeconds); err != nil {
+			return nil, err
+		}
+	default:
+		up.ContentType = firstNonEmpty(req.ContentType, sniff(src, size))
+		ext = fileExt(req.Filename)
+	}
+	if !s.mimeAllowed(p, up.ContentType) {
+		return nil, ErrMimeNotAllowed
+	}
+	// 按写入的大小（图片为处理后的大小）预占配额；复用已有对象同样计入本次上传者
+	if up.Reservation, err = s.reserveQuota(p, req.OwnerID, size, reservationTTL, 0); err != nil {
+		return nil, err
+	}
+	reservation := up.Reservation
+	defer func() {
+		if err != nil {
+			s.releaseQuota(reservation)
+		}
+	}()
+
+	base := req.Object
+	if base == "" {
+		// 内容寻址：对象名取原文摘要，bucket 内已有相同内容时直接复用（已扫描过，无需重复写入）
+		if up.SHA256, err = hashSource(src, srcSize); err != nil {
+			return nil, err
+		}
+		b, err := s.repo.TouchBlob(bucket, up.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		if b != nil {
+			reuseBlob(up, b)
+			return up, nil
+		}
+		base = firstNonEmpty(p.Prefix, "file") + "-" + up.SHA256
+	} else {
+		ext = ""
+	}
+	up.Object, up.Size = base+ext, size
+	// 扫描客户端上传的原始内容（图片处理前），命中时不写入目标 bucket
+	if err := s.scan(ctx, src, srcSize, scanTarget{OwnerID: req.OwnerID, Profile: req.Profile, Filename: req.Filename,
+		Mime: up.ContentType, Bucket: bucket, Object: up.Object}); err != nil {
+		return nil, err
+	}
+	if _, err := s.store.PutObject(ctx, bucket, up.Object, body, size, up.ContentType); err != nil {
+		return nil, err
+	}
+	up.Path = storage.ObjectPath(bucket, up.Object)
+	if img != nil && len(img.Variants) > 0 {
+		up.Variants = make(map[string]string, len(img.Variants))
+		for _, v := range img.Variants {
+			name := base + "_" + v.Name + v.Ext
+			if _, err := s.store.PutObject(ctx, bucket, name, bytes.NewReader(v.Data), int64(len(v.Data)), v.ContentType); err != nil {
+				return nil, err
+			}
+			up.Variants[v.Name] = storage.ObjectPath(bucket, name)
+		}
+	}
+	if up.SHA256 != "" {
+		if err := s.registerBlob(ctx, up); err != nil {
+			return nil, err
+		}
+	}
+	return up, nil
+}
+
+// openSource 返回可随机读取的上传内容：multipart 文件本身支持 ReaderAt（大文件已由 net/http 落盘），
+// 直接使用以免整体读入内存；其他来源读入内存。max 为 0 不限制大小
+func openSource(r io.Reader, size, max int64) (io.ReaderAt, int64, error) {
+	if ra, ok := r.(io.ReaderAt); ok && size >= 0 {
+		if max > 0 && size > max {
+			return nil, 0, ErrFileTooLarge
+		}
+		return ra, size, nil
+	}
+	data, err := readLimited(r, max)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// sniff 按文件头判断类型
+func sniff(src io.ReaderAt, size int64) string {
+	head := make([]byte, min(size, 512))
+	n, _ := src.ReadAt(head, 0)
+	return http.DetectContentType(head[:n])
+}
+
+// probeVideo 解析视频元数据并返回扩展名；maxSeconds 大于 0 时要求可解析时长
+func probeVideo(up *Upload, src io.ReaderAt, size int64, maxSeconds int) (string, error) {
+	info, err := videoprobe.Probe(src, size)
+	if err != nil {
+		if maxSeconds > 0 {
+			return "", ErrUnsupportedVideo
+		}
+		// 其他容器（如 WebM/MKV）仅按内容确认是视频
+		ct := sniff(src, size)
+		if !strings.HasPrefix(ct, "video/") {
+			return "", ErrNotVideo
+		}
+		up.ContentType = ct
+		return "." + strings.TrimPrefix(ct, "video/"), nil
+	}
+	if maxSeconds > 0 && info.Duration > time.Duration(maxSeconds)*time.Second {
+		return "", ErrVideoTooLong
+	}
+	up.ContentType, up.Width, up.Height, up.DurationMs = info.ContentType, info.Width, info.Height, info.Duration.Milliseconds()
+	if info.ContentType == "video/quicktime" {
+		return ".mov", nil
+	}
+	return ".mp4", nil
+}
+
+// readLimited 读取全部内容，超过 max 字节（max 为 0 不限制）返回 ErrFileTooLarge
+func readLimited(r io.Reader, max int64) ([]byte, error) {
+	if max <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, ErrFileTooLarge
+	}
+	return data, nil
+}
+
+func hasStep(p config.UploadProfile, step string) bool {
+	for _, s := range p.Process {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// fileExt 取文件名扩展名，异常过长时丢弃
+func fileExt(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if len(ext) > 10 {
+		return ""
+	}
+	return ext
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// mimeAllowed 判断 contentType 是否在允许列表中：为空不限制；支持 "*"、精确匹配与 "image/*" 前缀通配
+func mimeAllowed(allowed []string, ct string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	ct = strings.ToLower(ct)
+	if i := strings.IndexByte(ct, ';'); i >= 0 { // 去掉 charset 等参数
+		ct = strings.TrimSpace(ct[:i])
+	}
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == "" {
+			continue
+		}
+		if a == "*" {
+			return true
+		}
+		if strings.HasSuffix(a, "/*") {
+			if strings.HasPrefix(ct, strings.TrimSuffix(a, "/*")+"/") {
+				return true
+			}
+		} else if a == ct {
+			return true
+		}
+	}
+	return false
+}
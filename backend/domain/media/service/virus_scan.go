@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	mediaentity "alice/domain/media/entity"
+	"alice/infra/storage"
+	"alice/pkg/logger"
+)
+
+var (
+	ErrInfected        = errors.New("file rejected by virus scan")
+	ErrScanUnavailable = errors.New("virus scan unavailable, please retry later")
+)
+
+// scanTarget 待扫描的上传内容及命中时登记所需的信息
+type scanTarget struct {
+	OwnerID  uint
+	Profile  string
+	Filename string
+	Mime     string
+	Bucket   string
+	Object   string
+}
+
+// scan 启用扫描时检查上传内容：命中时将原始内容复制到隔离 bucket 并登记，返回 ErrInfected（由调用方放弃或删除原对象）；
+// 扫描引擎不可用时返回 ErrScanUnavailable，不放行未经扫描的文件
+func (s *uploadServiceImpl) scan(ctx context.Context, src io.ReaderAt, size int64, t scanTarget) error {
+	if s.scanner == nil {
+		return nil
+	}
+	res, err := s.scanner.Scan(ctx, io.NewSectionReader(src, 0, size))
+	if err != nil {
+		logger.Errorf("virus scan %s/%s failed: %v", t.Bucket, t.Object, err)
+		return ErrScanUnavailable
+	}
+	if !res.Infected {
+		return nil
+	}
+	logger.Warnf("virus scan: %s/%s uploaded by %d infected with %s, quarantined", t.Bucket, t.Object, t.OwnerID, res.Signature)
+	s.quarantine(ctx, src, size, t, res.Signature)
+	return ErrInfected
+}
+
+// quarantine 隔离对象名保留原 bucket 与对象名，便于复核时追溯；失败只记录日志，上传仍被拒绝
+func (s *uploadServiceImpl) quarantine(ctx context.Context, src io.ReaderAt, size int64, t scanTarget, signature string) {
+	object := t.Bucket + "/" + t.Object
+	if _, err := s.store.PutObject(ctx, s.quarantineBucket, object, io.NewSectionReader(src, 0, size), size, "application/octet-stream"); err != nil {
+		logger.Errorf("quarantine %s/%s failed: %v", t.Bucket, t.Object, err)
+		return
+	}
+	q := &mediaentity.QuarantinedFile{OwnerID: t.OwnerID, Profile: t.Profile, Filename: t.Filename, Mime: t.Mime, Size: size,
+		Signature: signature, Path: storage.ObjectPath(s.quarantineBucket, object)}
+	if err := s.repo.CreateQuarantine(q); err != nil {
+		logger.Errorf("record quarantined file %s failed: %v", q.Path, err)
+	}
+}
@@ -0,0 +1,72 @@
+package entity
+
+import "time"
+
+// Action 命中敏感词后的处置动作
+type Action string
+
+const (
+	ActionBlock Action = "block" // 拒绝发布
+	ActionMask  Action = "mask"  // 替换为 *
+	ActionFlag  Action = "flag"  // 放行但进入人工复核队列
+)
+
+// Valid 判断动作是否合法
+func (a Action) Valid() bool {
+	return a == ActionBlock || a == ActionMask || a == ActionFlag
+}
+
+// Scene 审核场景（内容来源）
+type Scene string
+
+const (
+	SceneChatMessage  Scene = "chat_message"
+	SceneGroupMessage Scene = "group_message"
+	SceneGroupName    Scene = "group_name"
+	SceneNickname     Scene = "nickname"
+	SceneBio          Scene = "bio"
+	SceneMoment       Scene = "moment"
+	SceneComment      Scene = "comment"
+)
+
+// SensitiveWord 敏感词规则
+type SensitiveWord struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Word      string    `json:"word" gorm:"type:varchar(100);not null;uniqueIndex"`
+	Action    Action    `json:"action" gorm:"type:varchar(16);not null;default:'mask'"`
+	Category  string    `json:"category" gorm:"type:varchar(50);default:''"` // 分类：政治/色情/广告...
+	Enabled   bool      `json:"enabled" gorm:"not null;default:true"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (SensitiveWord) TableName() string { return "app_sensitive_words" }
+
+// FlagStatus 待审内容状态
+type FlagStatus string
+
+const (
+	FlagStatusPending  FlagStatus = "pending"  // 待人工复核
+	FlagStatusApproved FlagStatus = "approved" // 复核通过（误报）
+	FlagStatusRejected FlagStatus = "rejected" // 复核确认违规
+	FlagStatusBlocked  FlagStatus = "blocked"  // 已被规则直接拦截（仅留档）
+)
+
+// FlaggedContent 命中规则的内容留档 / 人工复核队列
+type FlaggedContent struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Scene    Scene  `json:"scene" gorm:"type:varchar(32);not null;index"`
+	UserID   uint   `json:"user_id" gorm:"not null;index"`       // 内容作者（App 用户）
+	TargetID uint   `json:"target_id" gorm:"not null;default:0"` // 内容 ID（消息/动态/评论等，拦截时为 0）
+	Content  string `json:"content" gorm:"type:text;not null"`
+	// Matched 命中的敏感词（逗号分隔）
+	Matched    string     `json:"matched" gorm:"type:text;default:''"`
+	Action     Action     `json:"action" gorm:"type:varchar(16);not null"`
+	Status     FlagStatus `json:"status" gorm:"type:varchar(16);not null;default:'pending';index"`
+	ReviewerID uint       `json:"reviewer_id" gorm:"not null;default:0"` // 后台审核人 users.id
+	ReviewNote string     `json:"review_note" gorm:"type:varchar(255);default:''"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (FlaggedContent) TableName() string { return "app_moderation_flags" }
@@ -0,0 +1,67 @@
+package entity
+
+import "time"
+
+// ReportTargetType 举报对象类型
+type ReportTargetType string
+
+const (
+	ReportTargetMessage      ReportTargetType = "message"       // 私聊消息
+	ReportTargetGroupMessage ReportTargetType = "group_message" // 群消息
+	ReportTargetMoment       ReportTargetType = "moment"        // 动态
+	ReportTargetComment      ReportTargetType = "comment"       // 动态评论
+	ReportTargetUser         ReportTargetType = "user"          // 用户（资料/行为）
+)
+
+// Valid 判断举报对象类型是否合法
+func (t ReportTargetType) Valid() bool {
+	switch t {
+	case ReportTargetMessage, ReportTargetGroupMessage, ReportTargetMoment, ReportTargetComment, ReportTargetUser:
+		return true
+	}
+	return false
+}
+
+// ReportStatus 举报处理状态
+type ReportStatus string
+
+const (
+	ReportStatusPending   ReportStatus = "pending"   // 待处理
+	ReportStatusTriaged   ReportStatus = "triaged"   // 已受理（处理中）
+	ReportStatusResolved  ReportStatus = "resolved"  // 已处理（违规成立）
+	ReportStatusDismissed ReportStatus = "dismissed" // 已驳回（不成立）
+)
+
+// Report 用户举报
+type Report struct {
+	ID           uint             `json:"id" gorm:"primaryKey"`
+	ReporterID   uint             `json:"reporter_id" gorm:"not null;index"`
+	TargetType   ReportTargetType `json:"target_type" gorm:"type:varchar(20);not null;index:idx_report_target,priority:1"`
+	TargetID     uint             `json:"target_id" gorm:"not null;index:idx_report_target,priority:2"`
+	TargetUserID uint             `json:"target_user_id" gorm:"not null;default:0;index"` // 被举报内容的作者
+	Reason       string           `json:"reason" gorm:"type:varchar(50);not null"`
+	Detail       string           `json:"detail" gorm:"type:varchar(500);default:''"`
+	Evidence     string           `json:"evidence" gorm:"type:text;default:''"` // 举报时的内容快照(JSON)，内容删除后仍可追溯
+	Status       ReportStatus     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	HandlerID    uint             `json:"handler_id" gorm:"not null;default:0"` // 处理人（后台用户）
+	Resolution   string           `json:"resolution" gorm:"type:varchar(255);default:''"`
+	HandledAt    *time.Time       `json:"handled_at"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+func (Report) TableName() string { return "app_reports" }
+
+// AuditLog 后台审核操作日志
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	OperatorID uint      `json:"operator_id" gorm:"not null;index"`
+	Action     string    `json:"action" gorm:"type:varchar(50);not null;index"` // 如 report.triage / content.delete / user.ban
+	TargetType string    `json:"target_type" gorm:"type:varchar(20);not null;default:''"`
+	TargetID   uint      `json:"target_id" gorm:"not null;default:0"`
+	ReportID   uint      `json:"report_id" gorm:"not null;default:0;index"`
+	Detail     string    `json:"detail" gorm:"type:text;default:''"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (AuditLog) TableName() string { return "app_moderation_audit_logs" }
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	modentity "alice/domain/moderation/entity"
+)
+
+type ModerationRepository interface {
+	// Sensitive words
+	ListEnabledWords() ([]*modentity.SensitiveWord, error)
+	ListWords(keyword string, offset, limit int) ([]*modentity.SensitiveWord, int64, error)
+	CreateWords(words []*modentity.SensitiveWord) error
+	UpdateWord(w *modentity.SensitiveWord) error
+	GetWord(id uint) (*modentity.SensitiveWord, error)
+	DeleteWord(id uint) error
+
+	// Flag queue
+	CreateFlag(f *modentity.FlaggedContent) error
+	GetFlag(id uint) (*modentity.FlaggedContent, error)
+	ListFlags(status modentity.FlagStatus, scene modentity.Scene, offset, limit int) ([]*modentity.FlaggedContent, int64, error)
+	UpdateFlag(f *modentity.FlaggedContent) error
+}
@@ -0,0 +1,15 @@
+package repository
+
+import modentity "alice/domain/moderation/entity"
+
+type ReportRepository interface {
+	Create(r *modentity.Report) error
+	Get(id uint) (*modentity.Report, error)
+	Update(r *modentity.Report) error
+	List(status modentity.ReportStatus, targetType modentity.ReportTargetType, offset, limit int) ([]*modentity.Report, int64, error)
+	// FindOpen 查找同一举报人对同一对象尚未结案的举报（用于去重）
+	FindOpen(reporterID uint, targetType modentity.ReportTargetType, targetID uint) (*modentity.Report, error)
+	// Audit
+	CreateAudit(l *modentity.AuditLog) error
+	ListAudits(operatorID uint, action string, offset, limit int) ([]*modentity.AuditLog, int64, error)
+}
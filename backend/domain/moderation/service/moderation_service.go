@@ -0,0 +1,321 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	modentity "alice/domain/moderation/entity"
+	modrepo "alice/domain/moderation/repository"
+	"alice/pkg/ahocorasick"
+	"alice/pkg/logger"
+)
+
+var (
+	ErrContentBlocked = errors.New("content contains sensitive words")
+	ErrInvalidAction  = errors.New("invalid moderation action")
+	ErrFlagReviewed   = errors.New("flag already reviewed")
+)
+
+// Result 文本审核结果
+type Result struct {
+	Text    string   // 处置后的文本（mask 动作会替换为 *）
+	Flagged bool     // 是否命中 flag 规则，需要调用方在内容落库后调用 Flag 入队
+	Matched []string // 命中的敏感词（去重）
+}
+
+type ModerationService interface {
+	// Check 审核文本：命中 block 返回 ErrContentBlocked；mask 返回替换后的文本；flag 置 Flagged
+	Check(scene modentity.Scene, userID uint, text string) (*Result, error)
+	// Flag 将已落库的内容写入人工复核队列（Result.Flagged 为 true 时调用）
+	Flag(scene modentity.Scene, userID, targetID uint, res *Result)
+	// Reload 重新加载词库（数据库 + 词库文件）并重建匹配自动机
+	Reload() error
+	// Watch 周期性检查词库文件/数据库变更并热加载，直到 ctx 结束
+	Watch(ctx context.Context, interval time.Duration)
+
+	// 后台管理
+	ListWords(keyword string, page, pageSize int) ([]*modentity.SensitiveWord, int64, error)
+	AddWords(words []string, action modentity.Action, category string) (int, error)
+	UpdateWord(id uint, action modentity.Action, category string, enabled bool) (*modentity.SensitiveWord, error)
+	DeleteWord(id uint) error
+	ListFlags(status modentity.FlagStatus, scene modentity.Scene, page, pageSize int) ([]*modentity.FlaggedContent, int64, error)
+	ReviewFlag(reviewerID, id uint, status modentity.FlagStatus, note string) (*modentity.FlaggedContent, error)
+}
+
+// ruleSet 一次加载得到的不可变规则集合
+type ruleSet struct {
+	matcher *ahocorasick.Matcher
+	actions []modentity.Action // 与 matcher 模式下标一一对应
+}
+
+type moderationServiceImpl struct {
+	repo      modrepo.ModerationRepository
+	wordsFile string
+
+	mu    sync.RWMutex
+	rules *ruleSet
+}
+
+// NewModerationService wordsFile 为可选的词库文件（每行 `词` 或 `词,动作`，# 开头为注释）
+func NewModerationService(repo modrepo.ModerationRepository, wordsFile string) ModerationService {
+	s := &moderationServiceImpl{repo: repo, wordsFile: wordsFile, rules: &ruleSet{matcher: ahocorasick.New(nil)}}
+	if err := s.Reload(); err != nil {
+		logger.Warnf("load sensitive words failed: %v", err)
+	}
+	return s
+}
+
+func (s *moderationServiceImpl) Check(scene modentity.Scene, userID uint, text string) (*Result, error) {
+	res := &Result{Text: text}
+	if strings.TrimSpace(text) == "" {
+		return res, nil
+	}
+	s.mu.RLock()
+	rules := s.rules
+	s.mu.RUnlock()
+
+	matches := rules.matcher.FindAll(text)
+	if len(matches) == 0 {
+		return res, nil
+	}
+	patterns := rules.matcher.Patterns()
+	seen := make(map[int]struct{}, len(matches))
+	blocked := false
+	var runes []rune
+	for _, m := range matches {
+		if _, ok := seen[m.Pattern]; !ok {
+			seen[m.Pattern] = struct{}{}
+			res.Matched = append(res.Matched, patterns[m.Pattern])
+		}
+		switch rules.actions[m.Pattern] {
+		case modentity.ActionBlock:
+			blocked = true
+		case modentity.ActionFlag:
+			res.Flagged = true
+		case modentity.ActionMask:
+			if runes == nil {
+				runes = []rune(text)
+			}
+			for i := m.Start; i < m.End && i < len(runes); i++ {
+				runes[i] = '*'
+			}
+		}
+	}
+	if blocked {
+		// 拦截内容同样留档，便于审核人员追溯
+		s.record(&modentity.FlaggedContent{Scene: scene, UserID: userID, Content: text, Matched: strings.Join(res.Matched, ","), Action: modentity.ActionBlock, Status: modentity.FlagStatusBlocked})
+		return nil, ErrContentBlocked
+	}
+	if runes != nil {
+		res.Text = string(runes)
+	}
+	return res, nil
+}
+
+func (s *moderationServiceImpl) Flag(scene modentity.Scene, userID, targetID uint, res *Result) {
+	if res == nil || !res.Flagged {
+		return
+	}
+	s.record(&modentity.FlaggedContent{Scene: scene, UserID: userID, TargetID: targetID, Content: res.Text, Matched: strings.Join(res.Matched, ","), Action: modentity.ActionFlag, Status: modentity.FlagStatusPending})
+}
+
+func (s *moderationServiceImpl) record(f *modentity.FlaggedContent) {
+	if err := s.repo.CreateFlag(f); err != nil {
+		logger.Errorf("record moderation flag failed: %v", err)
+	}
+}
+
+func (s *moderationServiceImpl) Reload() error {
+	// 文件词库在前，数据库规则覆盖同名词的动作
+	byWord := make(map[string]modentity.Action)
+	order := make([]string, 0)
+	add := func(word string, action modentity.Action) {
+		key := ahocorasick.Fold(strings.TrimSpace(word))
+		if key == "" {
+			return
+		}
+		if _, ok := byWord[key]; !ok {
+			order = append(order, key)
+		}
+		byWord[key] = action
+	}
+
+	if s.wordsFile != "" {
+		fileWords, err := readWordsFile(s.wordsFile)
+		if err != nil {
+			logger.Warnf("read sensitive words file %s failed: %v", s.wordsFile, err)
+		}
+		for _, w := range fileWords {
+			add(w.Word, w.Action)
+		}
+	}
+	dbWords, err := s.repo.ListEnabledWords()
+	if err != nil {
+		return err
+	}
+	for _, w := range dbWords {
+		add(w.Word, w.Action)
+	}
+
+	actions := make([]modentity.Action, 0, len(order))
+	for _, w := range order {
+		actions = append(actions, byWord[w])
+	}
+	rules := &ruleSet{matcher: ahocorasick.New(order), actions: actions}
+
+	s.mu.Lock()
+	prev := len(s.rules.actions)
+	s.rules = rules
+	s.mu.Unlock()
+	if prev != len(actions) {
+		logger.Infof("sensitive words reloaded: %d rules", len(actions))
+	}
+	return nil
+}
+
+func (s *moderationServiceImpl) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// 多实例部署时数据库规则可能被其他实例修改，因此每个周期都整体重建
+			if err := s.Reload(); err != nil {
+				logger.Warnf("reload sensitive words failed: %v", err)
+			}
+		}
+	}
+}
+
+// readWordsFile 解析词库文件
+func readWordsFile(path string) ([]*modentity.SensitiveWord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []*modentity.SensitiveWord
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		word, action := line, modentity.ActionMask
+		if i := strings.LastIndex(line, ","); i > 0 {
+			if a := modentity.Action(strings.ToLower(strings.TrimSpace(line[i+1:]))); a.Valid() {
+				word, action = strings.TrimSpace(line[:i]), a
+			}
+		}
+		out = append(out, &modentity.SensitiveWord{Word: word, Action: action, Enabled: true})
+	}
+	return out, sc.Err()
+}
+
+func normPage(page, pageSize int) (int, int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	return page, pageSize, (page - 1) * pageSize
+}
+
+func (s *moderationServiceImpl) ListWords(keyword string, page, pageSize int) ([]*modentity.SensitiveWord, int64, error) {
+	_, pageSize, offset := normPage(page, pageSize)
+	return s.repo.ListWords(strings.TrimSpace(keyword), offset, pageSize)
+}
+
+func (s *moderationServiceImpl) AddWords(words []string, action modentity.Action, category string) (int, error) {
+	if action == "" {
+		action = modentity.ActionMask
+	}
+	if !action.Valid() {
+		return 0, ErrInvalidAction
+	}
+	list := make([]*modentity.SensitiveWord, 0, len(words))
+	dedup := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		w = strings.TrimSpace(w)
+		key := ahocorasick.Fold(w)
+		if w == "" {
+			continue
+		}
+		if _, ok := dedup[key]; ok {
+			continue
+		}
+		dedup[key] = struct{}{}
+		list = append(list, &modentity.SensitiveWord{Word: w, Action: action, Category: category, Enabled: true})
+	}
+	if len(list) == 0 {
+		return 0, errors.New("no words")
+	}
+	if err := s.repo.CreateWords(list); err != nil {
+		return 0, err
+	}
+	return len(list), s.Reload()
+}
+
+func (s *moderationServiceImpl) UpdateWord(id uint, action modentity.Action, category string, enabled bool) (*modentity.SensitiveWord, error) {
+	w, err := s.repo.GetWord(id)
+	if err != nil {
+		return nil, err
+	}
+	if action != "" {
+		if !action.Valid() {
+			return nil, ErrInvalidAction
+		}
+		w.Action = action
+	}
+	w.Category = category
+	w.Enabled = enabled
+	if err := s.repo.UpdateWord(w); err != nil {
+		return nil, err
+	}
+	return w, s.Reload()
+}
+
+func (s *moderationServiceImpl) DeleteWord(id uint) error {
+	if err := s.repo.DeleteWord(id); err != nil {
+		return err
+	}
+	return s.Reload()
+}
+
+func (s *moderationServiceImpl) ListFlags(status modentity.FlagStatus, scene modentity.Scene, page, pageSize int) ([]*modentity.FlaggedContent, int64, error) {
+	_, pageSize, offset := normPage(page, pageSize)
+	return s.repo.ListFlags(status, scene, offset, pageSize)
+}
+
+func (s *moderationServiceImpl) ReviewFlag(reviewerID, id uint, status modentity.FlagStatus, note string) (*modentity.FlaggedContent, error) {
+	if status != modentity.FlagStatusApproved && status != modentity.FlagStatusRejected {
+		return nil, errors.New("invalid review status")
+	}
+	f, err := s.repo.GetFlag(id)
+	if err != nil {
+		return nil, err
+	}
+	if f.Status != modentity.FlagStatusPending {
+		return nil, ErrFlagReviewed
+	}
+	now := time.Now()
+	f.Status = status
+	f.ReviewerID = reviewerID
+	f.ReviewNote = note
+	f.ReviewedAt = &now
+	if err := s.repo.UpdateFlag(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	appentity "alice/domain/appuser/entity"
+	apprepo "alice/domain/appuser/repository"
+	chatrepo "alice/domain/chat/repository"
+	mediaentity "alice/domain/media/entity"
+	mediasvc "alice/domain/media/service"
+	modentity "alice/domain/moderation/entity"
+	modrepo "alice/domain/moderation/repository"
+	momententity "alice/domain/moment/entity"
+	momentrepo "alice/domain/moment/repository"
+	"alice/pkg/logger"
+)
+
+var (
+	ErrInvalidReportTarget  = errors.New("invalid report target")
+	ErrReportTargetNotFound = errors.New("report target not found")
+	ErrReportSelf           = errors.New("cannot report yourself")
+	ErrReportClosed         = errors.New("report already closed")
+	ErrNoDeletableContent   = errors.New("report target has no deletable content")
+)
+
+// 审核操作日志动作
+const (
+	AuditReportTriage  = "report.triage"
+	AuditReportResolve = "report.resolve"
+	AuditReportDismiss = "report.dismiss"
+	AuditContentDelete = "content.delete"
+	AuditUserBan       = "user.ban"
+	AuditUserUnban     = "user.unban"
+)
+
+type ReportService interface {
+	// App 端
+	Submit(reporterID uint, targetType modentity.ReportTargetType, targetID uint, reason, detail string) (*modentity.Report, error)
+
+	// 后台
+	List(status modentity.ReportStatus, targetType modentity.ReportTargetType, page, pageSize int) ([]*modentity.Report, int64, error)
+	Get(id uint) (*modentity.Report, error)
+	Triage(operatorID, id uint, note string) (*modentity.Report, error)
+	Resolve(operatorID, id uint, dismiss bool, resolution string) (*modentity.Report, error)
+	DeleteContent(operatorID, id uint, note string) (*modentity.Report, error)
+	BanUser(operatorID, userID, reportID uint, reason string) error
+	UnbanUser(operatorID, userID uint, reason string) error
+	ListAudits(operatorID uint, action string, page, pageSize int) ([]*modentity.AuditLog, int64, error)
+}
+
+type reportServiceImpl struct {
+	repo       modrepo.ReportRepository
+	userRepo   apprepo.AppUserRepository
+	msgRepo    chatrepo.MessageRepository
+	groupRepo  chatrepo.GroupRepository
+	momentRepo momentrepo.MomentRepository
+	media      mediasvc.MediaService
+}
+
+func NewReportService(repo modrepo.ReportRepository, userRepo apprepo.AppUserRepository, msgRepo chatrepo.MessageRepository, groupRepo chatrepo.GroupRepository, momentRepo momentrepo.MomentRepository, media mediasvc.MediaService) ReportService {
+	return &reportServiceImpl{repo: repo, userRepo: userRepo, msgRepo: msgRepo, groupRepo: groupRepo, momentRepo: momentRepo, media: media}
+}
+
+func (s *reportServiceImpl) Submit(reporterID uint, targetType modentity.ReportTargetType, targetID uint, reason, detail string) (*modentity.Report, error) {
+	if reporterID == 0 || targetID == 0 || !targetType.Valid() || strings.TrimSpace(reason) == "" {
+		return nil, ErrInvalidReportTarget
+	}
+	ownerID, evidence, err := s.snapshot(reporterID, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if ownerID == reporterID {
+		return nil, ErrReportSelf
+	}
+	// 同一对象重复举报时直接返回未结案的那条，避免刷单
+	if open, err := s.repo.FindOpen(reporterID, targetType, targetID); err != nil {
+		return nil, err
+	} else if open != nil {
+		return open, nil
+	}
+	r := &modentity.Report{
+		ReporterID:   reporterID,
+		TargetType:   targetType,
+		TargetID:     targetID,
+		TargetUserID: ownerID,
+		Reason:       strings.TrimSpace(reason),
+		Detail:       strings.TrimSpace(detail),
+		Evidence:     evidence,
+		Status:       modentity.ReportStatusPending,
+	}
+	if err := s.repo.Create(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// viewableMoment 读取举报人可见的动态（评论以所属动态为准），不存在或不可见时返回 false
+func (s *reportServiceImpl) viewableMoment(momentID, reporterID uint) (*momententity.Moment, bool) {
+	m, err := s.momentRepo.Get(momentID)
+	if err != nil || m == nil {
+		return nil, false
+	}
+	if ok, err := s.momentRepo.CanView(m, reporterID); err != nil || !ok {
+		return nil, false
+	}
+	return m, true
+}
+
+// snapshot 读取举报对象，返回其作者 ID 与 JSON 证据快照；
+// 私聊/群聊消息只允许会话参与者举报，动态与评论只允许能看到该动态的用户举报；
+// 无权查看时与不存在返回同一错误，避免借举报探测对象是否存在
+func (s *reportServiceImpl) snapshot(reporterID uint, targetType modentity.ReportTargetType, targetID uint) (uint, string, error) {
+	var owner uint
+	var data any
+	switch targetType {
+	case modentity.ReportTargetMessage:
+		m, err := s.msgRepo.Get(targetID)
+		if err != nil || m == nil {
+			return 0, "", ErrReportTargetNotFound
+		}
+		if m.SenderID != reporterID && m.ReceiverID != reporterID {
+			return 0, "", ErrReportTargetNotFound
+		}
+		owner, data = m.SenderID, m
+	case modentity.ReportTargetGroupMessage:
+		m, err := s.groupRepo.GetMessage(targetID)
+		if err != nil || m == nil {
+			return 0, "", ErrReportTargetNotFound
+		}
+		if ok, _ := s.groupRepo.IsMember(m.GroupID, reporterID); !ok {
+			return 0, "", ErrReportTargetNotFound
+		}
+		owner, data = m.SenderID, m
+	case modentity.ReportTargetMoment:
+		m, ok := s.viewableMoment(targetID, reporterID)
+		if !ok {
+			return 0, "", ErrReportTargetNotFound
+		}
+		owner, data = m.UserID, m
+	case modentity.ReportTargetComment:
+		cmt, err := s.momentRepo.GetComment(targetID)
+		if err != nil || cmt == nil {
+			return 0, "", ErrReportTargetNotFound
+		}
+		if _, ok := s.viewableMoment(cmt.MomentID, reporterID); !ok {
+			return 0, "", ErrReportTargetNotFound
+		}
+		owner, data = cmt.UserID, cmt
+	case modentity.ReportTargetUser:
+		u, err := s.userRepo.GetByID(targetID)
+		if err != nil || u == nil {
+			return 0, "", ErrReportTargetNotFound
+		}
+		owner = u.ID
+		data = map[string]any{"id": u.ID, "nickname": u.Nickname, "avatar": u.Avatar, "bio": u.Bio, "status": u.Status}
+	default:
+		return 0, "", ErrInvalidReportTarget
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return 0, "", err
+	}
+	return owner, string(b), nil
+}
+
+func (s *reportServiceImpl) List(status modentity.ReportStatus, targetType modentity.ReportTargetType, page, pageSize int) ([]*modentity.Report, int64, error) {
+	_, pageSize, offset := normPage(page, pageSize)
+	return s.repo.List(status, targetType, offset, pageSize)
+}
+
+func (s *reportServiceImpl) Get(id uint) (*modentity.Report, error) { return s.repo.Get(id) }
+
+func (s *reportServiceImpl) Triage(operatorID, id uint, note string) (*modentity.Report, error) {
+	r, err := s.openReport(id)
+	if err != nil {
+		return nil, err
+	}
+	r.Status = modentity.ReportStatusTriaged
+	r.HandlerID = operatorID
+	if note != "" {
+		r.Resolution = note
+	}
+	if err := s.repo.Update(r); err != nil {
+		return nil, err
+	}
+	s.audit(operatorID, AuditReportTriage, r, note)
+	return r, nil
+}
+
+func (s *reportServiceImpl) Resolve(operatorID, id uint, dismiss bool, resolution string) (*modentity.Report, error) {
+	r, err := s.openReport(id)
+	if err != nil {
+		return nil, err
+	}
+	action := AuditReportResolve
+	r.Status = modentity.ReportStatusResolved
+	if dismiss {
+		action = AuditReportDismiss
+		r.Status = modentity.ReportStatusDismissed
+	}
+	if err := s.close(r, operatorID, resolution); err != nil {
+		return nil, err
+	}
+	s.audit(operatorID, action, r, resolution)
+	return r, nil
+}
+
+func (s *reportServiceImpl) DeleteContent(operatorID, id uint, note string) (*modentity.Report, error) {
+	r, err := s.openReport(id)
+	if err != nil {
+		return nil, err
+	}
+	// 消息与动态连同附件一并删除，释放对象引用
+	var refType mediaentity.RefType
+	switch r.TargetType {
+	case modentity.ReportTargetMessage:
+		refType = mediaentity.RefMessage
+		err = s.msgRepo.Delete(r.TargetID)
+	case modentity.ReportTargetGroupMessage:
+		refType = mediaentity.RefGroupMessage
+		err = s.groupRepo.DeleteMessage(r.TargetID)
+	case modentity.ReportTargetMoment:
+		refType = mediaentity.RefMoment
+		err = s.momentRepo.Delete(r.TargetID, r.TargetUserID)
+	case modentity.ReportTargetComment:
+		err = s.momentRepo.DeleteComment(r.TargetID)
+	default:
+		return nil, ErrNoDeletableContent
+	}
+	if err != nil {
+		return nil, err
+	}
+	if refType != mediaentity.RefNone {
+		if err := s.media.DeleteByRef(refType, r.TargetID); err != nil {
+			logger.Warnf("delete media of %s %d failed: %v", refType, r.TargetID, err)
+		}
+	}
+	s.audit(operatorID, AuditContentDelete, r, note)
+
+	r.Status = modentity.ReportStatusResolved
+	if note == "" {
+		note = "content deleted"
+	}
+	if err := s.close(r, operatorID, note); err != nil {
+		return nil, err
+	}
+	s.audit(operatorID, AuditReportResolve, r, note)
+	return r, nil
+}
+
+func (s *reportServiceImpl) BanUser(operatorID, userID, reportID uint, reason string) error {
+	if err := s.setUserStatus(userID, appentity.AppUserStatusBanned); err != nil {
+		return err
+	}
+	s.writeAudit(&modentity.AuditLog{OperatorID: operatorID, Action: AuditUserBan, TargetType: string(modentity.ReportTargetUser), TargetID: userID, ReportID: reportID, Detail: reason})
+	return nil
+}
+
+func (s *reportServiceImpl) UnbanUser(operatorID, userID uint, reason string) error {
+	if err := s.setUserStatus(userID, appentity.AppUserStatusActive); err != nil {
+		return err
+	}
+	s.writeAudit(&modentity.AuditLog{OperatorID: operatorID, Action: AuditUserUnban, TargetType: string(modentity.ReportTargetUser), TargetID: userID, Detail: reason})
+	return nil
+}
+
+func (s *reportServiceImpl) setUserStatus(userID uint, status appentity.AppUserStatus) error {
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil || u == nil {
+		return ErrReportTargetNotFound
+	}
+	if u.Status == status {
+		return nil
+	}
+	u.Status = status
+	return s.userRepo.Update(u)
+}
+
+func (s *reportServiceImpl) ListAudits(operatorID uint, action string, page, pageSize int) ([]*modentity.AuditLog, int64, error) {
+	_, pageSize, offset := normPage(page, pageSize)
+	return s.repo.ListAudits(operatorID, action, offset, pageSize)
+}
+
+func (s *reportServiceImpl) openReport(id uint) (*modentity.Report, error) {
+	r, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if r.Status != modentity.ReportStatusPending && r.Status != modentity.ReportStatusTriaged {
+		return nil, ErrReportClosed
+	}
+	return r, nil
+}
+
+func (s *reportServiceImpl) close(r *modentity.Report, operatorID uint, resolution string) error {
+	now := time.Now()
+	r.HandlerID = operatorID
+	r.Resolution = resolution
+	r.HandledAt = &now
+	return s.repo.Update(r)
+}
+
+func (s *reportServiceImpl) audit(operatorID uint, action string, r *modentity.Report, detail string) {
+	s.writeAudit(&modentity.AuditLog{OperatorID: operatorID, Action: action, TargetType: string(r.TargetType), TargetID: r.TargetID, ReportID: r.ID, Detail: detail})
+}
+
+// writeAudit 审计日志写入失败不影响业务结果，但必须留下错误日志
+func (s *reportServiceImpl) writeAudit(l *modentity.AuditLog) {
+	if err := s.repo.CreateAudit(l); err != nil {
+		logger.Errorf("write moderation audit log failed: action=%s target=%s/%d err=%v", l.Action, l.TargetType, l.TargetID, err)
+	}
+}
@@ -5,17 +5,50 @@ import (
 	"time"
 )
 
-// Moment 朋友圈动态（公开，可被所有用户查看；后续可扩展可见范围）
+// Visibility 动态可见范围
+type Visibility string
+
+const (
+	VisibilityPublic  Visibility = "public"  // 所有人
+	VisibilityFriends Visibility = "friends" // 仅好友
+	VisibilityPrivate Visibility = "private" // 仅自己
+	VisibilityInclude Visibility = "include" // 仅 MomentAudience 中的好友可见
+	VisibilityExclude Visibility = "exclude" // 除 MomentAudience 中的好友外，其余好友可见
+)
+
+// Valid 判断可见范围是否合法
+func (v Visibility) Valid() bool {
+	switch v {
+	case VisibilityPublic, VisibilityFriends, VisibilityPrivate, VisibilityInclude, VisibilityExclude:
+		return true
+	}
+	return false
+}
+
+// Moment 朋友圈动态，按 Visibility 控制可见范围（历史数据默认为 public）
 type Moment struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	UserID    uint      `json:"user_id" gorm:"not null;index"`
-	Content   string    `json:"content" gorm:"type:text;not null"`
-	Images    string    `json:"images" gorm:"type:text;default:''"` // 逗号分隔的相对路径 /bucket/object
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	UserID  uint   `json:"user_id" gorm:"not null;index"`
+	Content string `json:"content" gorm:"type:text;not null"`
+	Images  string `json:"images" gorm:"type:text;default:''"` // 逗号分隔的相对路径 /bucket/object
+	// Video 视频动态的视频相对路径，与 Images 互斥
+	Video      string     `json:"video" gorm:"type:varchar(255);default:''"`
+	Visibility Visibility `json:"visibility" gorm:"type:varchar(16);not null;default:'public'"`
+	// FannedOut 是否已写入好友时间线；为 false 时时间线读取阶段直接查询（读扩散）
+	FannedOut bool      `json:"-" gorm:"not null;default:false"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
 func (Moment) TableName() string { return "app_moments" }
 
+// MomentAudience include / exclude 模式下的指定用户名单
+type MomentAudience struct {
+	MomentID uint `json:"moment_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID   uint `json:"user_id" gorm:"primaryKey;autoIncrement:false;index"`
+}
+
+func (MomentAudience) TableName() string { return "app_moment_audiences" }
+
 // ParseImages 将存储字段解析为 slice
 func (m *Moment) ParseImages() []string {
 	if m.Images == "" {
@@ -2,13 +2,16 @@ package entity
 
 import "time"
 
-// MomentComment 动态评论
+// MomentComment 动态评论（两级结构）：顶层评论 ParentID 为 0；
+// 回复统一挂在所属顶层评论下（ParentID 为顶层评论 ID），ReplyToUserID 记录被回复的人，用于展示“A 回复 B”
 type MomentComment struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	MomentID  uint      `json:"moment_id" gorm:"index"`
-	UserID    uint      `json:"user_id" gorm:"index"`
-	Content   string    `json:"content" gorm:"type:text;not null"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	MomentID      uint      `json:"moment_id" gorm:"index"`
+	UserID        uint      `json:"user_id" gorm:"index"`
+	ParentID      uint      `json:"parent_id" gorm:"not null;default:0;index"`
+	ReplyToUserID uint      `json:"reply_to_user_id" gorm:"not null;default:0"`
+	Content       string    `json:"content" gorm:"type:text;not null"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 func (MomentComment) TableName() string { return "app_moment_comments" }
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// TimelineEntry 好友时间线（写扩散）：动态发布时为作者及可见好友各写一行。
+// 好友数超过阈值的作者不写扩散（Moment.FannedOut=false），由读取时直接查询其动态补齐。
+type TimelineEntry struct {
+	UserID    uint      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	MomentID  uint      `json:"moment_id" gorm:"primaryKey;autoIncrement:false;index"`
+	AuthorID  uint      `json:"author_id" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (TimelineEntry) TableName() string { return "app_moment_timelines" }
@@ -3,10 +3,26 @@ package repository
 import momententity "alice/domain/moment/entity"
 
 type MomentRepository interface {
-	Create(m *momententity.Moment) error
-	ListAll(offset, limit int) ([]*momententity.Moment, int64, error)
-	ListByUser(userID uint, offset, limit int) ([]*momententity.Moment, int64, error)
+	// Create 创建动态；audience 为 include / exclude 模式的名单
+	Create(m *momententity.Moment, audience []uint) error
+	// ListAll 返回 viewerID 可见的全部动态（广场）；excludeUserIDs 中用户的动态不会返回（如黑名单）
+	ListAll(viewerID uint, excludeUserIDs []uint, offset, limit int) ([]*momententity.Moment, int64, error)
+	// ListByUser 返回 userID 发布且 viewerID 可见的动态
+	ListByUser(viewerID, userID uint, offset, limit int) ([]*momententity.Moment, int64, error)
 	Get(id uint) (*momententity.Moment, error)
+	// CanView 按可见范围判断 viewerID 能否查看该动态（不含黑名单判断）
+	CanView(m *momententity.Moment, viewerID uint) (bool, error)
+	ListAudience(momentID uint) ([]uint, error)
+
+	// 好友时间线
+	// FanOut 将动态写入 userIDs 的时间线并标记为已写扩散
+	FanOut(m *momententity.Moment, userIDs []uint) error
+	// Timeline viewerID 的时间线：时间线表 + 好友中未写扩散的动态，按 ID 倒序，仅返回 ID < beforeID（0 表示从最新开始）
+	Timeline(viewerID uint, excludeUserIDs []uint, beforeID uint, limit int) ([]*momententity.Moment, error)
+	// BackfillTimeline 将 authorID 最近 limit 条已写扩散的动态补入 userID 的时间线
+	BackfillTimeline(userID, authorID uint, limit int) error
+	// RemoveFromTimeline 从 userID 的时间线移除 authorID 的全部动态
+	RemoveFromTimeline(userID, authorID uint) error
 	Delete(id uint, userID uint) error
 	// Likes
 	AddLike(momentID, userID uint) error
@@ -15,5 +31,11 @@ type MomentRepository interface {
 	CountLikes(momentID uint) (int64, error)
 	// Comments
 	AddComment(c *momententity.MomentComment) error
-	ListComments(momentID uint, offset, limit int) ([]*momententity.MomentComment, int64, error)
+	// ListComments 顶层评论；ListReplies 某条顶层评论下的回复。
+	// 两者均只返回 viewerID 可见的评论：动态作者看全部，其他人只看自己与好友的评论
+	ListComments(momentID, ownerID, viewerID uint, offset, limit int) ([]*momententity.MomentComment, int64, error)
+	ListReplies(parentID, ownerID, viewerID uint, offset, limit int) ([]*momententity.MomentComment, int64, error)
+	GetComment(id uint) (*momententity.MomentComment, error)
+	// DeleteComment 删除评论，顶层评论连同其回复一并删除
+	DeleteComment(id uint) error
 }
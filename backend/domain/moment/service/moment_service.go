@@ -1,35 +1,101 @@
 package service
 
 import (
+	friendrepo "alice/domain/appfriend/repository"
+	mediaentity "alice/domain/media/entity"
+	mediasvc "alice/domain/media/service"
+	modentity "alice/domain/moderation/entity"
+	modsvc "alice/domain/moderation/service"
 	momententity "alice/domain/moment/entity"
 	momentrepo "alice/domain/moment/repository"
+	notifyentity "alice/domain/notification/entity"
+	notifysvc "alice/domain/notification/service"
+	"alice/pkg/logger"
 	"errors"
 	"strings"
 )
 
 type MomentService interface {
-	Publish(userID uint, content string, images []string) (*momententity.Moment, error)
-	ListAll(page, pageSize int) ([]*momententity.Moment, int64, error)
-	ListByUser(userID uint, page, pageSize int) ([]*momententity.Moment, int64, error)
+	// Publish 发布动态；video 非空时为视频动态（不可同时带图片）；visibility 为空时默认仅好友可见，audience 为 include / exclude 模式的好友名单
+	Publish(userID uint, content string, images []string, video string, visibility momententity.Visibility, audience []uint) (*momententity.Moment, error)
+	// ListAll / ListByUser / Get 以 viewerID 视角过滤：按可见范围过滤，且与查看者存在拉黑关系的用户动态不可见
+	// ListAll 动态广场：所有对 viewerID 可见的动态
+	ListAll(viewerID uint, page, pageSize int) ([]*momententity.Moment, int64, error)
+	// Timeline 好友时间线（自己与好友的动态），beforeID 为上一页最后一条的 ID（游标），返回下一页游标（0 表示没有更多）
+	Timeline(viewerID, beforeID uint, limit int) ([]*momententity.Moment, uint, error)
+	ListByUser(viewerID, userID uint, page, pageSize int) ([]*momententity.Moment, int64, error)
+	Get(viewerID, id uint) (*momententity.Moment, error)
+	// ListAudience 动态的指定名单（仅作者可查看）
+	ListAudience(userID, momentID uint) ([]uint, error)
 	Delete(userID uint, id uint) error
 	Like(userID, momentID uint) error
 	Unlike(userID, momentID uint) error
 	HasLiked(userID, momentID uint) (bool, error)
 	CountLikes(momentID uint) (int64, error)
-	AddComment(userID, momentID uint, content string) (*momententity.MomentComment, error)
-	ListComments(momentID uint, page, pageSize int) ([]*momententity.MomentComment, int64, error)
+	// AddComment 评论动态；replyToID 非 0 时为回复该评论
+	AddComment(userID, momentID, replyToID uint, content string) (*momententity.MomentComment, error)
+	// DeleteComment 评论作者或动态作者可删除，删除顶层评论时其回复一并删除
+	DeleteComment(userID, momentID, commentID uint) error
+	// ListComments 顶层评论分页，每条附带前几条回复预览；仅返回 viewerID 可见的评论
+	ListComments(viewerID, momentID uint, page, pageSize int) ([]*CommentThread, int64, error)
+	// ListReplies 某条顶层评论下的回复分页
+	ListReplies(viewerID, commentID uint, page, pageSize int) ([]*momententity.MomentComment, int64, error)
+
+	// FriendAdded / FriendRemoved 好友关系变更时回填/清理时间线（满足 FriendshipObserver）
+	FriendAdded(userID, friendID uint)
+	FriendRemoved(userID, friendID uint)
+}
+
+// CommentThread 顶层评论及其回复预览
+type CommentThread struct {
+	Comment    *momententity.MomentComment
+	Replies    []*momententity.MomentComment
+	ReplyCount int64
 }
 
-type momentServiceImpl struct{ repo momentrepo.MomentRepository }
+// replyPreview 评论列表中每条顶层评论附带的回复条数
+const replyPreview = 3
 
-func NewMomentService(repo momentrepo.MomentRepository) MomentService {
-	return &momentServiceImpl{repo: repo}
+var (
+	ErrMomentNotFound    = errors.New("moment not found")
+	ErrCommentNotFound   = errors.New("comment not found")
+	ErrCommentForbidden  = errors.New("no permission to delete this comment")
+	ErrInvalidVisibility = errors.New("invalid visibility")
+	ErrAudienceRequired  = errors.New("audience required for this visibility")
+	ErrVideoWithImages   = errors.New("video moment cannot contain images")
+)
+
+// timelineBackfill 新加好友时回填的动态条数
+const timelineBackfill = 100
+
+type momentServiceImpl struct {
+	repo       momentrepo.MomentRepository
+	friendRepo friendrepo.FriendRepository
+	moderator  modsvc.ModerationService
+	notifier   notifysvc.Notifier
+	media      mediasvc.MediaService
+	fanoutMax  int
 }
 
-func (s *momentServiceImpl) Publish(userID uint, content string, images []string) (*momententity.Moment, error) {
+// NewMomentService fanoutMax 为写扩散的好友数上限，超过后该作者的动态改为读扩散
+func NewMomentService(repo momentrepo.MomentRepository, friendRepo friendrepo.FriendRepository, moderator modsvc.ModerationService, notifier notifysvc.Notifier, media mediasvc.MediaService, fanoutMax int) MomentService {
+	return &momentServiceImpl{repo: repo, friendRepo: friendRepo, moderator: moderator, notifier: notifier, media: media, fanoutMax: fanoutMax}
+}
+
+func (s *momentServiceImpl) Publish(userID uint, content string, images []string, video string, visibility momententity.Visibility, audience []uint) (*momententity.Moment, error) {
 	if userID == 0 || strings.TrimSpace(content) == "" {
 		return nil, errors.New("invalid params")
 	}
+	if visibility == "" {
+		visibility = momententity.VisibilityFriends
+	}
+	if !visibility.Valid() {
+		return nil, ErrInvalidVisibility
+	}
+	members, err := s.audience(userID, visibility, audience)
+	if err != nil {
+		return nil, err
+	}
 	if len(images) > 9 {
 		images = images[:9]
 	}
@@ -41,13 +107,62 @@ func (s *momentServiceImpl) Publish(userID uint, content string, images []string
 			filtered = append(filtered, img)
 		}
 	}
-	m := &momententity.Moment{UserID: userID, Content: content, Images: strings.Join(filtered, ",")}
-	if err := s.repo.Create(m); err != nil {
+	video = strings.TrimSpace(video)
+	if video != "" && len(filtered) > 0 {
+		return nil, ErrVideoWithImages
+	}
+	verdict, err := s.moderator.Check(modentity.SceneMoment, userID, content)
+	if err != nil {
 		return nil, err
 	}
+	m := &momententity.Moment{UserID: userID, Content: verdict.Text, Images: strings.Join(filtered, ","), Video: video, Visibility: visibility}
+	if err := s.repo.Create(m, members); err != nil {
+		return nil, err
+	}
+	if video != "" {
+		filtered = []string{video}
+	}
+	// 只能发布本人上传的图片/视频
+	if len(filtered) > 0 {
+		if _, err := s.media.Attach(userID, mediaentity.KindMoment, mediaentity.RefMoment, m.ID, filtered, nil); err != nil {
+			// Attach 失败时整体回滚，没有指向该动态的附件，只需删除动态
+			if derr := s.repo.Delete(m.ID, userID); derr != nil {
+				logger.Errorf("delete moment %d after attach failure failed: %v", m.ID, derr)
+			}
+			return nil, err
+		}
+	}
+	s.moderator.Flag(modentity.SceneMoment, userID, m.ID, verdict)
+	s.fanOut(m, members)
 	return m, nil
 }
 
+// audience 整理 include / exclude 名单：去重并只保留好友
+func (s *momentServiceImpl) audience(userID uint, visibility momententity.Visibility, ids []uint) ([]uint, error) {
+	if visibility != momententity.VisibilityInclude && visibility != momententity.VisibilityExclude {
+		return nil, nil
+	}
+	seen := make(map[uint]struct{}, len(ids))
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if _, dup := seen[id]; dup || id == 0 || id == userID {
+			continue
+		}
+		seen[id] = struct{}{}
+		ok, err := s.friendRepo.AreFriends(userID, id)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out = append(out, id)
+		}
+	}
+	if visibility == momententity.VisibilityInclude && len(out) == 0 {
+		return nil, ErrAudienceRequired
+	}
+	return out, nil
+}
+
 func normPage(page, pageSize int) (int, int, int) {
 	if page < 1 {
 		page = 1
@@ -59,29 +174,165 @@ func normPage(page, pageSize int) (int, int, int) {
 	return page, pageSize, offset
 }
 
-func (s *momentServiceImpl) ListAll(page, pageSize int) ([]*momententity.Moment, int64, error) {
+func (s *momentServiceImpl) ListAll(viewerID uint, page, pageSize int) ([]*momententity.Moment, int64, error) {
 	page, pageSize, offset := normPage(page, pageSize)
-	return s.repo.ListAll(offset, pageSize)
+	blocked, err := s.friendRepo.ListBlockRelated(viewerID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListAll(viewerID, blocked, offset, pageSize)
+}
+
+func (s *momentServiceImpl) Timeline(viewerID, beforeID uint, limit int) ([]*momententity.Moment, uint, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	blocked, err := s.friendRepo.ListBlockRelated(viewerID)
+	if err != nil {
+		return nil, 0, err
+	}
+	list, err := s.repo.Timeline(viewerID, blocked, beforeID, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	var next uint
+	if len(list) == limit {
+		next = list[len(list)-1].ID
+	}
+	return list, next, nil
 }
 
-func (s *momentServiceImpl) ListByUser(userID uint, page, pageSize int) ([]*momententity.Moment, int64, error) {
+// fanOut 写扩散：作者好友数不超过阈值时，将动态写入作者及可见好友的时间线；
+// 否则保持 FannedOut=false，由时间线读取时查询（读扩散）
+func (s *momentServiceImpl) fanOut(m *momententity.Moment, audience []uint) {
+	targets := []uint{m.UserID}
+	switch m.Visibility {
+	case momententity.VisibilityPrivate:
+	case momententity.VisibilityInclude:
+		targets = append(targets, audience...)
+	default:
+		friends, total, err := s.friendRepo.ListFriends(m.UserID, 0, s.fanoutMax+1)
+		if err != nil {
+			logger.Errorf("moment fan-out: list friends of %d failed: %v", m.UserID, err)
+			return
+		}
+		if total > int64(s.fanoutMax) {
+			return
+		}
+		excluded := make(map[uint]struct{}, len(audience))
+		if m.Visibility == momententity.VisibilityExclude {
+			for _, id := range audience {
+				excluded[id] = struct{}{}
+			}
+		}
+		for _, id := range friends {
+			if _, skip := excluded[id]; !skip {
+				targets = append(targets, id)
+			}
+		}
+	}
+	if err := s.repo.FanOut(m, targets); err != nil {
+		logger.Errorf("moment fan-out: moment %d failed: %v", m.ID, err)
+	}
+}
+
+// FriendAdded 实现 FriendshipObserver：互相回填对方最近的动态
+func (s *momentServiceImpl) FriendAdded(userID, friendID uint) {
+	if err := s.repo.BackfillTimeline(userID, friendID, timelineBackfill); err != nil {
+		logger.Errorf("timeline backfill %d<-%d failed: %v", userID, friendID, err)
+	}
+	if err := s.repo.BackfillTimeline(friendID, userID, timelineBackfill); err != nil {
+		logger.Errorf("timeline backfill %d<-%d failed: %v", friendID, userID, err)
+	}
+}
+
+// FriendRemoved 实现 FriendshipObserver：互相移除对方的动态
+func (s *momentServiceImpl) FriendRemoved(userID, friendID uint) {
+	if err := s.repo.RemoveFromTimeline(userID, friendID); err != nil {
+		logger.Errorf("timeline cleanup %d/%d failed: %v", userID, friendID, err)
+	}
+	if err := s.repo.RemoveFromTimeline(friendID, userID); err != nil {
+		logger.Errorf("timeline cleanup %d/%d failed: %v", friendID, userID, err)
+	}
+}
+
+func (s *momentServiceImpl) ListByUser(viewerID, userID uint, page, pageSize int) ([]*momententity.Moment, int64, error) {
 	page, pageSize, offset := normPage(page, pageSize)
-	return s.repo.ListByUser(userID, offset, pageSize)
+	if !s.visible(viewerID, userID) {
+		return []*momententity.Moment{}, 0, nil
+	}
+	return s.repo.ListByUser(viewerID, userID, offset, pageSize)
+}
+
+func (s *momentServiceImpl) Get(viewerID, id uint) (*momententity.Moment, error) {
+	return s.viewable(viewerID, id)
+}
+
+func (s *momentServiceImpl) ListAudience(userID, momentID uint) ([]uint, error) {
+	m, err := s.repo.Get(momentID)
+	if err != nil || m == nil || m.UserID != userID {
+		return nil, ErrMomentNotFound
+	}
+	return s.repo.ListAudience(momentID)
+}
+
+// visible 任一方拉黑对方后，双方互相看不到动态
+func (s *momentServiceImpl) visible(viewerID, ownerID uint) bool {
+	if viewerID == ownerID {
+		return true
+	}
+	if b, err := s.friendRepo.IsBlocked(viewerID, ownerID); err != nil || b {
+		return false
+	}
+	if b, err := s.friendRepo.IsBlocked(ownerID, viewerID); err != nil || b {
+		return false
+	}
+	return true
+}
+
+// viewable 读取动态并校验对 viewerID 可见（黑名单 + 可见范围），不可见时与不存在返回相同错误
+func (s *momentServiceImpl) viewable(viewerID, momentID uint) (*momententity.Moment, error) {
+	m, err := s.repo.Get(momentID)
+	if err != nil || m == nil || !s.visible(viewerID, m.UserID) {
+		return nil, ErrMomentNotFound
+	}
+	if ok, err := s.repo.CanView(m, viewerID); err != nil || !ok {
+		return nil, ErrMomentNotFound
+	}
+	return m, nil
 }
 
 func (s *momentServiceImpl) Delete(userID uint, id uint) error {
 	if userID == 0 || id == 0 {
 		return errors.New("invalid params")
 	}
-	// 简单直接删除（条件包含 user_id 保证只能删自己）
-	return s.repo.Delete(id, userID)
+	m, err := s.repo.Get(id)
+	if err != nil || m.UserID != userID {
+		return ErrMomentNotFound
+	}
+	if err := s.repo.Delete(id, userID); err != nil {
+		return err
+	}
+	return s.media.DeleteByRef(mediaentity.RefMoment, id)
 }
 
 func (s *momentServiceImpl) Like(userID, momentID uint) error {
 	if userID == 0 || momentID == 0 {
 		return errors.New("invalid params")
 	}
-	return s.repo.AddLike(momentID, userID)
+	m, err := s.viewable(userID, momentID)
+	if err != nil {
+		return err
+	}
+	// 重复点赞不重复通知
+	if liked, _ := s.repo.HasLiked(momentID, userID); liked {
+		return nil
+	}
+	if err := s.repo.AddLike(momentID, userID); err != nil {
+		return err
+	}
+	s.notifier.Notify(&notifyentity.Notification{UserID: m.UserID, ActorID: userID, Type: notifyentity.TypeMomentLike, TargetID: momentID})
+	return nil
 }
 
 func (s *momentServiceImpl) Unlike(userID, momentID uint) error {
@@ -105,23 +356,117 @@ func (s *momentServiceImpl) CountLikes(momentID uint) (int64, error) {
 	return s.repo.CountLikes(momentID)
 }
 
-func (s *momentServiceImpl) AddComment(userID, momentID uint, content string) (*momententity.MomentComment, error) {
+func (s *momentServiceImpl) AddComment(userID, momentID, replyToID uint, content string) (*momententity.MomentComment, error) {
 	if userID == 0 || momentID == 0 || strings.TrimSpace(content) == "" {
 		return nil, errors.New("invalid params")
 	}
-	cmt := &momententity.MomentComment{MomentID: momentID, UserID: userID, Content: content}
+	m, err := s.viewable(userID, momentID)
+	if err != nil {
+		return nil, err
+	}
+	cmt := &momententity.MomentComment{MomentID: momentID, UserID: userID}
+	if replyToID != 0 {
+		target, err := s.repo.GetComment(replyToID)
+		if err != nil || target == nil || target.MomentID != momentID || !s.commentVisible(userID, m.UserID, target) {
+			return nil, ErrCommentNotFound
+		}
+		// 回复统一挂在顶层评论下
+		cmt.ParentID = target.ID
+		if target.ParentID != 0 {
+			cmt.ParentID = target.ParentID
+		}
+		cmt.ReplyToUserID = target.UserID
+	}
+	verdict, err := s.moderator.Check(modentity.SceneComment, userID, content)
+	if err != nil {
+		return nil, err
+	}
+	cmt.Content = verdict.Text
 	if err := s.repo.AddComment(cmt); err != nil {
 		return nil, err
 	}
+	s.moderator.Flag(modentity.SceneComment, userID, cmt.ID, verdict)
+	// 被回复者收到回复通知；动态作者收到评论通知（被回复者即作者时只发回复通知）
+	if cmt.ReplyToUserID != 0 {
+		s.notifier.Notify(&notifyentity.Notification{UserID: cmt.ReplyToUserID, ActorID: userID, Type: notifyentity.TypeCommentReply, TargetID: momentID, SubjectID: cmt.ID, Content: cmt.Content})
+	}
+	if cmt.ReplyToUserID != m.UserID {
+		s.notifier.Notify(&notifyentity.Notification{UserID: m.UserID, ActorID: userID, Type: notifyentity.TypeMomentComment, TargetID: momentID, SubjectID: cmt.ID, Content: cmt.Content})
+	}
 	return cmt, nil
 }
 
-func (s *momentServiceImpl) ListComments(momentID uint, page, pageSize int) ([]*momententity.MomentComment, int64, error) {
+// commentVisible 单条评论对 viewerID 是否可见，规则与仓储层 ListComments 一致，另排除拉黑关系
+func (s *momentServiceImpl) commentVisible(viewerID, ownerID uint, c *momententity.MomentComment) bool {
+	if viewerID == c.UserID {
+		return true
+	}
+	if !s.visible(viewerID, c.UserID) {
+		return false
+	}
+	if viewerID == ownerID {
+		return true
+	}
+	ok, err := s.friendRepo.AreFriends(viewerID, c.UserID)
+	return err == nil && ok
+}
+
+func (s *momentServiceImpl) DeleteComment(userID, momentID, commentID uint) error {
+	if userID == 0 || momentID == 0 || commentID == 0 {
+		return errors.New("invalid params")
+	}
+	cmt, err := s.repo.GetComment(commentID)
+	if err != nil || cmt == nil || cmt.MomentID != momentID {
+		return ErrCommentNotFound
+	}
+	if cmt.UserID != userID {
+		m, err := s.repo.Get(momentID)
+		if err != nil || m == nil {
+			return ErrMomentNotFound
+		}
+		if m.UserID != userID {
+			return ErrCommentForbidden
+		}
+	}
+	return s.repo.DeleteComment(commentID)
+}
+
+func (s *momentServiceImpl) ListComments(viewerID, momentID uint, page, pageSize int) ([]*CommentThread, int64, error) {
 	if momentID == 0 {
 		return nil, 0, errors.New("invalid params")
 	}
-	page, pageSize, offset := normPage(page, pageSize)
-	_ = page
-	_ = pageSize
-	return s.repo.ListComments(momentID, offset, pageSize)
+	m, err := s.viewable(viewerID, momentID)
+	if err != nil {
+		return nil, 0, err
+	}
+	_, pageSize, offset := normPage(page, pageSize)
+	list, total, err := s.repo.ListComments(momentID, m.UserID, viewerID, offset, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	threads := make([]*CommentThread, 0, len(list))
+	for _, c := range list {
+		replies, cnt, err := s.repo.ListReplies(c.ID, m.UserID, viewerID, 0, replyPreview)
+		if err != nil {
+			return nil, 0, err
+		}
+		threads = append(threads, &CommentThread{Comment: c, Replies: replies, ReplyCount: cnt})
+	}
+	return threads, total, nil
+}
+
+func (s *momentServiceImpl) ListReplies(viewerID, commentID uint, page, pageSize int) ([]*momententity.MomentComment, int64, error) {
+	parent, err := s.repo.GetComment(commentID)
+	if err != nil || parent == nil || parent.ParentID != 0 {
+		return nil, 0, ErrCommentNotFound
+	}
+	m, err := s.viewable(viewerID, parent.MomentID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !s.commentVisible(viewerID, m.UserID, parent) {
+		return nil, 0, ErrCommentNotFound
+	}
+	_, pageSize, offset := normPage(page, pageSize)
+	return s.repo.ListReplies(parent.ID, m.UserID, viewerID, offset, pageSize)
 }
@@ -0,0 +1,64 @@
+package entity
+
+import "time"
+
+// Type 通知类型
+type Type string
+
+const (
+	TypeMomentLike     Type = "moment_like"     // 动态被点赞，TargetID=动态
+	TypeMomentComment  Type = "moment_comment"  // 动态被评论，TargetID=动态，SubjectID=评论
+	TypeCommentReply   Type = "comment_reply"   // 评论被回复，TargetID=动态，SubjectID=回复
+	TypeFriendRequest  Type = "friend_request"  // 收到好友申请，SubjectID=申请
+	TypeFriendAccepted Type = "friend_accepted" // 好友申请被通过，SubjectID=申请
+	TypeGroupInvite    Type = "group_invite"    // 被拉入群聊，TargetID=群
+	TypeMention        Type = "mention"         // 群聊中被 @，TargetID=群，SubjectID=消息
+)
+
+// Types 全部通知类型（用于偏好设置）
+var Types = []Type{TypeMomentLike, TypeMomentComment, TypeCommentReply, TypeFriendRequest, TypeFriendAccepted, TypeGroupInvite, TypeMention}
+
+// Valid 判断通知类型是否合法
+func (t Type) Valid() bool {
+	for _, v := range Types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification 用户通知；同一 (Type, TargetID) 的通知在收件箱中聚合展示（如“3 人赞了你的动态”）
+type Notification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index:idx_notification_user_group,priority:1"` // 接收人
+	ActorID   uint       `json:"actor_id" gorm:"not null"`                                             // 触发人
+	Type      Type       `json:"type" gorm:"type:varchar(32);not null;index:idx_notification_user_group,priority:2"`
+	TargetID  uint       `json:"target_id" gorm:"not null;default:0;index:idx_notification_user_group,priority:3"`
+	SubjectID uint       `json:"subject_id" gorm:"not null;default:0"`
+	Content   string     `json:"content" gorm:"type:varchar(200);default:''"` // 摘要（评论内容等）
+	IsRead    bool       `json:"is_read" gorm:"not null;default:false;index"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (Notification) TableName() string { return "app_notifications" }
+
+// Preference 用户按类型的通知开关；无记录视为开启
+type Preference struct {
+	UserID  uint `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Type    Type `json:"type" gorm:"primaryKey;type:varchar(32)"`
+	Enabled bool `json:"enabled" gorm:"not null"`
+}
+
+func (Preference) TableName() string { return "app_notification_preferences" }
+
+// Group 收件箱聚合项
+type Group struct {
+	Type       Type
+	TargetID   uint
+	ActorCount int64
+	Unread     int64
+	LastID     uint
+	LastAt     time.Time
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	notifyentity "alice/domain/notification/entity"
+)
+
+type NotificationRepository interface {
+	Create(n *notifyentity.Notification) error
+	List(userID uint, unreadOnly bool, offset, limit int) ([]*notifyentity.Notification, int64, error)
+	// ListGroups 按 (type, target_id) 聚合，按最新一条倒序
+	ListGroups(userID uint, offset, limit int) ([]*notifyentity.Group, int64, error)
+	// ListGroupActors 聚合项中最近的 limit 个触发人
+	ListGroupActors(userID uint, typ notifyentity.Type, targetID uint, limit int) ([]uint, error)
+	CountUnread(userID uint) (int64, error)
+	MarkRead(userID uint, ids []uint) error
+	MarkGroupRead(userID uint, typ notifyentity.Type, targetID uint) error
+	MarkAllRead(userID uint) error
+
+	ListPreferences(userID uint) ([]*notifyentity.Preference, error)
+	SavePreference(p *notifyentity.Preference) error
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	friendrepo "alice/domain/appfriend/repository"
+	appentity "alice/domain/appuser/entity"
+	apprepo "alice/domain/appuser/repository"
+	notifyentity "alice/domain/notification/entity"
+	notifyrepo "alice/domain/notification/repository"
+	"alice/pkg/logger"
+	"alice/pkg/realtime"
+)
+
+// 通知相关实时事件
+const (
+	EventNotification     = "notification"      // 新通知
+	EventNotificationRead = "notification_read" // 已读状态变化（多端同步未读数）
+)
+
+const (
+	groupActorPreview = 3   // 聚合项展示的触发人数量
+	maxContentRunes   = 200 // 摘要长度上限，与 Notification.Content 列宽一致
+)
+
+var (
+	ErrInvalidType = errors.New("invalid notification type")
+)
+
+// Notifier 业务服务投递通知用的最小接口；投递失败只记录日志，不影响业务流程
+type Notifier interface {
+	Notify(n *notifyentity.Notification)
+}
+
+// GroupSummary 收件箱聚合项
+type GroupSummary struct {
+	*notifyentity.Group
+	Actors  []*appentity.AppUser // 最近的若干触发人
+	Summary string               // 如 "3 people liked your moment"
+}
+
+type NotificationService interface {
+	Notifier
+	List(userID uint, unreadOnly bool, page, pageSize int) ([]*notifyentity.Notification, int64, error)
+	// ListGroups 按 (type, target) 聚合的收件箱
+	ListGroups(userID uint, page, pageSize int) ([]*GroupSummary, int64, error)
+	CountUnread(userID uint) (int64, error)
+	MarkRead(userID uint, ids []uint) error
+	MarkGroupRead(userID uint, typ notifyentity.Type, targetID uint) error
+	MarkAllRead(userID uint) error
+	// Preferences 返回全部类型的开关（未设置的类型默认开启）
+	Preferences(userID uint) (map[notifyentity.Type]bool, error)
+	SetPreferences(userID uint, prefs map[notifyentity.Type]bool) (map[notifyentity.Type]bool, error)
+}
+
+type notificationServiceImpl struct {
+	repo        notifyrepo.NotificationRepository
+	appUserRepo apprepo.AppUserRepository
+	friendRepo  friendrepo.FriendRepository
+	rt          realtime.Emitter
+}
+
+func NewNotificationService(repo notifyrepo.NotificationRepository, appUserRepo apprepo.AppUserRepository, friendRepo friendrepo.FriendRepository, rt realtime.Emitter) NotificationService {
+	return &notificationServiceImpl{repo: repo, appUserRepo: appUserRepo, friendRepo: friendRepo, rt: rt}
+}
+
+func (s *notificationServiceImpl) Notify(n *notifyentity.Notification) {
+	if n == nil || n.UserID == 0 || n.UserID == n.ActorID || !n.Type.Valid() {
+		return
+	}
+	if !s.enabled(n.UserID, n.Type) {
+		return
+	}
+	if r := []rune(n.Content); len(r) > maxContentRunes {
+		n.Content = string(r[:maxContentRunes-1]) + "…"
+	}
+	// 接收人拉黑了触发人则不打扰
+	if blocked, _ := s.friendRepo.IsBlocked(n.UserID, n.ActorID); blocked {
+		return
+	}
+	if err := s.repo.Create(n); err != nil {
+		logger.Errorf("save notification %s for user %d failed: %v", n.Type, n.UserID, err)
+		return
+	}
+	payload := map[string]any{"notification": n}
+	var actors []*appentity.AppUser
+	if u, _ := s.appUserRepo.GetByID(n.ActorID); u != nil {
+		payload["actor_nickname"] = u.Nickname
+		payload["actor_avatar"] = u.Avatar
+		actors = append(actors, u)
+	}
+	payload["summary"] = summarize(n.Type, 1, actors)
+	if cnt, err := s.repo.CountUnread(n.UserID); err == nil {
+		payload["unread_count"] = cnt
+	}
+	s.rt.Emit(n.UserID, EventNotification, payload)
+}
+
+func (s *notificationServiceImpl) enabled(userID uint, typ notifyentity.Type) bool {
+	prefs, err := s.repo.ListPreferences(userID)
+	if err != nil {
+		return true
+	}
+	for _, p := range prefs {
+		if p.Type == typ {
+			return p.Enabled
+		}
+	}
+	return true
+}
+
+func normPage(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	return (page - 1) * pageSize, pageSize
+}
+
+func (s *notificationServiceImpl) List(userID uint, unreadOnly bool, page, pageSize int) ([]*notifyentity.Notification, int64, error) {
+	offset, limit := normPage(page, pageSize)
+	return s.repo.List(userID, unreadOnly, offset, limit)
+}
+
+func (s *notificationServiceImpl) ListGroups(userID uint, page, pageSize int) ([]*GroupSummary, int64, error) {
+	offset, limit := normPage(page, pageSize)
+	groups, total, err := s.repo.ListGroups(userID, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make([]*GroupSummary, 0, len(groups))
+	for _, g := range groups {
+		item := &GroupSummary{Group: g}
+		ids, _ := s.repo.ListGroupActors(userID, g.Type, g.TargetID, groupActorPreview)
+		for _, id := range ids {
+			if u, _ := s.appUserRepo.GetByID(id); u != nil {
+				item.Actors = append(item.Actors, u)
+			}
+		}
+		item.Summary = summarize(g.Type, g.ActorCount, item.Actors)
+		out = append(out, item)
+	}
+	return out, total, nil
+}
+
+// actions 各类型通知的动作描述
+var actions = map[notifyentity.Type]string{
+	notifyentity.TypeMomentLike:     "liked your moment",
+	notifyentity.TypeMomentComment:  "commented on your moment",
+	notifyentity.TypeCommentReply:   "replied to your comment",
+	notifyentity.TypeFriendRequest:  "sent you a friend request",
+	notifyentity.TypeFriendAccepted: "accepted your friend request",
+	notifyentity.TypeGroupInvite:    "added you to a group",
+	notifyentity.TypeMention:        "mentioned you",
+}
+
+// summarize 聚合文案：单人显示昵称，多人显示人数
+func summarize(typ notifyentity.Type, actorCount int64, actors []*appentity.AppUser) string {
+	action := actions[typ]
+	if actorCount > 1 {
+		return fmt.Sprintf("%d people %s", actorCount, action)
+	}
+	name := "Someone"
+	if len(actors) > 0 && actors[0].Nickname != "" {
+		name = actors[0].Nickname
+	}
+	return name + " " + action
+}
+
+func (s *notificationServiceImpl) CountUnread(userID uint) (int64, error) {
+	return s.repo.CountUnread(userID)
+}
+
+func (s *notificationServiceImpl) MarkRead(userID uint, ids []uint) error {
+	if err := s.repo.MarkRead(userID, ids); err != nil {
+		return err
+	}
+	s.syncUnread(userID)
+	return nil
+}
+
+func (s *notificationServiceImpl) MarkGroupRead(userID uint, typ notifyentity.Type, targetID uint) error {
+	if !typ.Valid() {
+		return ErrInvalidType
+	}
+	if err := s.repo.MarkGroupRead(userID, typ, targetID); err != nil {
+		return err
+	}
+	s.syncUnread(userID)
+	return nil
+}
+
+func (s *notificationServiceImpl) MarkAllRead(userID uint) error {
+	if err := s.repo.MarkAllRead(userID); err != nil {
+		return err
+	}
+	s.syncUnread(userID)
+	return nil
+}
+
+// syncUnread 推送最新未读数，便于多端同步角标
+func (s *notificationServiceImpl) syncUnread(userID uint) {
+	if cnt, err := s.repo.CountUnread(userID); err == nil {
+		s.rt.Emit(userID, EventNotificationRead, map[string]any{"unread_count": cnt})
+	}
+}
+
+func (s *notificationServiceImpl) Preferences(userID uint) (map[notifyentity.Type]bool, error) {
+	list, err := s.repo.ListPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[notifyentity.Type]bool, len(notifyentity.Types))
+	for _, t := range notifyentity.Types {
+		out[t] = true
+	}
+	for _, p := range list {
+		if _, ok := out[p.Type]; ok {
+			out[p.Type] = p.Enabled
+		}
+	}
+	return out, nil
+}
+
+func (s *notificationServiceImpl) SetPreferences(userID uint, prefs map[notifyentity.Type]bool) (map[notifyentity.Type]bool, error) {
+	for t := range prefs {
+		if !t.Valid() {
+			return nil, ErrInvalidType
+		}
+	}
+	for t, enabled := range prefs {
+		if err := s.repo.SavePreference(&notifyentity.Preference{UserID: userID, Type: t, Enabled: enabled}); err != nil {
+			return nil, err
+		}
+	}
+	return s.Preferences(userID)
+}
@@ -0,0 +1,44 @@
+package entity
+
+import "time"
+
+// DeviceToken 用户设备的推送令牌；同一令牌只归属最近登录的用户
+type DeviceToken struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	Platform  string    `json:"platform" gorm:"type:varchar(16);not null"` // apns / fcm
+	Token     string    `json:"token" gorm:"type:varchar(255);not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (DeviceToken) TableName() string { return "app_push_devices" }
+
+// MuteTarget 免打扰对象类型
+type MuteTarget string
+
+const (
+	MuteAll   MuteTarget = "all"   // 全局免打扰，TargetID=0
+	MuteUser  MuteTarget = "user"  // 私聊会话，TargetID=对方
+	MuteGroup MuteTarget = "group" // 群聊，TargetID=群（被 @ 时仍推送）
+)
+
+func (t MuteTarget) Valid() bool {
+	return t == MuteAll || t == MuteUser || t == MuteGroup
+}
+
+// MuteSetting 会话免打扰：不发离线推送，且不计入角标
+type MuteSetting struct {
+	UserID     uint       `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	TargetType MuteTarget `json:"target_type" gorm:"primaryKey;type:varchar(16)"`
+	TargetID   uint       `json:"target_id" gorm:"primaryKey;autoIncrement:false"`
+	Until      *time.Time `json:"until"` // 为空表示一直免打扰
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (MuteSetting) TableName() string { return "app_push_mutes" }
+
+// Active 免打扰是否仍生效
+func (m *MuteSetting) Active(now time.Time) bool {
+	return m.Until == nil || m.Until.After(now)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	pushentity "alice/domain/push/entity"
+)
+
+type PushRepository interface {
+	// SaveDevice 按令牌 upsert，令牌换绑到新用户时覆盖原归属
+	SaveDevice(d *pushentity.DeviceToken) error
+	DeleteDevice(userID uint, token string) error
+	// DeleteToken 删除失效令牌（不校验归属）
+	DeleteToken(token string) error
+	ListDevices(userID uint) ([]*pushentity.DeviceToken, error)
+
+	SaveMute(m *pushentity.MuteSetting) error
+	DeleteMute(userID uint, targetType pushentity.MuteTarget, targetID uint) error
+	ListMutes(userID uint) ([]*pushentity.MuteSetting, error)
+	// GetMute 未设置时返回 nil
+	GetMute(userID uint, targetType pushentity.MuteTarget, targetID uint) (*pushentity.MuteSetting, error)
+
+	// UnreadBadge 角标数：私聊未读 + 群聊未读 + 未读通知，免打扰会话不计入
+	UnreadBadge(userID uint) (int64, error)
+}
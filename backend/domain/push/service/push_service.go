@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	chatrepo "alice/domain/chat/repository"
+	pushentity "alice/domain/push/entity"
+	pushrepo "alice/domain/push/repository"
+	"alice/pkg/logger"
+	"alice/pkg/push"
+	"alice/pkg/realtime"
+)
+
+var (
+	ErrInvalidPlatform   = errors.New("invalid platform")
+	ErrInvalidToken      = errors.New("invalid device token")
+	ErrInvalidMuteTarget = errors.New("invalid mute target")
+)
+
+// 推送正文长度上限（按字符）
+const maxBodyRunes = 120
+
+type PushService interface {
+	// Offline 实现 realtime.OfflineHandler：用户不在线时转为离线推送（异步执行）
+	Offline(userID uint, msg any)
+
+	RegisterDevice(userID uint, platform, token string) (*pushentity.DeviceToken, error)
+	UnregisterDevice(userID uint, token string) error
+	ListDevices(userID uint) ([]*pushentity.DeviceToken, error)
+
+	// Mute 设置免打扰，d<=0 表示一直免打扰
+	Mute(userID uint, targetType pushentity.MuteTarget, targetID uint, d time.Duration) (*pushentity.MuteSetting, error)
+	Unmute(userID uint, targetType pushentity.MuteTarget, targetID uint) error
+	ListMutes(userID uint) ([]*pushentity.MuteSetting, error)
+}
+
+type pushServiceImpl struct {
+	repo      pushrepo.PushRepository
+	groupRepo chatrepo.GroupRepository
+	provider  push.Provider
+	timeout   time.Duration
+}
+
+// NewPushService provider 为 nil 时仅管理设备与免打扰设置，不发送推送
+func NewPushService(repo pushrepo.PushRepository, groupRepo chatrepo.GroupRepository, provider push.Provider, timeout time.Duration) PushService {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &pushServiceImpl{repo: repo, groupRepo: groupRepo, provider: provider, timeout: timeout}
+}
+
+var _ realtime.OfflineHandler = (*pushServiceImpl)(nil)
+
+func (s *pushServiceImpl) RegisterDevice(userID uint, platform, token string) (*pushentity.DeviceToken, error) {
+	platform = strings.ToLower(strings.TrimSpace(platform))
+	if platform != push.PlatformAPNs && platform != push.PlatformFCM {
+		return nil, ErrInvalidPlatform
+	}
+	token = strings.TrimSpace(token)
+	if token == "" || len(token) > 255 {
+		return nil, ErrInvalidToken
+	}
+	d := &pushentity.DeviceToken{UserID: userID, Platform: platform, Token: token}
+	if err := s.repo.SaveDevice(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *pushServiceImpl) UnregisterDevice(userID uint, token string) error {
+	return s.repo.DeleteDevice(userID, strings.TrimSpace(token))
+}
+
+func (s *pushServiceImpl) ListDevices(userID uint) ([]*pushentity.DeviceToken, error) {
+	return s.repo.ListDevices(userID)
+}
+
+func (s *pushServiceImpl) Mute(userID uint, targetType pushentity.MuteTarget, targetID uint, d time.Duration) (*pushentity.MuteSetting, error) {
+	if !targetType.Valid() || (targetType == pushentity.MuteAll) != (targetID == 0) {
+		return nil, ErrInvalidMuteTarget
+	}
+	m := &pushentity.MuteSetting{UserID: userID, TargetType: targetType, TargetID: targetID, CreatedAt: time.Now()}
+	if d > 0 {
+		until := time.Now().Add(d)
+		m.Until = &until
+	}
+	if err := s.repo.SaveMute(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *pushServiceImpl) Unmute(userID uint, targetType pushentity.MuteTarget, targetID uint) error {
+	if !targetType.Valid() {
+		return ErrInvalidMuteTarget
+	}
+	return s.repo.DeleteMute(userID, targetType, targetID)
+}
+
+func (s *pushServiceImpl) ListMutes(userID uint) ([]*pushentity.MuteSetting, error) {
+	return s.repo.ListMutes(userID)
+}
+
+// envelope 离线消息的公共字段。实时通道上的结构不统一（私聊/群聊消息为 map，业务事件为 realtime.Event），统一按 JSON 字段解析
+type envelope struct {
+	Type        string `json:"type"`
+	ID          uint   `json:"id"`
+	MessageID   uint   `json:"message_id"`
+	SenderID    uint   `json:"sender_id"`
+	ReceiverID  uint   `json:"receiver_id"`
+	GroupID     uint   `json:"group_id"`
+	Content     string `json:"content"`
+	MessageType string `json:"message_type"`
+	Mentions    []uint `json:"mentions"`
+	Sender      struct {
+		Nickname string `json:"nickname"`
+	} `json:"sender"`
+	Data json.RawMessage `json:"data"`
+}
+
+// notificationEvent 通知中心事件负载（见 notification 服务）
+type notificationEvent struct {
+	Notification struct {
+		ID       uint   `json:"id"`
+		Type     string `json:"type"`
+		TargetID uint   `json:"target_id"`
+		Content  string `json:"content"`
+	} `json:"notification"`
+	Summary string `json:"summary"`
+}
+
+// alert 待发送的推送内容及其所属会话（用于免打扰判断）
+type alert struct {
+	title, body string
+	collapseKey string
+	muteType    pushentity.MuteTarget
+	muteID      uint
+	mentioned   bool // 群聊中被 @，忽略群免打扰
+	data        map[string]string
+}
+
+func (s *pushServiceImpl) Offline(userID uint, msg any) {
+	if s.provider == nil {
+		return
+	}
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return
+	}
+	a := s.compose(userID, &env)
+	if a == nil {
+		return
+	}
+	go s.deliver(userID, a)
+}
+
+// compose 将离线消息转为推送内容；不需要推送的消息返回 nil
+func (s *pushServiceImpl) compose(userID uint, env *envelope) *alert {
+	switch {
+	case env.Type == "group_message":
+		title := "Group chat"
+		if g, err := s.groupRepo.Get(env.GroupID); err == nil && g != nil {
+			title = g.Name
+		}
+		a := &alert{
+			title:       title,
+			body:        env.Sender.Nickname + ": " + excerpt(env.MessageType, env.Content),
+			collapseKey: "chat:group:" + strconv.FormatUint(uint64(env.GroupID), 10),
+			muteType:    pushentity.MuteGroup,
+			muteID:      env.GroupID,
+			data:        map[string]string{"kind": "group_message", "group_id": strconv.FormatUint(uint64(env.GroupID), 10)},
+		}
+		for _, id := range env.Mentions {
+			if id == userID {
+				a.mentioned = true
+				a.body = "[@you] " + a.body
+			}
+		}
+		return a
+	case env.ReceiverID == userID && env.SenderID != 0:
+		return &alert{
+			title:       env.Sender.Nickname,
+			body:        excerpt(env.Type, env.Content),
+			collapseKey: "chat:user:" + strconv.FormatUint(uint64(env.SenderID), 10),
+			muteType:    pushentity.MuteUser,
+			muteID:      env.SenderID,
+			data:        map[string]string{"kind": "message", "peer_id": strconv.FormatUint(uint64(env.SenderID), 10)},
+		}
+	case env.Type == "notification":
+		var ev notificationEvent
+		if err := json.Unmarshal(env.Data, &ev); err != nil || ev.Summary == "" {
+			return nil
+		}
+		n := ev.Notification
+		body := ev.Summary
+		if n.Content != "" {
+			body += ": " + n.Content
+		}
+		return &alert{
+			body:        body,
+			collapseKey: "notify:" + n.Type + ":" + strconv.FormatUint(uint64(n.TargetID), 10),
+			data:        map[string]string{"kind": "notification", "type": n.Type, "notification_id": strconv.FormatUint(uint64(n.ID), 10)},
+		}
+	}
+	// 其它业务事件（已读同步、好友状态等）只对在线客户端有意义
+	return nil
+}
+
+func (s *pushServiceImpl) muted(userID uint, a *alert) bool {
+	now := time.Now()
+	if m, _ := s.repo.GetMute(userID, pushentity.MuteAll, 0); m != nil && m.Active(now) {
+		return true
+	}
+	if a.muteType == "" || a.mentioned {
+		return false
+	}
+	m, _ := s.repo.GetMute(userID, a.muteType, a.muteID)
+	return m != nil && m.Active(now)
+}
+
+func (s *pushServiceImpl) deliver(userID uint, a *alert) {
+	devices, err := s.repo.ListDevices(userID)
+	if err != nil || len(devices) == 0 {
+		return
+	}
+	if s.muted(userID, a) {
+		return
+	}
+	badge, err := s.repo.UnreadBadge(userID)
+	if err != nil {
+		logger.Errorf("count push badge for user %d failed: %v", userID, err)
+	}
+	for _, d := range devices {
+		msg := &push.Message{Platform: d.Platform, Token: d.Token, Title: a.title, Body: a.body, Badge: badge, CollapseKey: a.collapseKey, Data: a.data}
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		err := s.provider.Send(ctx, msg)
+		cancel()
+		switch {
+		case errors.Is(err, push.ErrInvalidToken):
+			_ = s.repo.DeleteToken(d.Token)
+		case err != nil:
+			logger.Errorf("push to user %d (%s) failed: %v", userID, d.Platform, err)
+		}
+	}
+}
+
+// excerpt 推送正文：文本截断，其它类型显示占位
+func excerpt(msgType, content string) string {
+	if msgType != "" && msgType != "text" {
+		return "[" + msgType + "]"
+	}
+	if r := []rune(content); len(r) > maxBodyRunes {
+		return string(r[:maxBodyRunes-1]) + "…"
+	}
+	return content
+}
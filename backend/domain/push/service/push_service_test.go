@@ -0,0 +1,234 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	chatentity "alice/domain/chat/entity"
+	chatrepo "alice/domain/chat/repository"
+	pushentity "alice/domain/push/entity"
+	"alice/pkg/push"
+)
+
+// fakePushRepo 内存实现，只覆盖推送服务用到的方法
+type fakePushRepo struct {
+	devices map[uint][]*pushentity.DeviceToken
+	mutes   map[string]*pushentity.MuteSetting
+	deleted []string
+}
+
+func newFakePushRepo() *fakePushRepo {
+	return &fakePushRepo{devices: map[uint][]*pushentity.DeviceToken{}, mutes: map[string]*pushentity.MuteSetting{}}
+}
+
+func muteKey(userID uint, t pushentity.MuteTarget, targetID uint) string {
+	b, _ := json.Marshal([]any{userID, t, targetID})
+	return string(b)
+}
+
+func (r *fakePushRepo) SaveDevice(d *pushentity.DeviceToken) error {
+	r.devices[d.UserID] = append(r.devices[d.UserID], d)
+	return nil
+}
+func (r *fakePushRepo) DeleteDevice(userID uint, token string) error { return nil }
+func (r *fakePushRepo) DeleteToken(token string) error {
+	r.deleted = append(r.deleted, token)
+	return nil
+}
+func (r *fakePushRepo) ListDevices(userID uint) ([]*pushentity.DeviceToken, error) {
+	return r.devices[userID], nil
+}
+func (r *fakePushRepo) SaveMute(m *pushentity.MuteSetting) error {
+	r.mutes[muteKey(m.UserID, m.TargetType, m.TargetID)] = m
+	return nil
+}
+func (r *fakePushRepo) DeleteMute(userID uint, t pushentity.MuteTarget, targetID uint) error {
+	delete(r.mutes, muteKey(userID, t, targetID))
+	return nil
+}
+func (r *fakePushRepo) ListMutes(userID uint) ([]*pushentity.MuteSetting, error) { return nil, nil }
+func (r *fakePushRepo) GetMute(userID uint, t pushentity.MuteTarget, targetID uint) (*pushentity.MuteSetting, error) {
+	return r.mutes[muteKey(userID, t, targetID)], nil
+}
+func (r *fakePushRepo) UnreadBadge(userID uint) (int64, error) { return 3, nil }
+
+// fakeGroupRepo 只实现 Get，其余方法未被推送服务调用
+type fakeGroupRepo struct {
+	chatrepo.GroupRepository
+	groups map[uint]*chatentity.Group
+}
+
+func (r *fakeGroupRepo) Get(id uint) (*chatentity.Group, error) {
+	return r.groups[id], nil
+}
+
+func newTestPushService() (*pushServiceImpl, *fakePushRepo, *push.RecordingProvider) {
+	repo := newFakePushRepo()
+	groups := &fakeGroupRepo{groups: map[uint]*chatentity.Group{7: {ID: 7, Name: "Hikers"}}}
+	rec := push.NewRecordingProvider()
+	s := NewPushService(repo, groups, rec, time.Second).(*pushServiceImpl)
+	return s, repo, rec
+}
+
+// parse 按 Offline 的方式把实时消息解析为 envelope
+func parse(t *testing.T, msg any) *envelope {
+	t.Helper()
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		t.Fatal(err)
+	}
+	return &env
+}
+
+func TestCompose(t *testing.T) {
+	notification := map[string]any{
+		"type": "notification",
+		"data": map[string]any{
+			"notification": map[string]any{"id": 9, "type": "moment_like", "target_id": 4, "content": ""},
+			"summary":      "Bob liked your moment",
+		},
+	}
+	tests := []struct {
+		name        string
+		msg         any
+		wantNil     bool
+		title, body string
+		collapseKey string
+		muteType    pushentity.MuteTarget
+		muteID      uint
+		mentioned   bool
+	}{
+		{
+			name:  "private text",
+			msg:   map[string]any{"type": "text", "sender_id": 2, "receiver_id": 1, "content": "hi", "sender": map[string]any{"nickname": "Bob"}},
+			title: "Bob", body: "hi", collapseKey: "chat:user:2", muteType: pushentity.MuteUser, muteID: 2,
+		},
+		{
+			name:  "private image shows placeholder",
+			msg:   map[string]any{"type": "image", "sender_id": 2, "receiver_id": 1, "content": "app-chat-images/x.jpg", "sender": map[string]any{"nickname": "Bob"}},
+			title: "Bob", body: "[image]", collapseKey: "chat:user:2", muteType: pushentity.MuteUser, muteID: 2,
+		},
+		{
+			name:  "private text truncated",
+			msg:   map[string]any{"type": "text", "sender_id": 2, "receiver_id": 1, "content": strings.Repeat("好", 200), "sender": map[string]any{"nickname": "Bob"}},
+			title: "Bob", body: strings.Repeat("好", maxBodyRunes-1) + "…", collapseKey: "chat:user:2", muteType: pushentity.MuteUser, muteID: 2,
+		},
+		{
+			name:  "group message",
+			msg:   map[string]any{"type": "group_message", "group_id": 7, "sender_id": 2, "content": "hello", "message_type": "text", "sender": map[string]any{"nickname": "Bob"}},
+			title: "Hikers", body: "Bob: hello", collapseKey: "chat:group:7", muteType: pushentity.MuteGroup, muteID: 7,
+		},
+		{
+			name:  "group message mentioning recipient",
+			msg:   map[string]any{"type": "group_message", "group_id": 7, "sender_id": 2, "content": "@you", "message_type": "text", "mentions": []uint{3, 1}, "sender": map[string]any{"nickname": "Bob"}},
+			title: "Hikers", body: "[@you] Bob: @you", collapseKey: "chat:group:7", muteType: pushentity.MuteGroup, muteID: 7, mentioned: true,
+		},
+		{
+			name:  "group message from unknown group",
+			msg:   map[string]any{"type": "group_message", "group_id": 8, "sender_id": 2, "content": "hello", "message_type": "text", "sender": map[string]any{"nickname": "Bob"}},
+			title: "Group chat", body: "Bob: hello", collapseKey: "chat:group:8", muteType: pushentity.MuteGroup, muteID: 8,
+		},
+		{
+			name: "notification",
+			msg:  notification,
+			body: "Bob liked your moment", collapseKey: "notify:moment_like:4",
+		},
+		{
+			name:    "read receipt is not pushed",
+			msg:     map[string]any{"type": "read", "data": map[string]any{"peer_id": 2}},
+			wantNil: true,
+		},
+		{
+			name:    "own outgoing message is not pushed",
+			msg:     map[string]any{"type": "text", "sender_id": 1, "receiver_id": 2, "content": "hi"},
+			wantNil: true,
+		},
+	}
+	s, _, _ := newTestPushService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := s.compose(1, parse(t, tt.msg))
+			if tt.wantNil {
+				if a != nil {
+					t.Fatalf("compose = %+v, want nil", a)
+				}
+				return
+			}
+			if a == nil {
+				t.Fatal("compose = nil")
+			}
+			if a.title != tt.title || a.body != tt.body || a.collapseKey != tt.collapseKey {
+				t.Errorf("compose = (%q, %q, %q), want (%q, %q, %q)", a.title, a.body, a.collapseKey, tt.title, tt.body, tt.collapseKey)
+			}
+			if a.muteType != tt.muteType || a.muteID != tt.muteID || a.mentioned != tt.mentioned {
+				t.Errorf("mute = (%q, %d, %v), want (%q, %d, %v)", a.muteType, a.muteID, a.mentioned, tt.muteType, tt.muteID, tt.mentioned)
+			}
+		})
+	}
+}
+
+func TestDeliverMutes(t *testing.T) {
+	groupMsg := map[string]any{"type": "group_message", "group_id": 7, "sender_id": 2, "content": "hello", "message_type": "text"}
+	mentionMsg := map[string]any{"type": "group_message", "group_id": 7, "sender_id": 2, "content": "hello", "message_type": "text", "mentions": []uint{1}}
+	privateMsg := map[string]any{"type": "text", "sender_id": 2, "receiver_id": 1, "content": "hi"}
+	expired := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name     string
+		mutes    []pushentity.MuteSetting
+		msg      any
+		wantSent bool
+	}{
+		{name: "no mute", msg: groupMsg, wantSent: true},
+		{name: "group muted", mutes: []pushentity.MuteSetting{{TargetType: pushentity.MuteGroup, TargetID: 7}}, msg: groupMsg},
+		{name: "group muted but mentioned", mutes: []pushentity.MuteSetting{{TargetType: pushentity.MuteGroup, TargetID: 7}}, msg: mentionMsg, wantSent: true},
+		{name: "mute all overrides mention", mutes: []pushentity.MuteSetting{{TargetType: pushentity.MuteAll}}, msg: mentionMsg},
+		{name: "other group muted", mutes: []pushentity.MuteSetting{{TargetType: pushentity.MuteGroup, TargetID: 8}}, msg: groupMsg, wantSent: true},
+		{name: "peer muted", mutes: []pushentity.MuteSetting{{TargetType: pushentity.MuteUser, TargetID: 2}}, msg: privateMsg},
+		{name: "expired mute", mutes: []pushentity.MuteSetting{{TargetType: pushentity.MuteUser, TargetID: 2, Until: &expired}}, msg: privateMsg, wantSent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, rec := newTestPushService()
+			_ = repo.SaveDevice(&pushentity.DeviceToken{UserID: 1, Platform: push.PlatformFCM, Token: "tok"})
+			for _, m := range tt.mutes {
+				m.UserID = 1
+				_ = repo.SaveMute(&m)
+			}
+			a := s.compose(1, parse(t, tt.msg))
+			if a == nil {
+				t.Fatal("compose = nil")
+			}
+			s.deliver(1, a)
+			sent := rec.Messages()
+			if got := len(sent) > 0; got != tt.wantSent {
+				t.Fatalf("sent = %v, want %v", got, tt.wantSent)
+			}
+			if tt.wantSent && (sent[0].Token != "tok" || sent[0].Badge != 3 || sent[0].CollapseKey != a.collapseKey) {
+				t.Errorf("message = %+v", sent[0])
+			}
+		})
+	}
+}
+
+func TestDeliverDropsInvalidToken(t *testing.T) {
+	s, repo, rec := newTestPushService()
+	_ = repo.SaveDevice(&pushentity.DeviceToken{UserID: 1, Platform: push.PlatformAPNs, Token: "stale"})
+	_ = repo.SaveDevice(&pushentity.DeviceToken{UserID: 1, Platform: push.PlatformFCM, Token: "fresh"})
+	rec.InvalidTokens["stale"] = true
+
+	s.deliver(1, s.compose(1, parse(t, map[string]any{"type": "text", "sender_id": 2, "receiver_id": 1, "content": "hi"})))
+
+	if sent := rec.Messages(); len(sent) != 1 || sent[0].Token != "fresh" {
+		t.Fatalf("sent = %+v, want only fresh", sent)
+	}
+	if len(repo.deleted) != 1 || repo.deleted[0] != "stale" {
+		t.Fatalf("deleted tokens = %v, want [stale]", repo.deleted)
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -15,6 +16,20 @@ type Config struct {
 	JWT      JWTConfig      `yaml:"jwt"`
 	Log      LogConfig      `yaml:"log"`
 	Minio    MinioConfig    `yaml:"minio"`
+	// Storage 对象存储驱动选择（minio / local / memory）
+	Storage StorageConfig `yaml:"storage"`
+	// Moderation 内容审核（敏感词）
+	Moderation ModerationConfig `yaml:"moderation"`
+	// Friend 好友关系
+	Friend FriendConfig `yaml:"friend"`
+	// Moment 朋友圈
+	Moment MomentConfig `yaml:"moment"`
+	// Push 离线推送
+	Push PushConfig `yaml:"push"`
+	// UploadProfiles 上传场景（头像、动态图片、聊天视频等），键为场景名
+	UploadProfiles map[string]UploadProfile `yaml:"upload-profiles"`
+	// Quota App 用户存储配额
+	Quota QuotaConfig `yaml:"quota"`
 }
 
 // ServerConfig 服务器配置
@@ -58,6 +73,136 @@ type MinioConfig struct {
 	MaxFileSizeMB   int      `yaml:"max-file-size-mb"`
 	AllowedMIMEs    []string `yaml:"allowed-mime-types"`
 	EnableVirusScan bool     `yaml:"enable-virus-scan"`
+	// VirusScanner 扫描引擎：clamd（默认，ClamAV 守护进程）/ eicar（仅识别 EICAR 测试串，离线开发与测试）
+	VirusScanner string `yaml:"virus-scanner"`
+	// ClamdAddress clamd 地址，如 tcp://127.0.0.1:3310 或 unix:///var/run/clamav/clamd.ctl
+	ClamdAddress            string `yaml:"clamd-address"`
+	VirusScanTimeoutSeconds int    `yaml:"virus-scan-timeout-seconds"`
+	// QuarantineBucket 感染文件的隔离 bucket（私有）
+	QuarantineBucket string `yaml:"quarantine-bucket"`
+	// SignedURLTTLSeconds 私有 bucket（如聊天媒体）读取时签发的预签名地址有效期
+	SignedURLTTLSeconds int `yaml:"signed-url-ttl-seconds"`
+}
+
+// StorageConfig 对象存储驱动配置
+type StorageConfig struct {
+	// Driver minio（默认，使用 minio 配置）/ local（本地目录，开发与 CI 使用）/ memory（进程内，数据随进程退出丢失）
+	Driver string `yaml:"driver"`
+	// LocalRoot local 驱动的根目录，每个 bucket 为其下一个子目录
+	LocalRoot string `yaml:"local-root"`
+	// BaseURL local / memory 驱动对外访问的基础地址，对象与预签名链接由本服务的 /files 路由提供
+	BaseURL string `yaml:"base-url"`
+	// SigningSecret local / memory 驱动预签名链接的 HMAC 密钥，留空使用 jwt.secret_key
+	SigningSecret string `yaml:"signing-secret"`
+	// Lifecycle 对象生命周期：清理未被引用的对象与按 bucket 过期
+	Lifecycle LifecycleConfig `yaml:"lifecycle"`
+}
+
+// LifecycleConfig 对象生命周期任务，作用于上传场景的 bucket 与 Buckets 中列出的 bucket
+type LifecycleConfig struct {
+	Enabled         bool `yaml:"enabled"`
+	IntervalMinutes int  `yaml:"interval-minutes"`
+	// OrphanGraceHours 对象写入后超过该时长仍未被任何业务数据引用即删除
+	OrphanGraceHours int `yaml:"orphan-grace-hours"`
+	// DryRun 只生成报告（写日志），不删除
+	DryRun  bool                    `yaml:"dry-run"`
+	Buckets map[string]BucketPolicy `yaml:"buckets"`
+}
+
+// BucketPolicy 单个 bucket 的保留与过期策略
+type BucketPolicy struct {
+	// OrphanGraceHours 覆盖全局宽限期，-1 表示不清理该 bucket 中未被引用的对象（如存放管理端手工上传的文件）
+	OrphanGraceHours int `yaml:"orphan-grace-hours"`
+	// ExpireDays 对象写入超过该天数即删除，无论是否仍被引用；0 表示不过期
+	ExpireDays int `yaml:"expire-days"`
+}
+
+// ModerationConfig 内容审核配置
+type ModerationConfig struct {
+	// WordsFile 敏感词库文件（每行 `词` 或 `词,动作`，动作为 block/mask/flag，默认 mask），留空仅使用数据库词库
+	WordsFile string `yaml:"words-file"`
+	// ReloadIntervalSeconds 词库热加载周期（秒）
+	ReloadIntervalSeconds int `yaml:"reload-interval-seconds"`
+}
+
+// FriendConfig 好友配置
+type FriendConfig struct {
+	// RequestTTLHours 好友申请有效期（小时），过期后自动标记为 expired
+	RequestTTLHours int `yaml:"request-ttl-hours"`
+	// SuggestionRefreshMinutes “可能认识的人”预计算刷新周期（分钟）
+	SuggestionRefreshMinutes int `yaml:"suggestion-refresh-minutes"`
+}
+
+// MomentConfig 朋友圈配置
+type MomentConfig struct {
+	// FanoutMaxFriends 好友数不超过该值的用户发布动态时写扩散到好友时间线，超过则改为读扩散
+	FanoutMaxFriends int `yaml:"fanout-max-friends"`
+}
+
+// PushConfig 离线推送配置
+type PushConfig struct {
+	// Provider 推送通道：none（不推送）/ http（本地替身，POST 到 Endpoint）/ recording（仅记录）
+	Provider string `yaml:"provider"`
+	// Endpoint http 通道的接收地址
+	Endpoint string `yaml:"endpoint"`
+	// TimeoutSeconds 单次推送超时（秒）
+	TimeoutSeconds int `yaml:"timeout-seconds"`
+}
+
+// UploadProfile 上传场景配置
+type UploadProfile struct {
+	// Bucket 目标 bucket，留空时由调用方指定（管理端上传）
+	Bucket string `yaml:"bucket"`
+	// Prefix 生成对象名的前缀：{prefix}-{sha256}{ext}（分片与直传在内容确定前命名，为 {prefix}-{id}-{时间戳}{ext}）
+	Prefix string `yaml:"prefix"`
+	// AllowedMIMEs 允许的真实类型（支持 * 与 image/* 前缀），留空沿用 minio.allowed-mime-types
+	AllowedMIMEs []string `yaml:"allowed-mime-types"`
+	// MaxSizeMB 单文件大小上限，0 沿用 minio.max-file-size-mb
+	MaxSizeMB int `yaml:"max-size-mb"`
+	// Process 处理步骤：image（嗅探类型并去除元数据）/ variants（生成缩略图与中图）/ video（解析时长与尺寸）
+	Process []string `yaml:"process"`
+	// MaxDurationSeconds 视频最长时长，配置后仅接受可解析时长的 MP4/MOV
+	MaxDurationSeconds int `yaml:"max-duration-seconds"`
+	// Kind 登记为媒体附件时的场景（moment / chat），留空表示非 App 媒体（不开放分片上传）
+	Kind string `yaml:"kind"`
+	// PosterProfile 视频封面图使用的上传场景
+	PosterProfile string `yaml:"poster-profile"`
+	// Private 私有 bucket：不开放公共读，读取时仅向有权限的用户签发短时有效的预签名地址
+	Private bool `yaml:"private"`
+	// Quota 计入上传者存储配额的类别（chat / moment / avatar），留空不计入（如群头像、管理端上传）
+	Quota string `yaml:"quota"`
+}
+
+// QuotaConfig App 用户存储配额：用量按类别统计（聊天、动态为本人名下的媒体附件，头像为当前头像），按档位限制
+type QuotaConfig struct {
+	// Enabled 关闭时仍统计用量，但不拒绝上传
+	Enabled bool `yaml:"enabled"`
+	// DefaultTier 未单独设置档位的用户所用档位
+	DefaultTier string               `yaml:"default-tier"`
+	Tiers       map[string]QuotaTier `yaml:"tiers"`
+}
+
+// QuotaTier 配额档位，数值为 0 表示不限制
+type QuotaTier struct {
+	TotalMB int64 `yaml:"total-mb"`
+	// CategoryMB 按类别的上限，未配置的类别只受总量约束
+	CategoryMB map[string]int64 `yaml:"category-mb"`
+}
+
+// defaultUploadProfiles 内置上传场景，YAML 中同名配置整体覆盖
+func defaultUploadProfiles() map[string]UploadProfile {
+	images := []string{"image/jpeg", "image/png", "image/gif"}
+	return map[string]UploadProfile{
+		"avatar":       {Bucket: "app-avatars", Prefix: "avatar", AllowedMIMEs: images, Process: []string{"image", "variants"}, Quota: "avatar"},
+		"group-avatar": {Bucket: "app-group-avatars", Prefix: "group-avatar", AllowedMIMEs: images, Process: []string{"image", "variants"}},
+		"moment-image": {Bucket: "app-moment-images", Prefix: "moment", AllowedMIMEs: images, Process: []string{"image", "variants"}, Kind: "moment", Quota: "moment"},
+		"moment-video": {Bucket: "app-moment-videos", Prefix: "moment-video", AllowedMIMEs: []string{"video/mp4", "video/quicktime"}, Process: []string{"video"},
+			MaxDurationSeconds: 60, Kind: "moment", PosterProfile: "moment-image", Quota: "moment"},
+		"chat-image": {Bucket: "app-chat-images", Prefix: "chat", AllowedMIMEs: images, Process: []string{"image", "variants"}, Kind: "chat", Private: true, Quota: "chat"},
+		"chat-video": {Bucket: "app-chat-videos", Prefix: "chat-video", AllowedMIMEs: []string{"video/*"}, Process: []string{"video"}, Kind: "chat", PosterProfile: "chat-image",
+			Private: true, Quota: "chat"},
+		"admin": {},
+	}
 }
 
 // Load 加载配置
@@ -109,7 +254,38 @@ func Load() *Config {
 				}
 				return nil
 			}(),
-			EnableVirusScan: getEnv("MINIO_ENABLE_VIRUS_SCAN", "false") == "true",
+			EnableVirusScan:         getEnv("MINIO_ENABLE_VIRUS_SCAN", "false") == "true",
+			VirusScanner:            getEnv("MINIO_VIRUS_SCANNER", "clamd"),
+			ClamdAddress:            getEnv("MINIO_CLAMD_ADDRESS", "tcp://127.0.0.1:3310"),
+			VirusScanTimeoutSeconds: getEnvAsInt("MINIO_VIRUS_SCAN_TIMEOUT_SECONDS", 30),
+			QuarantineBucket:        getEnv("MINIO_QUARANTINE_BUCKET", "app-quarantine"),
+		},
+		Storage: StorageConfig{
+			Driver:        getEnv("STORAGE_DRIVER", "minio"),
+			LocalRoot:     getEnv("STORAGE_LOCAL_ROOT", "./data/storage"),
+			BaseURL:       getEnv("STORAGE_BASE_URL", ""),
+			SigningSecret: getEnv("STORAGE_SIGNING_SECRET", ""),
+			Lifecycle: LifecycleConfig{
+				Enabled:          getEnv("STORAGE_LIFECYCLE_ENABLED", "false") == "true",
+				IntervalMinutes:  getEnvAsInt("STORAGE_LIFECYCLE_INTERVAL_MINUTES", 1440),
+				OrphanGraceHours: getEnvAsInt("STORAGE_LIFECYCLE_ORPHAN_GRACE_HOURS", 72),
+				DryRun:           getEnv("STORAGE_LIFECYCLE_DRY_RUN", "false") == "true",
+			},
+		},
+		Moderation: ModerationConfig{
+			WordsFile:             getEnv("MODERATION_WORDS_FILE", ""),
+			ReloadIntervalSeconds: getEnvAsInt("MODERATION_RELOAD_INTERVAL_SECONDS", 60),
+		},
+		Friend: FriendConfig{
+			RequestTTLHours:          getEnvAsInt("FRIEND_REQUEST_TTL_HOURS", 168),
+			SuggestionRefreshMinutes: getEnvAsInt("FRIEND_SUGGESTION_REFRESH_MINUTES", 60),
+		},
+		Moment: MomentConfig{
+			FanoutMaxFriends: getEnvAsInt("MOMENT_FANOUT_MAX_FRIENDS", 500),
+		},
+		Quota: QuotaConfig{
+			Enabled:     getEnv("QUOTA_ENABLED", "false") == "true",
+			DefaultTier: getEnv("QUOTA_DEFAULT_TIER", "free"),
 		},
 	}
 	applyDefaults(cfg)
@@ -147,6 +323,74 @@ func applyDefaults(c *Config) {
 	if len(c.Minio.AllowedMIMEs) == 0 { // 默认允许常见图片/文本
 		c.Minio.AllowedMIMEs = []string{"image/png", "image/jpeg", "image/gif", "text/plain", "application/pdf", "video/mp4", "video/quicktime", "video/x-matroska"}
 	}
+	if c.Minio.SignedURLTTLSeconds <= 0 {
+		c.Minio.SignedURLTTLSeconds = 600
+	}
+	if c.Minio.VirusScanner == "" {
+		c.Minio.VirusScanner = "clamd"
+	}
+	if c.Minio.ClamdAddress == "" {
+		c.Minio.ClamdAddress = "tcp://127.0.0.1:3310"
+	}
+	if c.Minio.VirusScanTimeoutSeconds <= 0 {
+		c.Minio.VirusScanTimeoutSeconds = 30
+	}
+	if c.Minio.QuarantineBucket == "" {
+		c.Minio.QuarantineBucket = "app-quarantine"
+	}
+	if c.Storage.Driver == "" {
+		c.Storage.Driver = "minio"
+	}
+	if c.Storage.LocalRoot == "" {
+		c.Storage.LocalRoot = "./data/storage"
+	}
+	if c.Storage.BaseURL == "" {
+		c.Storage.BaseURL = "http://localhost:8090/files"
+	}
+	if c.Storage.SigningSecret == "" {
+		c.Storage.SigningSecret = c.JWT.SecretKey
+	}
+	if c.Storage.Lifecycle.IntervalMinutes <= 0 {
+		c.Storage.Lifecycle.IntervalMinutes = 1440
+	}
+	if c.Storage.Lifecycle.OrphanGraceHours <= 0 {
+		c.Storage.Lifecycle.OrphanGraceHours = 72
+	}
+	if c.Moderation.ReloadIntervalSeconds <= 0 {
+		c.Moderation.ReloadIntervalSeconds = 60
+	}
+	if c.Friend.RequestTTLHours == 0 {
+		c.Friend.RequestTTLHours = 168
+	}
+	if c.Friend.SuggestionRefreshMinutes <= 0 {
+		c.Friend.SuggestionRefreshMinutes = 60
+	}
+	if c.Moment.FanoutMaxFriends <= 0 {
+		c.Moment.FanoutMaxFriends = 500
+	}
+	if c.UploadProfiles == nil {
+		c.UploadProfiles = map[string]UploadProfile{}
+	}
+	for name, p := range defaultUploadProfiles() {
+		if _, ok := c.UploadProfiles[name]; !ok {
+			c.UploadProfiles[name] = p
+		}
+	}
+	if c.Quota.DefaultTier == "" {
+		c.Quota.DefaultTier = "free"
+	}
+	if len(c.Quota.Tiers) == 0 {
+		c.Quota.Tiers = map[string]QuotaTier{
+			"free": {TotalMB: 1024, CategoryMB: map[string]int64{"avatar": 20}},
+			"plus": {TotalMB: 10240, CategoryMB: map[string]int64{"avatar": 20}},
+		}
+	}
+	if c.Push.Provider == "" {
+		c.Push.Provider = "none"
+	}
+	if c.Push.TimeoutSeconds <= 0 {
+		c.Push.TimeoutSeconds = 5
+	}
 }
 
 // splitAndTrim 按逗号拆分并去空白
@@ -179,3 +423,44 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// LifecycleBuckets 生命周期任务处理的 bucket：上传场景的 bucket、隔离 bucket（启用扫描时）与单独配置了策略的 bucket
+func (c *Config) LifecycleBuckets() []string {
+	seen := map[string]bool{}
+	var out []string
+	add := func(b string) {
+		if b != "" && !seen[b] {
+			seen[b] = true
+			out = append(out, b)
+		}
+	}
+	for _, p := range c.UploadProfiles {
+		add(p.Bucket)
+	}
+	if c.Minio.EnableVirusScan {
+		add(c.Minio.QuarantineBucket)
+	}
+	for b := range c.Storage.Lifecycle.Buckets {
+		add(b)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// PrivateBuckets 配置为私有的上传场景所用的 bucket，启用病毒扫描时包含隔离 bucket
+func (c *Config) PrivateBuckets() []string {
+	seen := map[string]bool{}
+	var out []string
+	if c.Minio.EnableVirusScan && c.Minio.QuarantineBucket != "" {
+		seen[c.Minio.QuarantineBucket] = true
+		out = append(out, c.Minio.QuarantineBucket)
+	}
+	for _, p := range c.UploadProfiles {
+		if p.Private && p.Bucket != "" && !seen[p.Bucket] {
+			seen[p.Bucket] = true
+			out = append(out, p.Bucket)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
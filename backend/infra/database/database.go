@@ -9,7 +9,11 @@ import (
 	friendEntity "alice/domain/appfriend/entity"
 	appEntity "alice/domain/appuser/entity"
 	chatEntity "alice/domain/chat/entity"
+	mediaEntity "alice/domain/media/entity"
+	moderationEntity "alice/domain/moderation/entity"
 	momentEntity "alice/domain/moment/entity"
+	notificationEntity "alice/domain/notification/entity"
+	pushEntity "alice/domain/push/entity"
 	rbacEntity "alice/domain/rbac/entity"
 	"alice/domain/user/entity"
 	"alice/infra/config"
@@ -78,6 +82,9 @@ func InitDB(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 	if err := autoMigrate(db); err != nil {
 		return nil, fmt.Errorf("failed to auto migrate: %w", err)
 	}
+	if err := migrateMediaAttachments(db); err != nil {
+		logger.Warn("迁移历史媒体附件失败", "error", err)
+	}
 
 	logger.Info("Database connected successfully")
 	return db, nil
@@ -91,11 +98,18 @@ func autoMigrate(db *gorm.DB) error {
 
 		// App 端表
 		&appEntity.AppUser{},
+		&appEntity.AppUserPrivacy{},
 		&friendEntity.FriendRelation{},
 		&friendEntity.FriendRequest{},
+		&friendEntity.UserBlock{},
+		&friendEntity.FriendTag{},
+		&friendEntity.FriendTagMember{},
+		&friendEntity.FriendSuggestion{},
 
 		// Moments
 		&momentEntity.Moment{},
+		&momentEntity.MomentAudience{},
+		&momentEntity.TimelineEntry{},
 		&momentEntity.MomentLike{},
 		&momentEntity.MomentComment{},
 
@@ -106,6 +120,28 @@ func autoMigrate(db *gorm.DB) error {
 		&chatEntity.GroupMessage{},
 		&chatEntity.GroupReadCursor{},
 
+		// 内容审核
+		&moderationEntity.SensitiveWord{},
+		&moderationEntity.FlaggedContent{},
+		&moderationEntity.Report{},
+		&moderationEntity.AuditLog{},
+
+		// 媒体附件
+		&mediaEntity.Attachment{},
+		&mediaEntity.UploadSession{},
+		&mediaEntity.QuarantinedFile{},
+		&mediaEntity.Blob{},
+		&mediaEntity.StorageQuota{},
+		&mediaEntity.StorageReservation{},
+
+		// 通知中心
+		&notificationEntity.Notification{},
+		&notificationEntity.Preference{},
+
+		// 离线推送
+		&pushEntity.DeviceToken{},
+		&pushEntity.MuteSetting{},
+
 		// RBAC表
 		&rbacEntity.Role{},
 		&rbacEntity.Permission{},
@@ -115,3 +151,48 @@ func autoMigrate(db *gorm.DB) error {
 		&rbacEntity.RoleMenu{},
 	)
 }
+
+// mediaMimeSQL 按扩展名推断历史数据的 MIME
+const mediaMimeSQL = `CASE
+	WHEN lower(%[1]s) LIKE '%%.png' THEN 'image/png'
+	WHEN lower(%[1]s) LIKE '%%.jpg' OR lower(%[1]s) LIKE '%%.jpeg' THEN 'image/jpeg'
+	WHEN lower(%[1]s) LIKE '%%.gif' THEN 'image/gif'
+	WHEN lower(%[1]s) LIKE '%%.webp' THEN 'image/webp'
+	WHEN lower(%[1]s) LIKE '%%.mp4' THEN 'video/mp4'
+	WHEN lower(%[1]s) LIKE '%%.mov' THEN 'video/quicktime'
+	ELSE '' END`
+
+// migrateMediaAttachments 将历史动态的逗号分隔图片及聊天图片/视频消息转换为媒体附件（幂等，已有附件的对象跳过）。
+// 历史数据没有宽高与占位信息，客户端按未知尺寸处理
+func migrateMediaAttachments(db *gorm.DB) error {
+	moments := fmt.Sprintf(`INSERT INTO app_media_attachments (owner_id, kind, path, mime, ref_type, ref_id, position, created_at)
+		SELECT m.user_id, 'moment', trim(p.path), %s, 'moment', m.id, p.ord - 1, m.created_at
+		FROM app_moments m
+		CROSS JOIN LATERAL unnest(string_to_array(m.images, ',')) WITH ORDINALITY AS p(path, ord)
+		WHERE m.images <> '' AND trim(p.path) <> ''
+		AND NOT EXISTS (SELECT 1 FROM app_media_attachments a WHERE a.ref_type = 'moment' AND a.ref_id = m.id)`, fmt.Sprintf(mediaMimeSQL, "p.path"))
+	res := db.Exec(moments)
+	if res.Error != nil {
+		return res.Error
+	}
+	total := res.RowsAffected
+	for _, t := range []struct{ table, refType, sender string }{
+		{"app_chat_messages", "message", "sender_id"},
+		{"app_chat_group_messages", "group_message", "sender_id"},
+	} {
+		res = db.Exec(fmt.Sprintf(`INSERT INTO app_media_attachments (owner_id, kind, path, mime, ref_type, ref_id, position, created_at)
+			SELECT m.%[2]s, 'chat', m.content, %[4]s, '%[3]s', m.id, 0, m.created_at
+			FROM %[1]s m
+			WHERE m.type IN ('image', 'video')
+			AND NOT EXISTS (SELECT 1 FROM app_media_attachments a WHERE a.ref_type = '%[3]s' AND a.ref_id = m.id)`,
+			t.table, t.sender, t.refType, fmt.Sprintf(mediaMimeSQL, "m.content")))
+		if res.Error != nil {
+			return res.Error
+		}
+		total += res.RowsAffected
+	}
+	if total > 0 {
+		logger.Infof("已迁移 %d 条历史媒体附件", total)
+	}
+	return nil
+}
@@ -1,7 +1,11 @@
 package repository
 
 import (
+	"errors"
+	"strings"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	appentity "alice/domain/appuser/entity"
 	apprepo "alice/domain/appuser/repository"
@@ -59,3 +63,57 @@ func (r *appUserRepositoryImpl) List(offset, limit int) ([]*appentity.AppUser, i
 	}
 	return list, total, nil
 }
+
+func (r *appUserRepositoryImpl) GetByUsername(username string) (*appentity.AppUser, error) {
+	var u appentity.AppUser
+	if err := r.db.Where("username = ?", username).First(&u).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *appUserRepositoryImpl) Search(query string, excludeIDs []uint, offset, limit int) ([]*appentity.AppUser, int64, error) {
+	query = strings.TrimSpace(query)
+	q := r.db.Model(&appentity.AppUser{}).
+		Joins("LEFT JOIN app_user_privacy p ON p.user_id = app_users.id").
+		Where("app_users.status = ?", appentity.AppUserStatusActive)
+	if len(excludeIDs) > 0 {
+		q = q.Where("app_users.id NOT IN ?", excludeIDs)
+	}
+	lower := strings.ToLower(query)
+	var order interface{} = "app_users.id ASC"
+	if strings.Contains(query, "@") {
+		// 邮箱只做精确匹配，避免枚举
+		q = q.Where("app_users.email = ? AND COALESCE(p.findable_by_email, TRUE)", lower)
+	} else {
+		like := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(lower)
+		q = q.Where("COALESCE(p.findable_by_handle, TRUE)").
+			Where("app_users.username = ? OR app_users.username LIKE ? OR LOWER(app_users.nickname) LIKE ?", lower, like+"%", "%"+like+"%")
+		// handle 完全匹配优先
+		order = clause.OrderBy{Expression: clause.Expr{SQL: "CASE WHEN app_users.username = ? THEN 0 ELSE 1 END, app_users.id ASC", Vars: []interface{}{lower}, WithoutParentheses: true}}
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []*appentity.AppUser
+	if err := q.Select("app_users.*").Order(order).Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+func (r *appUserRepositoryImpl) GetPrivacy(userID uint) (*appentity.AppUserPrivacy, error) {
+	var p appentity.AppUserPrivacy
+	if err := r.db.First(&p, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appentity.DefaultPrivacy(userID), nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *appUserRepositoryImpl) SavePrivacy(p *appentity.AppUserPrivacy) error {
+	return r.db.Save(p).Error
+}
@@ -176,3 +176,20 @@ func (r *groupRepositoryImpl) ListMessages(groupID uint, offset, limit int) ([]*
 
 // Expose additional interface via type assertion in service (quick approach). In production you'd split repos.
 var ErrNotOwner = errors.New("not owner")
+
+func (r *groupRepositoryImpl) GetMessage(id uint) (*chatentity.GroupMessage, error) {
+	var m chatentity.GroupMessage
+	if err := r.db.First(&m, id).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *groupRepositoryImpl) DeleteMessage(id uint) error {
+	return r.db.Delete(&chatentity.GroupMessage{}, id).Error
+}
+
+func (r *groupRepositoryImpl) RecallMessage(id uint, at time.Time) error {
+	return r.db.Model(&chatentity.GroupMessage{}).Where("id = ?", id).
+		Updates(map[string]any{"content": "", "mentions": "", "recalled_at": at}).Error
+}
@@ -19,10 +19,28 @@ func (r *messageRepositoryImpl) Save(msg *chatentity.Message) error {
 	return r.db.Create(msg).Error
 }
 
+func (r *messageRepositoryImpl) Get(id uint) (*chatentity.Message, error) {
+	var m chatentity.Message
+	if err := r.db.First(&m, id).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *messageRepositoryImpl) Delete(id uint) error {
+	return r.db.Delete(&chatentity.Message{}, id).Error
+}
+
+func (r *messageRepositoryImpl) Recall(id uint, at time.Time) error {
+	return r.db.Model(&chatentity.Message{}).Where("id = ?", id).
+		Updates(map[string]any{"content": "", "recalled_at": at}).Error
+}
+
 func (r *messageRepositoryImpl) ListConversation(a, b uint, offset, limit int) ([]*chatentity.Message, int64, error) {
 	var total int64
+	// a 为查看方：被静默丢弃的消息对接收方不可见
 	q := r.db.Model(&chatentity.Message{}).Where(
-		"(sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?)", a, b, b, a,
+		"((sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ? AND dropped = ?))", a, b, b, a, false,
 	)
 	if err := q.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -56,7 +74,7 @@ func (r *messageRepositoryImpl) ListRecentConversations(self uint, offset, limit
 	var rows []row
 	sub := r.db.Model(&chatentity.Message{}).
 		Select("CASE WHEN sender_id = ? THEN receiver_id ELSE sender_id END AS peer_id, MAX(id) AS last_id", self).
-		Where("sender_id = ? OR receiver_id = ?", self, self).
+		Where("sender_id = ? OR (receiver_id = ? AND dropped = ?)", self, self, false).
 		Group("peer_id")
 	// 分页总数
 	var total int64
@@ -82,7 +100,7 @@ func (r *messageRepositoryImpl) ListRecentConversations(self uint, offset, limit
 		// 未读：对方->我 且 is_read=false
 		var unread int64
 		if err := r.db.Model(&chatentity.Message{}).
-			Where("sender_id = ? AND receiver_id = ? AND is_read = ?", rrow.PeerID, self, false).
+			Where("sender_id = ? AND receiver_id = ? AND is_read = ? AND dropped = ?", rrow.PeerID, self, false, false).
 			Count(&unread).Error; err != nil {
 			return nil, 0, err
 		}
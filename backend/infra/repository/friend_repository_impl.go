@@ -1,6 +1,11 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
 	"gorm.io/gorm"
 
 	friendentity "alice/domain/appfriend/entity"
@@ -45,30 +50,125 @@ func (r *friendRepositoryImpl) ListFriends(userID uint, offset, limit int) ([]ui
 	return ids, total, nil
 }
 
+func (r *friendRepositoryImpl) ListFriendsByTag(userID, tagID uint, offset, limit int) ([]uint, int64, error) {
+	var total int64
+	q := r.db.Model(&friendentity.FriendRelation{}).
+		Joins("JOIN app_friend_tag_members tm ON tm.friend_id = app_friend_relations.friend_id AND tm.tag_id = ?", tagID).
+		Where("app_friend_relations.user_id = ?", userID)
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var ids []uint
+	if err := q.Order("app_friend_relations.id DESC").Offset(offset).Limit(limit).Pluck("app_friend_relations.friend_id", &ids).Error; err != nil {
+		return nil, 0, err
+	}
+	return ids, total, nil
+}
+
+func (r *friendRepositoryImpl) GetRelations(userID uint, friendIDs []uint) ([]*friendentity.FriendRelation, error) {
+	var rows []*friendentity.FriendRelation
+	if len(friendIDs) == 0 {
+		return rows, nil
+	}
+	if err := r.db.Where("user_id = ? AND friend_id IN ?", userID, friendIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// UpdateRemark 返回 false 表示不存在该好友关系
+func (r *friendRepositoryImpl) UpdateRemark(userID, friendID uint, alias, notes string) (bool, error) {
+	res := r.db.Model(&friendentity.FriendRelation{}).
+		Where("user_id = ? AND friend_id = ?", userID, friendID).
+		Updates(map[string]interface{}{"alias": alias, "notes": notes})
+	return res.RowsAffected > 0, res.Error
+}
+
 // Friend Requests
-func (r *friendRepositoryImpl) CreateRequest(requesterID, addresseeID uint) error {
-	if requesterID == addresseeID {
+func (r *friendRepositoryImpl) SaveRequest(req *friendentity.FriendRequest) error {
+	if req.RequesterID == req.AddresseeID {
 		return nil
 	}
-	req := &friendentity.FriendRequest{RequesterID: requesterID, AddresseeID: addresseeID, Status: friendentity.FriendRequestPending}
-	// one active pending per requester->addressee
-	return r.db.Where("requester_id = ? AND addressee_id = ? AND status = ?", requesterID, addresseeID, friendentity.FriendRequestPending).FirstOrCreate(req).Error
+	existing, err := r.FindPendingRequest(req.RequesterID, req.AddresseeID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return r.db.Create(req).Error
+	}
+	existing.Message = req.Message
+	existing.Source = req.Source
+	existing.ExpiresAt = req.ExpiresAt
+	if err := r.db.Save(existing).Error; err != nil {
+		return err
+	}
+	*req = *existing
+	return nil
+}
+
+func (r *friendRepositoryImpl) GetRequest(id uint) (*friendentity.FriendRequest, error) {
+	var req friendentity.FriendRequest
+	if err := r.db.First(&req, id).Error; err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *friendRepositoryImpl) pendingScope(now time.Time) *gorm.DB {
+	return r.db.Model(&friendentity.FriendRequest{}).
+		Where("status = ? AND (expires_at IS NULL OR expires_at > ?)", friendentity.FriendRequestPending, now)
 }
 
-func (r *friendRepositoryImpl) AcceptRequest(requestID uint) (uint, uint, error) {
+func (r *friendRepositoryImpl) FindPendingRequest(requesterID, addresseeID uint) (*friendentity.FriendRequest, error) {
 	var req friendentity.FriendRequest
-	if err := r.db.First(&req, requestID).Error; err != nil {
-		return 0, 0, err
+	err := r.pendingScope(time.Now()).
+		Where("requester_id = ? AND addressee_id = ?", requesterID, addresseeID).
+		Order("id DESC").First(&req).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
 	}
-	req.Status = friendentity.FriendRequestAccepted
-	if err := r.db.Save(&req).Error; err != nil {
-		return 0, 0, err
+	if err != nil {
+		return nil, err
 	}
-	return req.RequesterID, req.AddresseeID, nil
+	return &req, nil
 }
 
-func (r *friendRepositoryImpl) DeclineRequest(requestID uint) error {
-	return r.db.Model(&friendentity.FriendRequest{}).Where("id = ?", requestID).Update("status", friendentity.FriendRequestDeclined).Error
+func (r *friendRepositoryImpl) TransitionRequest(id uint, to friendentity.FriendRequestStatus) (bool, error) {
+	res := r.db.Model(&friendentity.FriendRequest{}).
+		Where("id = ? AND status = ?", id, friendentity.FriendRequestPending).
+		Update("status", to)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *friendRepositoryImpl) ListIncomingRequests(addresseeID uint, offset, limit int) ([]*friendentity.FriendRequest, int64, error) {
+	// 收件人已拉黑的申请人：申请对其不可见
+	q := r.pendingScope(time.Now()).
+		Where("addressee_id = ?", addresseeID).
+		Where("requester_id NOT IN (?)", r.db.Model(&friendentity.UserBlock{}).Select("blocked_id").Where("user_id = ?", addresseeID))
+	return r.listRequests(q, offset, limit)
+}
+
+func (r *friendRepositoryImpl) ListOutgoingRequests(requesterID uint, offset, limit int) ([]*friendentity.FriendRequest, int64, error) {
+	return r.listRequests(r.pendingScope(time.Now()).Where("requester_id = ?", requesterID), offset, limit)
+}
+
+func (r *friendRepositoryImpl) listRequests(q *gorm.DB, offset, limit int) ([]*friendentity.FriendRequest, int64, error) {
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []*friendentity.FriendRequest
+	if err := q.Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+func (r *friendRepositoryImpl) ExpireRequests(now time.Time) (int64, error) {
+	res := r.db.Model(&friendentity.FriendRequest{}).
+		Where("status = ? AND expires_at IS NOT NULL AND expires_at <= ?", friendentity.FriendRequestPending, now).
+		Update("status", friendentity.FriendRequestExpired)
+	return res.RowsAffected, res.Error
 }
 
 // AreFriends 检查是否互为好友（需要同时存在 A->B 与 B->A 记录）
@@ -85,21 +185,242 @@ func (r *friendRepositoryImpl) AreFriends(a, b uint) (bool, error) {
 	return cnt >= 2, nil
 }
 
-func (r *friendRepositoryImpl) GetPendingRequests(addresseeID uint, offset, limit int) ([]uint, []uint, int64, error) {
-	var list []friendentity.FriendRequest
+// Block list
+func (r *friendRepositoryImpl) Block(userID, blockedID uint) error {
+	if userID == blockedID {
+		return nil
+	}
+	b := &friendentity.UserBlock{UserID: userID, BlockedID: blockedID}
+	return r.db.Where("user_id = ? AND blocked_id = ?", userID, blockedID).FirstOrCreate(b).Error
+}
+
+func (r *friendRepositoryImpl) Unblock(userID, blockedID uint) error {
+	return r.db.Where("user_id = ? AND blocked_id = ?", userID, blockedID).Delete(&friendentity.UserBlock{}).Error
+}
+
+func (r *friendRepositoryImpl) ListBlocked(userID uint, offset, limit int) ([]uint, int64, error) {
+	var total int64
+	q := r.db.Model(&friendentity.UserBlock{}).Where("user_id = ?", userID)
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var ids []uint
+	if err := q.Order("id DESC").Offset(offset).Limit(limit).Pluck("blocked_id", &ids).Error; err != nil {
+		return nil, 0, err
+	}
+	return ids, total, nil
+}
+
+func (r *friendRepositoryImpl) IsBlocked(userID, targetID uint) (bool, error) {
+	if userID == 0 || targetID == 0 || userID == targetID {
+		return false, nil
+	}
+	var cnt int64
+	if err := r.db.Model(&friendentity.UserBlock{}).Where("user_id = ? AND blocked_id = ?", userID, targetID).Count(&cnt).Error; err != nil {
+		return false, err
+	}
+	return cnt > 0, nil
+}
+
+func (r *friendRepositoryImpl) ListBlockRelated(userID uint) ([]uint, error) {
+	var rows []friendentity.UserBlock
+	if err := r.db.Where("user_id = ? OR blocked_id = ?", userID, userID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(rows))
+	for _, b := range rows {
+		if b.UserID == userID {
+			ids = append(ids, b.BlockedID)
+		} else {
+			ids = append(ids, b.UserID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *friendRepositoryImpl) ShareGroup(a, b uint) (bool, error) {
+	var n int64
+	err := r.db.Table("app_chat_group_members AS m1").
+		Joins("JOIN app_chat_group_members AS m2 ON m2.group_id = m1.group_id").
+		Where("m1.user_id = ? AND m2.user_id = ?", a, b).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// Friend tags
+func (r *friendRepositoryImpl) CreateTag(t *friendentity.FriendTag) error {
+	return r.db.Create(t).Error
+}
+
+func (r *friendRepositoryImpl) GetTag(id uint) (*friendentity.FriendTag, error) {
+	var t friendentity.FriendTag
+	if err := r.db.First(&t, id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *friendRepositoryImpl) GetTagByName(userID uint, name string) (*friendentity.FriendTag, error) {
+	var t friendentity.FriendTag
+	err := r.db.Where("user_id = ? AND name = ?", userID, name).First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *friendRepositoryImpl) UpdateTag(t *friendentity.FriendTag) error { return r.db.Save(t).Error }
+
+func (r *friendRepositoryImpl) DeleteTag(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", id).Delete(&friendentity.FriendTagMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&friendentity.FriendTag{}, id).Error
+	})
+}
+
+func (r *friendRepositoryImpl) ListTags(userID uint) ([]*friendentity.FriendTag, error) {
+	var list []*friendentity.FriendTag
+	if err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// SetTagMembers 以 friendIDs 整体替换标签成员
+func (r *friendRepositoryImpl) SetTagMembers(tagID uint, friendIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", tagID).Delete(&friendentity.FriendTagMember{}).Error; err != nil {
+			return err
+		}
+		if len(friendIDs) == 0 {
+			return nil
+		}
+		rows := make([]friendentity.FriendTagMember, 0, len(friendIDs))
+		for _, id := range friendIDs {
+			rows = append(rows, friendentity.FriendTagMember{TagID: tagID, FriendID: id})
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+func (r *friendRepositoryImpl) ListTagMembers(tagIDs []uint) ([]*friendentity.FriendTagMember, error) {
+	var rows []*friendentity.FriendTagMember
+	if len(tagIDs) == 0 {
+		return rows, nil
+	}
+	if err := r.db.Where("tag_id IN ?", tagIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *friendRepositoryImpl) RemoveFromTags(userID, friendID uint) error {
+	return r.db.Where("friend_id = ? AND tag_id IN (?)", friendID,
+		r.db.Model(&friendentity.FriendTag{}).Select("id").Where("user_id = ?", userID)).
+		Delete(&friendentity.FriendTagMember{}).Error
+}
+
+// 推荐打分权重：一位共同好友计 2 分，一个共同群计 1 分
+const (
+	suggestMutualWeight = 2
+	suggestGroupWeight  = 1
+)
+
+// suggestionExclusions 推荐候选的排除条件：已是好友、任一方向拉黑、待处理或被拒绝的好友申请
+func suggestionExclusions(userCol, candCol string) string {
+	return fmt.Sprintf(`NOT EXISTS (SELECT 1 FROM app_friend_relations r WHERE r.user_id = %[1]s AND r.friend_id = %[2]s)
+	AND NOT EXISTS (SELECT 1 FROM app_user_blocks b WHERE (b.user_id = %[1]s AND b.blocked_id = %[2]s) OR (b.user_id = %[2]s AND b.blocked_id = %[1]s))
+	AND NOT EXISTS (SELECT 1 FROM app_friend_requests q WHERE q.status IN ('pending', 'declined')
+		AND ((q.requester_id = %[1]s AND q.addressee_id = %[2]s) OR (q.requester_id = %[2]s AND q.addressee_id = %[1]s)))`, userCol, candCol)
+}
+
+// suggestionSQL 为 @users 中每个用户计算前 @limit 个候选（二度好友 + 同群成员）
+var suggestionSQL = `
+WITH pairs AS (
+	SELECT f1.user_id, f2.friend_id AS candidate_id, COUNT(*) AS mutual_friends, 0 AS shared_groups
+	FROM app_friend_relations f1
+	JOIN app_friend_relations f2 ON f2.user_id = f1.friend_id
+	WHERE f1.user_id IN @users
+	GROUP BY f1.user_id, f2.friend_id
+	UNION ALL
+	SELECT m1.user_id, m2.user_id AS candidate_id, 0 AS mutual_friends, COUNT(DISTINCT m1.group_id) AS shared_groups
+	FROM app_chat_group_members m1
+	JOIN app_chat_group_members m2 ON m2.group_id = m1.group_id
+	WHERE m1.user_id IN @users
+	GROUP BY m1.user_id, m2.user_id
+), scored AS (
+	SELECT p.user_id, p.candidate_id,
+		SUM(p.mutual_friends) AS mutual_friends,
+		SUM(p.shared_groups) AS shared_groups,
+		SUM(p.mutual_friends) * ` + strconv.Itoa(suggestMutualWeight) + ` + SUM(p.shared_groups) * ` + strconv.Itoa(suggestGroupWeight) + ` AS score
+	FROM pairs p
+	JOIN app_users u ON u.id = p.candidate_id AND u.status = 'active'
+	WHERE p.candidate_id <> p.user_id AND ` + suggestionExclusions("p.user_id", "p.candidate_id") + `
+	GROUP BY p.user_id, p.candidate_id
+), ranked AS (
+	SELECT s.*, ROW_NUMBER() OVER (PARTITION BY s.user_id ORDER BY s.score DESC, s.candidate_id) AS rn
+	FROM scored s
+)
+SELECT user_id, candidate_id, mutual_friends, shared_groups, score FROM ranked WHERE rn <= @limit`
+
+func (r *friendRepositoryImpl) ComputeSuggestions(userID uint, limit int) ([]*friendentity.FriendSuggestion, error) {
+	var rows []*friendentity.FriendSuggestion
+	err := r.db.Raw(suggestionSQL+" ORDER BY score DESC, candidate_id", map[string]interface{}{"users": []uint{userID}, "limit": limit}).
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *friendRepositoryImpl) ListSuggestions(userID uint, offset, limit int) ([]*friendentity.FriendSuggestion, int64, error) {
+	q := r.db.Model(&friendentity.FriendSuggestion{}).
+		Joins("JOIN app_users u ON u.id = app_friend_suggestions.candidate_id AND u.status = ?", "active").
+		Where("app_friend_suggestions.user_id = ?", userID).
+		Where(suggestionExclusions("app_friend_suggestions.user_id", "app_friend_suggestions.candidate_id"))
 	var total int64
-	q := r.db.Model(&friendentity.FriendRequest{}).Where("addressee_id = ? AND status = ?", addresseeID, friendentity.FriendRequestPending)
 	if err := q.Count(&total).Error; err != nil {
-		return nil, nil, 0, err
+		return nil, 0, err
 	}
-	if err := q.Offset(offset).Limit(limit).Order("id DESC").Find(&list).Error; err != nil {
-		return nil, nil, 0, err
+	var rows []*friendentity.FriendSuggestion
+	if err := q.Select("app_friend_suggestions.*").
+		Order("app_friend_suggestions.score DESC, app_friend_suggestions.candidate_id").
+		Offset(offset).Limit(limit).Find(&rows).Error; err != nil {
+		return nil, 0, err
 	}
-	reqIDs := make([]uint, 0, len(list))
-	requesterIDs := make([]uint, 0, len(list))
-	for _, r := range list {
-		reqIDs = append(reqIDs, r.ID)
-		requesterIDs = append(requesterIDs, r.RequesterID)
+	return rows, total, nil
+}
+
+func (r *friendRepositoryImpl) RefreshSuggestions(batchSize, perUser int) (int, error) {
+	var lastID uint
+	processed := 0
+	for {
+		var ids []uint
+		if err := r.db.Table("app_users").Where("id > ? AND status = ?", lastID, "active").
+			Order("id").Limit(batchSize).Pluck("id", &ids).Error; err != nil {
+			return processed, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("user_id IN ?", ids).Delete(&friendentity.FriendSuggestion{}).Error; err != nil {
+				return err
+			}
+			return tx.Exec(`INSERT INTO app_friend_suggestions (user_id, candidate_id, mutual_friends, shared_groups, score, computed_at)
+				SELECT t.user_id, t.candidate_id, t.mutual_friends, t.shared_groups, t.score, NOW() FROM (`+suggestionSQL+`) t`,
+				map[string]interface{}{"users": ids, "limit": perUser}).Error
+		})
+		if err != nil {
+			return processed, err
+		}
+		processed += len(ids)
+		lastID = ids[len(ids)-1]
 	}
-	return reqIDs, requesterIDs, total, nil
+	// 已停用的用户不再保留推荐
+	err := r.db.Where("user_id NOT IN (?)", r.db.Table("app_users").Select("id").Where("status = ?", "active")).
+		Delete(&friendentity.FriendSuggestion{}).Error
+	return processed, err
 }
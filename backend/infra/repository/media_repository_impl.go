@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	appentity "alice/domain/appuser/entity"
+	chatentity "alice/domain/chat/entity"
+	mediaentity "alice/domain/media/entity"
+	mediarepo "alice/domain/media/repository"
+	momententity "alice/domain/moment/entity"
+)
+
+type mediaRepositoryImpl struct{ db *gorm.DB }
+
+func NewMediaRepository(db *gorm.DB) mediarepo.MediaRepository {
+	return &mediaRepositoryImpl{db: db}
+}
+
+func (r *mediaRepositoryImpl) Create(a *mediaentity.Attachment) error {
+	if a.Reservation == 0 {
+		return r.db.Create(a).Error
+	}
+	// 登记即计入用量，同一事务内释放上传时的预占
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(a).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&mediaentity.StorageReservation{}, a.Reservation).Error
+	})
+}
+
+func (r *mediaRepositoryImpl) Save(a *mediaentity.Attachment) error {
+	return r.db.Save(a).Error
+}
+
+func (r *mediaRepositoryImpl) SaveAttached(list []*mediaentity.Attachment, paths []string) error {
+	// 附件已关联而引用未增加时，对象会被当作孤儿回收，二者须一起提交
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, a := range list {
+			if err := tx.Save(a).Error; err != nil {
+				return err
+			}
+		}
+		return adjustBlobRefs(tx, paths, 1)
+	})
+}
+
+func (r *mediaRepositoryImpl) first(q *gorm.DB) (*mediaentity.Attachment, error) {
+	var a mediaentity.Attachment
+	err := q.Order("id DESC").First(&a).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *mediaRepositoryImpl) Get(id uint) (*mediaentity.Attachment, error) {
+	return r.first(r.db.Where("id = ?", id))
+}
+
+func (r *mediaRepositoryImpl) FindUnattached(ownerID uint, path string) (*mediaentity.Attachment, error) {
+	return r.first(r.db.Where("owner_id = ? AND path = ? AND ref_type = ''", ownerID, path))
+}
+
+func (r *mediaRepositoryImpl) FindOwned(ownerID uint, path string) (*mediaentity.Attachment, error) {
+	return r.first(r.db.Where("owner_id = ? AND path = ?", ownerID, path))
+}
+
+func (r *mediaRepositoryImpl) ListByPath(path string, limit int) ([]*mediaentity.Attachment, error) {
+	var list []*mediaentity.Attachment
+	err := r.db.Where("path = ? AND ref_type <> ''", path).Order("id DESC").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+func (r *mediaRepositoryImpl) ListByRefs(refType mediaentity.RefType, refIDs []uint) ([]*mediaentity.Attachment, error) {
+	if len(refIDs) == 0 {
+		return nil, nil
+	}
+	var list []*mediaentity.Attachment
+	err := r.db.Where("ref_type = ? AND ref_id IN ?", refType, refIDs).Order("ref_id, position").Find(&list).Error
+	return list, err
+}
+
+func (r *mediaRepositoryImpl) DeleteByRef(refType mediaentity.RefType, refID uint) error {
+	return r.db.Where("ref_type = ? AND ref_id = ?", refType, refID).Delete(&mediaentity.Attachment{}).Error
+}
+
+func (r *mediaRepositoryImpl) CreateSession(s *mediaentity.UploadSession) error {
+	return r.db.Create(s).Error
+}
+
+func (r *mediaRepositoryImpl) SaveSession(s *mediaentity.UploadSession) error {
+	return r.db.Save(s).Error
+}
+
+func (r *mediaRepositoryImpl) GetSession(id uint) (*mediaentity.UploadSession, error) {
+	var s mediaentity.UploadSession
+	err := r.db.First(&s, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *mediaRepositoryImpl) ListExpiredSessions(before time.Time, limit int) ([]*mediaentity.UploadSession, error) {
+	var list []*mediaentity.UploadSession
+	err := r.db.Where("status = ? AND expires_at < ?", mediaentity.UploadUploading, before).Order("id").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+func (r *mediaRepositoryImpl) CreateQuarantine(q *mediaentity.QuarantinedFile) error {
+	return r.db.Create(q).Error
+}
+
+func (r *mediaRepositoryImpl) TouchBlob(bucket, sha256 string) (*mediaentity.Blob, error) {
+	res := r.db.Model(&mediaentity.Blob{}).Where("bucket = ? AND sha256 = ?", bucket, sha256).Update("updated_at", time.Now())
+	if res.Error != nil || res.RowsAffected == 0 {
+		return nil, res.Error
+	}
+	var b mediaentity.Blob
+	if err := r.db.Where("bucket = ? AND sha256 = ?", bucket, sha256).First(&b).Error; err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *mediaRepositoryImpl) EnsureBlob(b *mediaentity.Blob) (*mediaentity.Blob, error) {
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(b).Error; err != nil {
+		return nil, err
+	}
+	got, err := r.TouchBlob(b.Bucket, b.SHA256)
+	if err == nil && got == nil {
+		err = gorm.ErrRecordNotFound
+	}
+	return got, err
+}
+
+func (r *mediaRepositoryImpl) AdjustBlobRefs(paths []string, delta int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return adjustBlobRefs(tx, paths, delta)
+	})
+}
+
+func adjustBlobRefs(db *gorm.DB, paths []string, delta int) error {
+	// 同一路径可能出现多次（如一条动态中重复的图片），按次数合并
+	counts := map[string]int{}
+	for _, p := range paths {
+		if p != "" {
+			counts[p] += delta
+		}
+	}
+	for p, d := range counts {
+		err := db.Model(&mediaentity.Blob{}).Where("path = ?", p).
+			Updates(map[string]any{"ref_count": gorm.Expr("GREATEST(ref_count + ?, 0)", d), "updated_at": time.Now()}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *mediaRepositoryImpl) ListOrphanBlobs(before time.Time, limit int) ([]*mediaentity.Blob, error) {
+	var list []*mediaentity.Blob
+	err := r.db.Where("ref_count = 0 AND updated_at < ?", before).Order("id").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+func (r *mediaRepositoryImpl) CollectBlob(id uint, before time.Time, fn func(b *mediaentity.Blob) error) (bool, error) {
+	collected := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 行锁与上传命中（TouchBlob）、引用增加互斥：二者要么先于锁刷新了条件，要么在回收提交后找不到记录
+		var b mediaentity.Blob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND ref_count = 0 AND updated_at < ?", id, before).First(&b).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(&b); err != nil {
+			return err
+		}
+		collected = true
+		return tx.Delete(&b).Error
+	})
+	return collected && err == nil, err
+}
+
+func (r *mediaRepositoryImpl) DeleteUnattachedByPath(path string) error {
+	return r.db.Where("path = ? AND ref_type = ''", path).Delete(&mediaentity.Attachment{}).Error
+}
+
+func (r *mediaRepositoryImpl) DeleteBlobByPath(path string) error {
+	return r.db.Where("path = ?", path).Delete(&mediaentity.Blob{}).Error
+}
+
+func (r *mediaRepositoryImpl) ListReferencedPaths(bucket string, paths, stems []string) ([]string, error) {
+	prefix := "/" + bucket + "/"
+	if len(paths) == 0 && len(stems) == 0 {
+		return nil, nil
+	}
+	sources := []struct {
+		model   any
+		columns []string
+		where   string
+	}{
+		{&mediaentity.Attachment{}, []string{"path", "thumb_path", "medium_path", "poster_path"}, ""},
+		{&mediaentity.Blob{}, []string{"path", "thumb_path", "medium_path"}, ""},
+		{&mediaentity.QuarantinedFile{}, []string{"path"}, ""},
+		{&appentity.AppUser{}, []string{"avatar"}, ""},
+		{&chatentity.Group{}, []string{"avatar"}, ""},
+		{&momententity.Moment{}, []string{"video"}, ""},
+		{&chatentity.Message{}, []string{"content"}, "type <> 'text'"},
+		{&chatentity.GroupMessage{}, []string{"content"}, "type <> 'text'"},
+	}
+	var out []string
+	for _, src := range sources {
+		for _, col := range src.columns {
+			q := r.db.Model(src.model).Where(matchPaths(col, paths, stems))
+			if src.where != "" {
+				q = q.Where(src.where)
+			}
+			var found []string
+			if err := q.Distinct().Pluck(col, &found).Error; err != nil {
+				return nil, err
+			}
+			out = append(out, found...)
+		}
+	}
+	// 动态图片为逗号分隔的列表：先按子串粗筛，再拆分逐项比对
+	var patterns []any
+	for _, p := range paths {
+		patterns = append(patterns, "%"+escapeLike(p)+"%")
+	}
+	for _, st := range stems {
+		patterns = append(patterns, "%"+escapeLike(st)+".%")
+	}
+	var images []string
+	if err := r.db.Model(&momententity.Moment{}).Where(orLike("images", len(patterns)), patterns...).Pluck("images", &images).Error; err != nil {
+		return nil, err
+	}
+	want := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		want[p] = true
+	}
+	stemSet := make(map[string]bool, len(stems))
+	for _, st := range stems {
+		stemSet[st] = true
+	}
+	for _, list := range images {
+		for _, p := range strings.Split(list, ",") {
+			p = strings.TrimSpace(p)
+			if want[p] || stemSet[strings.TrimSuffix(p, path.Ext(p))] {
+				out = append(out, p)
+			}
+		}
+	}
+	// 进行中的上传会话：直传对象在回调前已存在
+	objects := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if o, ok := strings.CutPrefix(p, prefix); ok {
+			objects = append(objects, o)
+		}
+	}
+	if len(objects) > 0 {
+		var sessions []string
+		err := r.db.Model(&mediaentity.UploadSession{}).Where("bucket = ? AND status = ? AND object IN ?", bucket, mediaentity.UploadUploading, objects).
+			Pluck("object", &sessions).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range sessions {
+			out = append(out, prefix+o)
+		}
+	}
+	return out, nil
+}
+
+// matchPaths 列等于 paths 之一，或形如 {stem}.{ext} 且 stem 属于 stems
+func matchPaths(col string, paths, stems []string) clause.Expression {
+	var exprs []clause.Expression
+	if len(paths) > 0 {
+		exprs = append(exprs, clause.Expr{SQL: col + " IN ?", Vars: []any{paths}})
+	}
+	if len(stems) > 0 {
+		vars := make([]any, len(stems))
+		for i, st := range stems {
+			vars[i] = escapeLike(st) + ".%"
+		}
+		exprs = append(exprs, clause.Expr{SQL: orLike(col, len(stems)), Vars: vars})
+	}
+	return clause.Or(exprs...)
+}
+
+// orLike n 个 LIKE 条件的析取：(col LIKE ? OR col LIKE ? ...)
+func orLike(col string, n int) string {
+	if n == 0 {
+		return "1 = 0"
+	}
+	return "(" + strings.TrimSuffix(strings.Repeat(col+" LIKE ? OR ", n), " OR ") + ")"
+}
+
+// escapeLike 转义 LIKE 通配符（PostgreSQL 默认转义符为反斜杠）
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (r *mediaRepositoryImpl) UsageByOwner(ownerID uint) (map[string]int64, error) {
+	return usageByOwner(r.db, ownerID)
+}
+
+func usageByOwner(db *gorm.DB, ownerID uint) (map[string]int64, error) {
+	var rows []struct {
+		Kind  string
+		Bytes int64
+	}
+	err := db.Model(&mediaentity.Attachment{}).Select("kind, COALESCE(SUM(size), 0) AS bytes").
+		Where("owner_id = ?", ownerID).Group("kind").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	usage := make(map[string]int64, len(rows)+1)
+	for _, row := range rows {
+		usage[row.Kind] = row.Bytes
+	}
+	// 头像不登记附件，按当前头像对应的内容寻址对象计算
+	var avatar int64
+	err = db.Model(&mediaentity.Blob{}).Select("COALESCE(SUM(app_media_blobs.size), 0)").
+		Joins("JOIN app_users ON app_users.avatar = app_media_blobs.path").Where("app_users.id = ?", ownerID).Scan(&avatar).Error
+	if err != nil {
+		return nil, err
+	}
+	usage["avatar"] = avatar
+	return usage, nil
+}
+
+func (r *mediaRepositoryImpl) GetQuota(userID uint) (*mediaentity.StorageQuota, error) {
+	var q mediaentity.StorageQuota
+	err := r.db.Where("user_id = ?", userID).First(&q).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+func (r *mediaRepositoryImpl) SaveQuota(q *mediaentity.StorageQuota) error {
+	return r.db.Save(q).Error
+}
+
+func (r *mediaRepositoryImpl) ReserveQuota(userID, replace uint, res *mediaentity.StorageReservation, fn func(q *mediaentity.StorageQuota, used map[string]int64) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// 配额记录兼作用户级的锁：先确保存在再加行锁，并发的预占在此排队
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&mediaentity.StorageQuota{UserID: userID}).Error; err != nil {
+			return err
+		}
+		var q mediaentity.StorageQuota
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&q).Error; err != nil {
+			return err
+		}
+		// 顺带清理过期的预占；replace 在 fn 失败时随事务回滚
+		if err := tx.Where("user_id = ? AND (expires_at < ? OR id = ?)", userID, time.Now(), replace).Delete(&mediaentity.StorageReservation{}).Error; err != nil {
+			return err
+		}
+		// 先读预占再读附件：登记附件与释放预占在同一事务内提交，按此顺序读取至多重复计入，不会两头遗漏
+		var rows []struct {
+			Category string
+			Bytes    int64
+		}
+		err := tx.Model(&mediaentity.StorageReservation{}).Select("category, COALESCE(SUM(size), 0) AS bytes").
+			Where("user_id = ?", userID).Group("category").Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+		used, err := usageByOwner(tx, userID)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			used[row.Category] += row.Bytes
+		}
+		if err := fn(&q, used); err != nil {
+			return err
+		}
+		if res == nil {
+			return nil
+		}
+		return tx.Create(res).Error
+	})
+}
+
+func (r *mediaRepositoryImpl) DeleteReservation(id uint) error {
+	return r.db.Delete(&mediaentity.StorageReservation{}, id).Error
+}
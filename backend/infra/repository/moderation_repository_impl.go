@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	modentity "alice/domain/moderation/entity"
+	modrepo "alice/domain/moderation/repository"
+)
+
+type moderationRepositoryImpl struct{ db *gorm.DB }
+
+func NewModerationRepository(db *gorm.DB) modrepo.ModerationRepository {
+	return &moderationRepositoryImpl{db: db}
+}
+
+func (r *moderationRepositoryImpl) ListEnabledWords() ([]*modentity.SensitiveWord, error) {
+	var list []*modentity.SensitiveWord
+	if err := r.db.Where("enabled = ?", true).Order("id ASC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *moderationRepositoryImpl) ListWords(keyword string, offset, limit int) ([]*modentity.SensitiveWord, int64, error) {
+	var list []*modentity.SensitiveWord
+	var total int64
+	q := r.db.Model(&modentity.SensitiveWord{})
+	if keyword != "" {
+		q = q.Where("word ILIKE ?", "%"+keyword+"%")
+	}
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// CreateWords 批量创建，已存在的词更新动作/分类
+func (r *moderationRepositoryImpl) CreateWords(words []*modentity.SensitiveWord) error {
+	if len(words) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "word"}},
+		DoUpdates: clause.AssignmentColumns([]string{"action", "category", "enabled", "updated_at"}),
+	}).Create(&words).Error
+}
+
+func (r *moderationRepositoryImpl) UpdateWord(w *modentity.SensitiveWord) error {
+	return r.db.Save(w).Error
+}
+
+func (r *moderationRepositoryImpl) GetWord(id uint) (*modentity.SensitiveWord, error) {
+	var w modentity.SensitiveWord
+	if err := r.db.First(&w, id).Error; err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *moderationRepositoryImpl) DeleteWord(id uint) error {
+	return r.db.Delete(&modentity.SensitiveWord{}, id).Error
+}
+
+func (r *moderationRepositoryImpl) CreateFlag(f *modentity.FlaggedContent) error {
+	return r.db.Create(f).Error
+}
+
+func (r *moderationRepositoryImpl) GetFlag(id uint) (*modentity.FlaggedContent, error) {
+	var f modentity.FlaggedContent
+	if err := r.db.First(&f, id).Error; err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *moderationRepositoryImpl) ListFlags(status modentity.FlagStatus, scene modentity.Scene, offset, limit int) ([]*modentity.FlaggedContent, int64, error) {
+	var list []*modentity.FlaggedContent
+	var total int64
+	q := r.db.Model(&modentity.FlaggedContent{})
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if scene != "" {
+		q = q.Where("scene = ?", scene)
+	}
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+func (r *moderationRepositoryImpl) UpdateFlag(f *modentity.FlaggedContent) error {
+	return r.db.Save(f).Error
+}
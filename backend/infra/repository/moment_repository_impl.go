@@ -3,9 +3,12 @@ package repository
 import (
 	momententity "alice/domain/moment/entity"
 	momentrepo "alice/domain/moment/repository"
+	"database/sql"
 	"errors"
+	"math"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type momentRepositoryImpl struct{ db *gorm.DB }
@@ -14,32 +17,72 @@ func NewMomentRepository(db *gorm.DB) momentrepo.MomentRepository {
 	return &momentRepositoryImpl{db: db}
 }
 
-func (r *momentRepositoryImpl) Create(m *momententity.Moment) error { return r.db.Create(m).Error }
+func (r *momentRepositoryImpl) Create(m *momententity.Moment, audience []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+		if len(audience) == 0 {
+			return nil
+		}
+		rows := make([]momententity.MomentAudience, 0, len(audience))
+		for _, uid := range audience {
+			rows = append(rows, momententity.MomentAudience{MomentID: m.ID, UserID: uid})
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+// visibleClause viewerID 视角下动态可见的 SQL 条件（作者本人始终可见）
+const visibleClause = `(app_moments.user_id = @viewer OR app_moments.visibility = 'public' OR (
+	app_moments.visibility IN ('friends', 'include', 'exclude')
+	AND EXISTS (SELECT 1 FROM app_friend_relations f WHERE f.user_id = app_moments.user_id AND f.friend_id = @viewer)
+	AND (app_moments.visibility = 'friends'
+		OR (app_moments.visibility = 'include' AND EXISTS (SELECT 1 FROM app_moment_audiences a WHERE a.moment_id = app_moments.id AND a.user_id = @viewer))
+		OR (app_moments.visibility = 'exclude' AND NOT EXISTS (SELECT 1 FROM app_moment_audiences a WHERE a.moment_id = app_moments.id AND a.user_id = @viewer)))))`
 
-func (r *momentRepositoryImpl) ListAll(offset, limit int) ([]*momententity.Moment, int64, error) {
+func (r *momentRepositoryImpl) ListAll(viewerID uint, excludeUserIDs []uint, offset, limit int) ([]*momententity.Moment, int64, error) {
 	var list []*momententity.Moment
 	var total int64
-	if err := r.db.Model(&momententity.Moment{}).Count(&total).Error; err != nil {
+	q := r.db.Model(&momententity.Moment{}).Where(visibleClause, sql.Named("viewer", viewerID))
+	if len(excludeUserIDs) > 0 {
+		q = q.Where("app_moments.user_id NOT IN ?", excludeUserIDs)
+	}
+	if err := q.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
-	if err := r.db.Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+	if err := q.Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
 		return nil, 0, err
 	}
 	return list, total, nil
 }
 
-func (r *momentRepositoryImpl) ListByUser(userID uint, offset, limit int) ([]*momententity.Moment, int64, error) {
+func (r *momentRepositoryImpl) ListByUser(viewerID, userID uint, offset, limit int) ([]*momententity.Moment, int64, error) {
 	var list []*momententity.Moment
 	var total int64
-	if err := r.db.Model(&momententity.Moment{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+	q := r.db.Model(&momententity.Moment{}).Where("app_moments.user_id = ?", userID).Where(visibleClause, sql.Named("viewer", viewerID))
+	if err := q.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
-	if err := r.db.Where("user_id = ?", userID).Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+	if err := q.Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
 		return nil, 0, err
 	}
 	return list, total, nil
 }
 
+func (r *momentRepositoryImpl) CanView(m *momententity.Moment, viewerID uint) (bool, error) {
+	var cnt int64
+	err := r.db.Model(&momententity.Moment{}).Where("app_moments.id = ?", m.ID).
+		Where(visibleClause, sql.Named("viewer", viewerID)).Count(&cnt).Error
+	return cnt > 0, err
+}
+
+func (r *momentRepositoryImpl) ListAudience(momentID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&momententity.MomentAudience{}).Where("moment_id = ?", momentID).Order("user_id").Pluck("user_id", &ids).Error
+	return ids, err
+}
+
 func (r *momentRepositoryImpl) Get(id uint) (*momententity.Moment, error) {
 	var m momententity.Moment
 	if err := r.db.First(&m, id).Error; err != nil {
@@ -49,7 +92,16 @@ func (r *momentRepositoryImpl) Get(id uint) (*momententity.Moment, error) {
 }
 
 func (r *momentRepositoryImpl) Delete(id uint, userID uint) error {
-	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&momententity.Moment{}).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&momententity.Moment{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		if err := tx.Where("moment_id = ?", id).Delete(&momententity.TimelineEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("moment_id = ?", id).Delete(&momententity.MomentAudience{}).Error
+	})
 }
 
 // Likes
@@ -86,14 +138,95 @@ func (r *momentRepositoryImpl) AddComment(cmt *momententity.MomentComment) error
 	return r.db.Create(cmt).Error
 }
 
-func (r *momentRepositoryImpl) ListComments(momentID uint, offset, limit int) ([]*momententity.MomentComment, int64, error) {
+// commentVisibleClause 评论可见性（与微信一致）：动态作者看全部；其他人只看自己与好友的评论
+const commentVisibleClause = `(@viewer = @owner OR app_moment_comments.user_id = @viewer
+	OR EXISTS (SELECT 1 FROM app_friend_relations f WHERE f.user_id = @viewer AND f.friend_id = app_moment_comments.user_id))`
+
+func (r *momentRepositoryImpl) listComments(q *gorm.DB, ownerID, viewerID uint, offset, limit int) ([]*momententity.MomentComment, int64, error) {
 	var list []*momententity.MomentComment
 	var total int64
-	if err := r.db.Model(&momententity.MomentComment{}).Where("moment_id = ?", momentID).Count(&total).Error; err != nil {
+	q = q.Where(commentVisibleClause, sql.Named("viewer", viewerID), sql.Named("owner", ownerID))
+	if err := q.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
-	if err := r.db.Where("moment_id = ?", momentID).Order("id ASC").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+	if err := q.Order("id ASC").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
 		return nil, 0, err
 	}
 	return list, total, nil
 }
+
+func (r *momentRepositoryImpl) ListComments(momentID, ownerID, viewerID uint, offset, limit int) ([]*momententity.MomentComment, int64, error) {
+	q := r.db.Model(&momententity.MomentComment{}).Where("moment_id = ? AND parent_id = 0", momentID)
+	return r.listComments(q, ownerID, viewerID, offset, limit)
+}
+
+func (r *momentRepositoryImpl) ListReplies(parentID, ownerID, viewerID uint, offset, limit int) ([]*momententity.MomentComment, int64, error) {
+	q := r.db.Model(&momententity.MomentComment{}).Where("parent_id = ?", parentID)
+	return r.listComments(q, ownerID, viewerID, offset, limit)
+}
+
+func (r *momentRepositoryImpl) GetComment(id uint) (*momententity.MomentComment, error) {
+	var cmt momententity.MomentComment
+	if err := r.db.First(&cmt, id).Error; err != nil {
+		return nil, err
+	}
+	return &cmt, nil
+}
+
+func (r *momentRepositoryImpl) DeleteComment(id uint) error {
+	return r.db.Where("id = ? OR parent_id = ?", id, id).Delete(&momententity.MomentComment{}).Error
+}
+
+// Timeline
+
+func (r *momentRepositoryImpl) FanOut(m *momententity.Moment, userIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(userIDs) > 0 {
+			rows := make([]momententity.TimelineEntry, 0, len(userIDs))
+			for _, uid := range userIDs {
+				rows = append(rows, momententity.TimelineEntry{UserID: uid, MomentID: m.ID, AuthorID: m.UserID, CreatedAt: m.CreatedAt})
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&rows, 500).Error; err != nil {
+				return err
+			}
+		}
+		m.FannedOut = true
+		return tx.Model(&momententity.Moment{}).Where("id = ?", m.ID).Update("fanned_out", true).Error
+	})
+}
+
+func (r *momentRepositoryImpl) Timeline(viewerID uint, excludeUserIDs []uint, beforeID uint, limit int) ([]*momententity.Moment, error) {
+	if beforeID == 0 {
+		beforeID = math.MaxInt32
+	}
+	// 写扩散部分 + 读扩散兜底（自己与好友未写扩散的动态）
+	ids := r.db.Raw(`SELECT moment_id FROM app_moment_timelines WHERE user_id = @viewer AND moment_id < @before
+		UNION
+		SELECT id FROM app_moments WHERE fanned_out = FALSE AND id < @before
+			AND (user_id = @viewer OR user_id IN (SELECT friend_id FROM app_friend_relations WHERE user_id = @viewer))`,
+		sql.Named("viewer", viewerID), sql.Named("before", beforeID))
+	// 读取时再次校验可见范围，发布后修改的好友关系/名单即时生效
+	q := r.db.Model(&momententity.Moment{}).
+		Where("app_moments.id IN (?)", ids).
+		Where(visibleClause, sql.Named("viewer", viewerID))
+	if len(excludeUserIDs) > 0 {
+		q = q.Where("app_moments.user_id NOT IN ?", excludeUserIDs)
+	}
+	var list []*momententity.Moment
+	if err := q.Order("app_moments.id DESC").Limit(limit).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *momentRepositoryImpl) BackfillTimeline(userID, authorID uint, limit int) error {
+	return r.db.Exec(`INSERT INTO app_moment_timelines (user_id, moment_id, author_id, created_at)
+		SELECT ?, id, user_id, created_at FROM app_moments
+		WHERE user_id = ? AND fanned_out = TRUE
+		ORDER BY id DESC LIMIT ?
+		ON CONFLICT DO NOTHING`, userID, authorID, limit).Error
+}
+
+func (r *momentRepositoryImpl) RemoveFromTimeline(userID, authorID uint) error {
+	return r.db.Where("user_id = ? AND author_id = ?", userID, authorID).Delete(&momententity.TimelineEntry{}).Error
+}
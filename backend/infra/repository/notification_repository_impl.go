@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	notifyentity "alice/domain/notification/entity"
+	notifyrepo "alice/domain/notification/repository"
+)
+
+type notificationRepositoryImpl struct{ db *gorm.DB }
+
+func NewNotificationRepository(db *gorm.DB) notifyrepo.NotificationRepository {
+	return &notificationRepositoryImpl{db: db}
+}
+
+func (r *notificationRepositoryImpl) Create(n *notifyentity.Notification) error {
+	return r.db.Create(n).Error
+}
+
+func (r *notificationRepositoryImpl) List(userID uint, unreadOnly bool, offset, limit int) ([]*notifyentity.Notification, int64, error) {
+	q := r.db.Model(&notifyentity.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		q = q.Where("is_read = ?", false)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []*notifyentity.Notification
+	if err := q.Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+func (r *notificationRepositoryImpl) ListGroups(userID uint, offset, limit int) ([]*notifyentity.Group, int64, error) {
+	sub := r.db.Model(&notifyentity.Notification{}).
+		Select(`type, target_id,
+			COUNT(DISTINCT actor_id) AS actor_count,
+			SUM(CASE WHEN is_read THEN 0 ELSE 1 END) AS unread,
+			MAX(id) AS last_id,
+			MAX(created_at) AS last_at`).
+		Where("user_id = ?", userID).
+		Group("type, target_id")
+	var total int64
+	if err := r.db.Table("(?) AS g", sub).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []*notifyentity.Group
+	if err := r.db.Table("(?) AS g", sub).Order("last_id DESC").Offset(offset).Limit(limit).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+func (r *notificationRepositoryImpl) ListGroupActors(userID uint, typ notifyentity.Type, targetID uint, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&notifyentity.Notification{}).
+		Where("user_id = ? AND type = ? AND target_id = ?", userID, typ, targetID).
+		Group("actor_id").Order("MAX(id) DESC").Limit(limit).
+		Pluck("actor_id", &ids).Error
+	return ids, err
+}
+
+func (r *notificationRepositoryImpl) CountUnread(userID uint) (int64, error) {
+	var n int64
+	err := r.db.Model(&notifyentity.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&n).Error
+	return n, err
+}
+
+func (r *notificationRepositoryImpl) markRead(q *gorm.DB) error {
+	now := time.Now()
+	return q.Model(&notifyentity.Notification{}).Where("is_read = ?", false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": &now}).Error
+}
+
+func (r *notificationRepositoryImpl) MarkRead(userID uint, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.markRead(r.db.Where("user_id = ? AND id IN ?", userID, ids))
+}
+
+func (r *notificationRepositoryImpl) MarkGroupRead(userID uint, typ notifyentity.Type, targetID uint) error {
+	return r.markRead(r.db.Where("user_id = ? AND type = ? AND target_id = ?", userID, typ, targetID))
+}
+
+func (r *notificationRepositoryImpl) MarkAllRead(userID uint) error {
+	return r.markRead(r.db.Where("user_id = ?", userID))
+}
+
+func (r *notificationRepositoryImpl) ListPreferences(userID uint) ([]*notifyentity.Preference, error) {
+	var list []*notifyentity.Preference
+	err := r.db.Where("user_id = ?", userID).Find(&list).Error
+	return list, err
+}
+
+func (r *notificationRepositoryImpl) SavePreference(p *notifyentity.Preference) error {
+	return r.db.Save(p).Error
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	pushentity "alice/domain/push/entity"
+	pushrepo "alice/domain/push/repository"
+)
+
+type pushRepositoryImpl struct{ db *gorm.DB }
+
+func NewPushRepository(db *gorm.DB) pushrepo.PushRepository {
+	return &pushRepositoryImpl{db: db}
+}
+
+func (r *pushRepositoryImpl) SaveDevice(d *pushentity.DeviceToken) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "updated_at"}),
+	}).Create(d).Error
+}
+
+func (r *pushRepositoryImpl) DeleteDevice(userID uint, token string) error {
+	return r.db.Where("user_id = ? AND token = ?", userID, token).Delete(&pushentity.DeviceToken{}).Error
+}
+
+func (r *pushRepositoryImpl) DeleteToken(token string) error {
+	return r.db.Where("token = ?", token).Delete(&pushentity.DeviceToken{}).Error
+}
+
+func (r *pushRepositoryImpl) ListDevices(userID uint) ([]*pushentity.DeviceToken, error) {
+	var list []*pushentity.DeviceToken
+	err := r.db.Where("user_id = ?", userID).Order("updated_at DESC").Find(&list).Error
+	return list, err
+}
+
+func (r *pushRepositoryImpl) SaveMute(m *pushentity.MuteSetting) error {
+	return r.db.Save(m).Error
+}
+
+func (r *pushRepositoryImpl) DeleteMute(userID uint, targetType pushentity.MuteTarget, targetID uint) error {
+	return r.db.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).Delete(&pushentity.MuteSetting{}).Error
+}
+
+func (r *pushRepositoryImpl) ListMutes(userID uint) ([]*pushentity.MuteSetting, error) {
+	var list []*pushentity.MuteSetting
+	err := r.db.Where("user_id = ? AND (until IS NULL OR until > NOW())", userID).Order("created_at DESC").Find(&list).Error
+	return list, err
+}
+
+func (r *pushRepositoryImpl) GetMute(userID uint, targetType pushentity.MuteTarget, targetID uint) (*pushentity.MuteSetting, error) {
+	var m pushentity.MuteSetting
+	err := r.db.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// badgeSQL 免打扰的私聊/群聊不计入角标；群聊不计自己发的消息
+const badgeSQL = `SELECT
+	(SELECT COUNT(*) FROM app_chat_messages m
+		WHERE m.receiver_id = @user AND m.is_read = FALSE AND m.dropped = FALSE
+		AND NOT EXISTS (SELECT 1 FROM app_push_mutes pm WHERE pm.user_id = @user AND pm.target_type = 'user'
+			AND pm.target_id = m.sender_id AND (pm.until IS NULL OR pm.until > NOW())))
+	+ (SELECT COUNT(*) FROM app_chat_group_messages gm
+		JOIN app_chat_group_members mb ON mb.group_id = gm.group_id AND mb.user_id = @user
+		LEFT JOIN app_chat_group_read_cursors c ON c.group_id = gm.group_id AND c.user_id = @user
+		WHERE gm.id > COALESCE(c.last_read_msg_id, 0) AND gm.sender_id <> @user
+		AND NOT EXISTS (SELECT 1 FROM app_push_mutes pm WHERE pm.user_id = @user AND pm.target_type = 'group'
+			AND pm.target_id = gm.group_id AND (pm.until IS NULL OR pm.until > NOW())))
+	+ (SELECT COUNT(*) FROM app_notifications n WHERE n.user_id = @user AND n.is_read = FALSE)`
+
+func (r *pushRepositoryImpl) UnreadBadge(userID uint) (int64, error) {
+	var n int64
+	err := r.db.Raw(badgeSQL, sql.Named("user", userID)).Scan(&n).Error
+	return n, err
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	modentity "alice/domain/moderation/entity"
+	modrepo "alice/domain/moderation/repository"
+)
+
+type reportRepositoryImpl struct{ db *gorm.DB }
+
+func NewReportRepository(db *gorm.DB) modrepo.ReportRepository {
+	return &reportRepositoryImpl{db: db}
+}
+
+func (r *reportRepositoryImpl) Create(rep *modentity.Report) error { return r.db.Create(rep).Error }
+
+func (r *reportRepositoryImpl) Get(id uint) (*modentity.Report, error) {
+	var rep modentity.Report
+	if err := r.db.First(&rep, id).Error; err != nil {
+		return nil, err
+	}
+	return &rep, nil
+}
+
+func (r *reportRepositoryImpl) Update(rep *modentity.Report) error { return r.db.Save(rep).Error }
+
+func (r *reportRepositoryImpl) List(status modentity.ReportStatus, targetType modentity.ReportTargetType, offset, limit int) ([]*modentity.Report, int64, error) {
+	var list []*modentity.Report
+	var total int64
+	q := r.db.Model(&modentity.Report{})
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if targetType != "" {
+		q = q.Where("target_type = ?", targetType)
+	}
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+func (r *reportRepositoryImpl) FindOpen(reporterID uint, targetType modentity.ReportTargetType, targetID uint) (*modentity.Report, error) {
+	var rep modentity.Report
+	err := r.db.Where("reporter_id = ? AND target_type = ? AND target_id = ? AND status IN ?",
+		reporterID, targetType, targetID, []modentity.ReportStatus{modentity.ReportStatusPending, modentity.ReportStatusTriaged}).
+		First(&rep).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rep, nil
+}
+
+func (r *reportRepositoryImpl) CreateAudit(l *modentity.AuditLog) error { return r.db.Create(l).Error }
+
+func (r *reportRepositoryImpl) ListAudits(operatorID uint, action string, offset, limit int) ([]*modentity.AuditLog, int64, error) {
+	var list []*modentity.AuditLog
+	var total int64
+	q := r.db.Model(&modentity.AuditLog{})
+	if operatorID != 0 {
+		q = q.Where("operator_id = ?", operatorID)
+	}
+	if action != "" {
+		q = q.Where("action = ?", action)
+	}
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrLinkExpired   = errors.New("signed link expired")
+	ErrLinkSignature = errors.New("signed link signature mismatch")
+)
+
+// 表单直传（POST）的字段名，与 S3 POST policy 保持相近
+const (
+	FormKey         = "key"
+	FormContentType = "Content-Type"
+	FormMaxSize     = "x-max-size"
+	FormExpires     = "x-expires"
+	FormSignature   = "x-signature"
+)
+
+// LinkServer 由本服务 /files 路由对外提供对象访问的存储（local / memory）
+type LinkServer interface {
+	Links() *LinkSigner
+	// IsPublic bucket 是否公共读，私有 bucket 的读取须携带有效签名
+	IsPublic(bucket string) bool
+}
+
+// LinkSigner 生成与校验 HMAC 签名的对象链接，替代对象存储的预签名 URL。
+// 链接形如 {base}/{bucket}/{object}?x-expires=...&x-signature=...，约束（类型、大小）作为查询参数参与签名
+type LinkSigner struct {
+	base   string
+	secret []byte
+}
+
+func NewLinkSigner(base string, secret []byte) *LinkSigner {
+	return &LinkSigner{base: strings.TrimRight(base, "/"), secret: secret}
+}
+
+// Base 对象访问基础地址，公共读对象的 URL 为 {base}/{bucket}/{object}
+func (s *LinkSigner) Base() string { return s.base }
+
+// Sign 签发有效期 expiry 的链接；constraints 为需要校验的请求约束（如 content-type、content-length）
+func (s *LinkSigner) Sign(method, bucket, objectName string, expiry time.Duration, constraints url.Values) string {
+	q := url.Values{}
+	for k, v := range constraints {
+		q[k] = v
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	q.Set(FormExpires, expires)
+	q.Set(FormSignature, s.sign(method, bucket, objectName, expires, constraints))
+	return s.base + "/" + bucket + "/" + escapeObject(objectName) + "?" + q.Encode()
+}
+
+// Verify 校验链接签名与有效期，返回参与签名的约束
+func (s *LinkSigner) Verify(method, bucket, objectName string, q url.Values) (url.Values, error) {
+	constraints := url.Values{}
+	for k, v := range q {
+		if k != FormExpires && k != FormSignature {
+			constraints[k] = v
+		}
+	}
+	if err := s.check(method, bucket, objectName, q.Get(FormExpires), q.Get(FormSignature), constraints); err != nil {
+		return nil, err
+	}
+	return constraints, nil
+}
+
+// PostForm 表单直传：返回提交地址与需原样提交的字段，限定对象名、类型与大小上限
+func (s *LinkSigner) PostForm(bucket, objectName, contentType string, maxSize int64, expiry time.Duration) (string, map[string]string) {
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	size := strconv.FormatInt(maxSize, 10)
+	form := map[string]string{FormKey: objectName, FormContentType: contentType, FormMaxSize: size, FormExpires: expires}
+	form[FormSignature] = s.sign("POST", bucket, objectName, expires, postConstraints(contentType, size))
+	return s.base + "/" + bucket, form
+}
+
+// VerifyPost 校验表单直传字段，返回对象名、类型与大小上限
+func (s *LinkSigner) VerifyPost(bucket string, form map[string]string) (string, string, int64, error) {
+	key, ct, size := form[FormKey], form[FormContentType], form[FormMaxSize]
+	if err := s.check("POST", bucket, key, form[FormExpires], form[FormSignature], postConstraints(ct, size)); err != nil {
+		return "", "", 0, err
+	}
+	max, err := strconv.ParseInt(size, 10, 64)
+	if err != nil {
+		return "", "", 0, ErrLinkSignature
+	}
+	return key, ct, max, nil
+}
+
+func postConstraints(contentType, maxSize string) url.Values {
+	return url.Values{"content-type": {contentType}, "max-size": {maxSize}}
+}
+
+func (s *LinkSigner) check(method, bucket, objectName, expires, sig string, constraints url.Values) error {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || sig == "" {
+		return ErrLinkSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.sign(method, bucket, objectName, expires, constraints))) {
+		return ErrLinkSignature
+	}
+	if time.Now().Unix() > exp {
+		return ErrLinkExpired
+	}
+	return nil
+}
+
+// sign 签名串：方法、bucket、对象名、过期时间与按键排序的约束，逐行拼接
+func (s *LinkSigner) sign(method, bucket, objectName, expires string, constraints url.Values) string {
+	keys := make([]string, 0, len(constraints))
+	for k := range constraints {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(method + "\n" + bucket + "\n" + objectName + "\n" + expires))
+	for _, k := range keys {
+		mac.Write([]byte("\n" + k + "=" + strings.Join(constraints[k], ",")))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// escapeObject 按路径段转义对象名，保留分隔符 /
+func escapeObject(objectName string) string {
+	segs := strings.Split(objectName, "/")
+	for i, seg := range segs {
+		segs[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segs, "/")
+}
+
+// listKeys 按 S3 语义从有序的对象名中筛选前缀；非递归时下一级“目录”折叠为以 / 结尾的前缀
+func listKeys(sorted []string, prefix string, recursive bool, limit int) []string {
+	var res []string
+	last := ""
+	for _, k := range sorted {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if !recursive {
+			if i := strings.IndexByte(k[len(prefix):], '/'); i >= 0 {
+				k = k[:len(prefix)+i+1]
+				if k == last {
+					continue
+				}
+			}
+		}
+		last = k
+		res = append(res, k)
+		if limit > 0 && len(res) >= limit {
+			break
+		}
+	}
+	return res
+}
+
+// pageKeys 在 listKeys 的基础上分页：跳过不大于 token 的项，返回至多 limit 项（对象与折叠的“目录”合计），还有更多时 next 为本页最后一项
+func pageKeys(sorted []string, prefix string, recursive bool, token string, limit int) (keys, prefixes []string, next string) {
+	n := 0
+	for _, k := range listKeys(sorted, prefix, recursive, 0) {
+		if k <= token {
+			continue
+		}
+		if limit > 0 && n >= limit {
+			return keys, prefixes, next
+		}
+		if !recursive && strings.HasSuffix(k, "/") {
+			prefixes = append(prefixes, k)
+		} else {
+			keys = append(keys, k)
+		}
+		n++
+		next = k
+	}
+	return keys, prefixes, ""
+}
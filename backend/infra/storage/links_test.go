@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// query 取签发链接的查询参数
+func query(t *testing.T, link string) url.Values {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query()
+}
+
+func TestLinkSignerVerify(t *testing.T) {
+	s := NewLinkSigner("http://localhost:8090/files/", []byte("secret"))
+	constraints := url.Values{"content-type": {"image/png"}, "content-length": {"42"}}
+
+	tests := []struct {
+		name    string
+		method  string
+		object  string
+		expiry  time.Duration
+		tamper  func(q url.Values)
+		signer  *LinkSigner
+		wantErr error
+	}{
+		{name: "valid", method: "PUT", object: "a/b c.png", expiry: time.Minute},
+		{name: "other method", method: "GET", object: "a/b c.png", expiry: time.Minute, wantErr: ErrLinkSignature},
+		{name: "other object", method: "PUT", object: "a/other.png", expiry: time.Minute, wantErr: ErrLinkSignature},
+		{name: "tampered content type", method: "PUT", object: "a/b c.png", expiry: time.Minute,
+			tamper: func(q url.Values) { q.Set("content-type", "text/html") }, wantErr: ErrLinkSignature},
+		{name: "dropped constraint", method: "PUT", object: "a/b c.png", expiry: time.Minute,
+			tamper: func(q url.Values) { q.Del("content-length") }, wantErr: ErrLinkSignature},
+		{name: "added constraint", method: "PUT", object: "a/b c.png", expiry: time.Minute,
+			tamper: func(q url.Values) { q.Set("x-extra", "1") }, wantErr: ErrLinkSignature},
+		{name: "extended expiry", method: "PUT", object: "a/b c.png", expiry: time.Minute,
+			tamper: func(q url.Values) { q.Set(FormExpires, "9999999999") }, wantErr: ErrLinkSignature},
+		{name: "missing signature", method: "PUT", object: "a/b c.png", expiry: time.Minute,
+			tamper: func(q url.Values) { q.Del(FormSignature) }, wantErr: ErrLinkSignature},
+		{name: "other secret", method: "PUT", object: "a/b c.png", expiry: time.Minute,
+			signer: NewLinkSigner("http://localhost:8090/files", []byte("other")), wantErr: ErrLinkSignature},
+		{name: "expired", method: "PUT", object: "a/b c.png", expiry: -time.Minute, wantErr: ErrLinkExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := query(t, s.Sign("PUT", "bucket", "a/b c.png", tt.expiry, constraints))
+			if tt.tamper != nil {
+				tt.tamper(q)
+			}
+			verifier := s
+			if tt.signer != nil {
+				verifier = tt.signer
+			}
+			got, err := verifier.Verify(tt.method, "bucket", tt.object, q)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, constraints) {
+				t.Errorf("constraints = %v, want %v", got, constraints)
+			}
+		})
+	}
+}
+
+func TestLinkSignerSignURL(t *testing.T) {
+	s := NewLinkSigner("http://localhost:8090/files/", []byte("secret"))
+	u, err := url.Parse(s.Sign("GET", "bucket", "dir/a b#c.png", time.Minute, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != "/files/bucket/dir/a b#c.png" {
+		t.Errorf("path = %q", u.Path)
+	}
+	if _, err := s.Verify("GET", "bucket", "dir/a b#c.png", u.Query()); err != nil {
+		t.Errorf("Verify = %v", err)
+	}
+}
+
+func TestLinkSignerVerifyPost(t *testing.T) {
+	s := NewLinkSigner("http://localhost:8090/files", []byte("secret"))
+
+	tests := []struct {
+		name    string
+		expiry  time.Duration
+		tamper  func(f map[string]string)
+		wantErr error
+	}{
+		{name: "valid", expiry: time.Minute},
+		{name: "other key", expiry: time.Minute, tamper: func(f map[string]string) { f[FormKey] = "avatar/other.png" }, wantErr: ErrLinkSignature},
+		{name: "other content type", expiry: time.Minute, tamper: func(f map[string]string) { f[FormContentType] = "text/html" }, wantErr: ErrLinkSignature},
+		{name: "raised max size", expiry: time.Minute, tamper: func(f map[string]string) { f[FormMaxSize] = "999999999" }, wantErr: ErrLinkSignature},
+		{name: "missing signature", expiry: time.Minute, tamper: func(f map[string]string) { delete(f, FormSignature) }, wantErr: ErrLinkSignature},
+		{name: "expired", expiry: -time.Minute, wantErr: ErrLinkExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, form := s.PostForm("bucket", "avatar/a.png", "image/png", 1024, tt.expiry)
+			if action != "http://localhost:8090/files/bucket" {
+				t.Fatalf("action = %q", action)
+			}
+			if tt.tamper != nil {
+				tt.tamper(form)
+			}
+			key, ct, max, err := s.VerifyPost("bucket", form)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyPost error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (key != "avatar/a.png" || ct != "image/png" || max != 1024) {
+				t.Errorf("VerifyPost = (%q, %q, %d)", key, ct, max)
+			}
+		})
+	}
+	// 表单提交到其它 bucket
+	_, form := s.PostForm("bucket", "avatar/a.png", "image/png", 1024, time.Minute)
+	if _, _, _, err := s.VerifyPost("other-bucket", form); !errors.Is(err, ErrLinkSignature) {
+		t.Errorf("VerifyPost other bucket = %v, want %v", err, ErrLinkSignature)
+	}
+}
+
+func TestPageKeys(t *testing.T) {
+	sorted := []string{"a.txt", "dir/1.txt", "dir/2.txt", "dir/sub/3.txt", "other/4.txt", "z.txt"}
+
+	tests := []struct {
+		name         string
+		prefix       string
+		recursive    bool
+		token        string
+		limit        int
+		wantKeys     []string
+		wantPrefixes []string
+		wantNext     string
+	}{
+		{name: "top level", wantKeys: []string{"a.txt", "z.txt"}, wantPrefixes: []string{"dir/", "other/"}},
+		{name: "top level first page", limit: 2, wantKeys: []string{"a.txt"}, wantPrefixes: []string{"dir/"}, wantNext: "dir/"},
+		{name: "top level second page is last", token: "dir/", limit: 2, wantKeys: []string{"z.txt"}, wantPrefixes: []string{"other/"}},
+		{name: "top level last page", token: "other/", limit: 2, wantKeys: []string{"z.txt"}},
+		{name: "page ending on a folder", limit: 3, wantKeys: []string{"a.txt"}, wantPrefixes: []string{"dir/", "other/"}, wantNext: "other/"},
+		{name: "folder", prefix: "dir/", wantKeys: []string{"dir/1.txt", "dir/2.txt"}, wantPrefixes: []string{"dir/sub/"}},
+		{name: "recursive", prefix: "dir/", recursive: true, wantKeys: []string{"dir/1.txt", "dir/2.txt", "dir/sub/3.txt"}},
+		{name: "recursive paged", recursive: true, token: "dir/1.txt", limit: 2, wantKeys: []string{"dir/2.txt", "dir/sub/3.txt"}, wantNext: "dir/sub/3.txt"},
+		{name: "no match", prefix: "nope/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, prefixes, next := pageKeys(sorted, tt.prefix, tt.recursive, tt.token, tt.limit)
+			if !reflect.DeepEqual(keys, tt.wantKeys) || !reflect.DeepEqual(prefixes, tt.wantPrefixes) || next != tt.wantNext {
+				t.Errorf("pageKeys = (%v, %v, %q), want (%v, %v, %q)", keys, prefixes, next, tt.wantKeys, tt.wantPrefixes, tt.wantNext)
+			}
+		})
+	}
+}
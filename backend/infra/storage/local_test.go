@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidBucket(t *testing.T) {
+	tests := []struct {
+		bucket string
+		ok     bool
+	}{
+		{"app-avatars", true},
+		{"abc", true},
+		{"a.b-c", true},
+		{"ab", false},
+		{"", false},
+		{"Upper", false},
+		{"-abc", false},
+		{"abc-", false},
+		{"a..b", false},
+		{"..", false},
+		{".sys", false},
+		{"a/b", false},
+		{"a\\b", false},
+		{strings.Repeat("a", 64), false},
+	}
+	for _, tt := range tests {
+		if err := validBucket(tt.bucket); (err == nil) != tt.ok {
+			t.Errorf("validBucket(%q) = %v, want ok=%v", tt.bucket, err, tt.ok)
+		}
+	}
+}
+
+func TestValidObject(t *testing.T) {
+	tests := []struct {
+		object string
+		ok     bool
+	}{
+		{"a.png", true},
+		{"avatar/2025/01/a.png", true},
+		{"..a/b..", true},
+		{"", false},
+		{"/etc/passwd", false},
+		{"dir/", false},
+		{"..", false},
+		{"../a", false},
+		{"a/../../b", false},
+		{"a/./b", false},
+		{"./a", false},
+		{"a//b", false},
+		{"a\\..\\b", false},
+		{strings.Repeat("a", 1025), false},
+	}
+	for _, tt := range tests {
+		if err := validObject(tt.object); (err == nil) != tt.ok {
+			t.Errorf("validObject(%q) = %v, want ok=%v", tt.object, err, tt.ok)
+		}
+	}
+}
+
+// 校验失败时不应在根目录外读写任何文件
+func TestLocalRejectsTraversal(t *testing.T) {
+	ctx := context.Background()
+	parent := t.TempDir()
+	l, err := NewLocal(filepath.Join(parent, "root"), "http://localhost/files", []byte("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.CreateBucket(ctx, "bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(parent, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, object := range []string{"../../secret.txt", "../escape.txt", "/abs.txt"} {
+		if _, err := l.PutObject(ctx, "bucket", object, strings.NewReader("x"), 1, "text/plain"); !errors.Is(err, ErrInvalidObject) {
+			t.Errorf("PutObject(%q) = %v, want %v", object, err, ErrInvalidObject)
+		}
+		if _, _, err := l.GetObject(ctx, "bucket", object); !errors.Is(err, ErrInvalidObject) {
+			t.Errorf("GetObject(%q) = %v, want %v", object, err, ErrInvalidObject)
+		}
+		if err := l.DeleteObject(ctx, "bucket", object); !errors.Is(err, ErrInvalidObject) {
+			t.Errorf("DeleteObject(%q) = %v, want %v", object, err, ErrInvalidObject)
+		}
+	}
+	if _, err := l.PutObject(ctx, "..", "secret.txt", strings.NewReader("x"), 1, "text/plain"); !errors.Is(err, ErrInvalidBucket) {
+		t.Errorf("PutObject bucket .. = %v, want %v", err, ErrInvalidBucket)
+	}
+	if data, _ := os.ReadFile(filepath.Join(parent, "secret.txt")); string(data) != "secret" {
+		t.Errorf("file outside root changed: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(parent, "escape.txt")); !os.IsNotExist(err) {
+		t.Errorf("file written outside root: %v", err)
+	}
+}
+
+func TestLocalPutGetList(t *testing.T) {
+	ctx := context.Background()
+	l, err := NewLocal(t.TempDir(), "http://localhost/files", []byte("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.CreateBucket(ctx, "bucket"); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"dir/b.txt", "dir/a.txt", "top.txt"} {
+		if _, err := l.PutObject(ctx, "bucket", k, strings.NewReader("hello "+k), -1, "text/plain"); err != nil {
+			t.Fatalf("PutObject(%q) = %v", k, err)
+		}
+	}
+	r, size, err := l.GetObject(ctx, "bucket", "dir/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "hello dir/a.txt" || size != int64(len(data)) {
+		t.Errorf("GetObject = %q (%d)", data, size)
+	}
+
+	page, err := l.ListObjectPage(ctx, "bucket", "", false, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Prefixes) != 1 || page.Prefixes[0] != "dir/" || len(page.Objects) != 0 || page.NextToken != "dir/" {
+		t.Fatalf("first page = %+v", page)
+	}
+	page, err = l.ListObjectPage(ctx, "bucket", "", false, page.NextToken, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Objects) != 1 || page.Objects[0].Key != "top.txt" || page.NextToken != "" {
+		t.Fatalf("second page = %+v", page)
+	}
+	if page.Objects[0].ContentType != "text/plain" || page.Objects[0].Size != int64(len("hello top.txt")) {
+		t.Errorf("object info = %+v", page.Objects[0])
+	}
+}
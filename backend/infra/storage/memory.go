@@ -0,0 +1,408 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrBucketNotFound = errors.New("bucket not found")
+	ErrBucketNotEmpty = errors.New("bucket not empty")
+	ErrUploadNotFound = errors.New("multipart upload not found")
+	ErrInvalidPart    = errors.New("invalid or missing part")
+)
+
+// memObject 写入后不再修改，更新元数据与标签时整体替换
+type memObject struct {
+	data        []byte
+	contentType string
+	etag        string
+	modTime     time.Time
+	metadata    map[string]string
+	tags        map[string]string
+}
+
+type memUpload struct {
+	bucket, object, contentType string
+	parts                       map[int]*memObject
+}
+
+// MemoryStorage 进程内对象存储，用于单元测试与无外部依赖的本地运行，数据随进程退出丢失
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string]*memObject
+	public  map[string]bool
+	uploads map[string]*memUpload
+	links   *LinkSigner
+}
+
+// NewMemory baseURL / secret 用于签发由 /files 路由提供的对象链接
+func NewMemory(baseURL string, secret []byte) *MemoryStorage {
+	return &MemoryStorage{buckets: map[string]map[string]*memObject{}, public: map[string]bool{}, uploads: map[string]*memUpload{},
+		links: NewLinkSigner(baseURL, secret)}
+}
+
+func (m *MemoryStorage) Links() *LinkSigner { return m.links }
+
+func (m *MemoryStorage) IsPublic(bucket string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.public[bucket]
+}
+
+func (m *MemoryStorage) CreateBucket(ctx context.Context, bucket string) error {
+	if err := validBucket(bucket); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.buckets[bucket] == nil {
+		m.buckets[bucket] = map[string]*memObject{}
+	}
+	return nil
+}
+
+func (m *MemoryStorage) DeleteBucket(ctx context.Context, bucket string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	objs, ok := m.buckets[bucket]
+	if !ok {
+		return ErrBucketNotFound
+	}
+	if len(objs) > 0 {
+		return ErrBucketNotEmpty
+	}
+	delete(m.buckets, bucket)
+	delete(m.public, bucket)
+	return nil
+}
+
+func (m *MemoryStorage) PutObject(ctx context.Context, bucket, objectName string, r io.Reader, size int64, contentType string) (string, error) {
+	if err := validObject(objectName); err != nil {
+		return "", err
+	}
+	obj, err := readMemObject(r, size, contentType)
+	if err != nil {
+		return "", err
+	}
+	if err := m.CreateBucket(ctx, bucket); err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	m.buckets[bucket][objectName] = obj
+	m.mu.Unlock()
+	return m.links.Base() + ObjectPath(bucket, objectName), nil
+}
+
+// readMemObject 读取内容并校验长度（size 为 -1 表示未知）
+func readMemObject(r io.Reader, size int64, contentType string) (*memObject, error) {
+	var data []byte
+	var err error
+	if size >= 0 {
+		data = make([]byte, size)
+		_, err = io.ReadFull(r, data)
+	} else {
+		data, err = io.ReadAll(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sum := md5.Sum(data)
+	return &memObject{data: data, contentType: contentType, etag: hex.EncodeToString(sum[:]), modTime: time.Now()}, nil
+}
+
+func (m *MemoryStorage) object(bucket, objectName string) (*memObject, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	obj := m.buckets[bucket][objectName]
+	if obj == nil {
+		return nil, ErrObjectNotFound
+	}
+	return obj, nil
+}
+
+func (m *MemoryStorage) GetObject(ctx context.Context, bucket, objectName string) (ObjectReader, int64, error) {
+	obj, err := m.object(bucket, objectName)
+	if err != nil {
+		return nil, 0, err
+	}
+	return memReader{bytes.NewReader(obj.data)}, int64(len(obj.data)), nil
+}
+
+type memReader struct{ *bytes.Reader }
+
+func (memReader) Close() error { return nil }
+
+func (m *MemoryStorage) StatObject(ctx context.Context, bucket, objectName string) (ObjectInfo, error) {
+	obj, err := m.object(bucket, objectName)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{Key: objectName, Size: int64(len(obj.data)), ContentType: obj.contentType, ETag: obj.etag, LastModified: obj.modTime,
+		Metadata: cloneMap(obj.metadata)}, nil
+}
+
+func (m *MemoryStorage) DeleteObject(ctx context.Context, bucket, objectName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.buckets[bucket], objectName)
+	return nil
+}
+
+func (m *MemoryStorage) DeleteObjects(ctx context.Context, bucket string, objectNames []string) (map[string]error, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	objs, ok := m.buckets[bucket]
+	if !ok {
+		return nil, ErrBucketNotFound
+	}
+	for _, name := range objectNames {
+		delete(objs, name)
+	}
+	return map[string]error{}, nil
+}
+
+func (m *MemoryStorage) CopyObject(ctx context.Context, srcBucket, srcObject, dstBucket, dstObject string) error {
+	if err := validObject(dstObject); err != nil {
+		return err
+	}
+	src, err := m.object(srcBucket, srcObject)
+	if err != nil {
+		return err
+	}
+	if err := m.CreateBucket(ctx, dstBucket); err != nil {
+		return err
+	}
+	cp := *src
+	cp.modTime = time.Now()
+	m.mu.Lock()
+	m.buckets[dstBucket][dstObject] = &cp
+	m.mu.Unlock()
+	return nil
+}
+
+// replace 以 fn 修改后的副本替换对象
+func (m *MemoryStorage) replace(bucket, objectName string, fn func(obj *memObject)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	obj := m.buckets[bucket][objectName]
+	if obj == nil {
+		return ErrObjectNotFound
+	}
+	cp := *obj
+	fn(&cp)
+	m.buckets[bucket][objectName] = &cp
+	return nil
+}
+
+func (m *MemoryStorage) UpdateObjectMetadata(ctx context.Context, bucket, objectName, contentType string, metadata map[string]string) error {
+	return m.replace(bucket, objectName, func(obj *memObject) {
+		if contentType != "" {
+			obj.contentType = contentType
+		}
+		obj.metadata = cloneMap(metadata)
+		obj.modTime = time.Now()
+	})
+}
+
+func (m *MemoryStorage) GetObjectTags(ctx context.Context, bucket, objectName string) (map[string]string, error) {
+	obj, err := m.object(bucket, objectName)
+	if err != nil {
+		return nil, err
+	}
+	tags := cloneMap(obj.tags)
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	return tags, nil
+}
+
+func (m *MemoryStorage) SetObjectTags(ctx context.Context, bucket, objectName string, tags map[string]string) error {
+	if err := ValidateTags(tags); err != nil {
+		return err
+	}
+	return m.replace(bucket, objectName, func(obj *memObject) { obj.tags = cloneMap(tags) })
+}
+
+func (m *MemoryStorage) ListBuckets(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.buckets))
+	for b := range m.buckets {
+		names = append(names, b)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (m *MemoryStorage) ListObjects(ctx context.Context, bucket, prefix string, recursive bool, limit int) ([]string, error) {
+	m.mu.RLock()
+	objs, ok := m.buckets[bucket]
+	if !ok {
+		m.mu.RUnlock()
+		return nil, ErrBucketNotFound
+	}
+	keys := make([]string, 0, len(objs))
+	for k := range objs {
+		keys = append(keys, k)
+	}
+	m.mu.RUnlock()
+	sort.Strings(keys)
+	return listKeys(keys, prefix, recursive, limit), nil
+}
+
+func (m *MemoryStorage) ListObjectPage(ctx context.Context, bucket, prefix string, recursive bool, token string, limit int) (ObjectPage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	objs, ok := m.buckets[bucket]
+	if !ok {
+		return ObjectPage{}, ErrBucketNotFound
+	}
+	sorted := make([]string, 0, len(objs))
+	for k := range objs {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	keys, prefixes, next := pageKeys(sorted, prefix, recursive, token, limit)
+	page := ObjectPage{Prefixes: prefixes, NextToken: next}
+	for _, k := range keys {
+		obj := objs[k]
+		page.Objects = append(page.Objects, ObjectInfo{Key: k, Size: int64(len(obj.data)), ContentType: obj.contentType, ETag: obj.etag, LastModified: obj.modTime})
+	}
+	return page, nil
+}
+
+func (m *MemoryStorage) GetPresignedURL(ctx context.Context, bucket, objectName string, expiry time.Duration) (string, error) {
+	if expiry <= 0 {
+		expiry = time.Hour
+	}
+	return m.links.Sign("GET", bucket, objectName, expiry, nil), nil
+}
+
+func (m *MemoryStorage) SetBucketPublic(ctx context.Context, bucket string, public bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.buckets[bucket]; !ok {
+		return ErrBucketNotFound
+	}
+	m.public[bucket] = public
+	return nil
+}
+
+func (m *MemoryStorage) PresignedPutURL(ctx context.Context, bucket, objectName, contentType string, size int64, expiry time.Duration) (string, error) {
+	if err := m.CreateBucket(ctx, bucket); err != nil {
+		return "", err
+	}
+	return m.links.Sign("PUT", bucket, objectName, expiry, putConstraints(contentType, size)), nil
+}
+
+func (m *MemoryStorage) PresignedPostPolicy(ctx context.Context, bucket, objectName, contentType string, maxSize int64, expiry time.Duration) (string, map[string]string, error) {
+	if err := m.CreateBucket(ctx, bucket); err != nil {
+		return "", nil, err
+	}
+	u, form := m.links.PostForm(bucket, objectName, contentType, maxSize, expiry)
+	return u, form, nil
+}
+
+func (m *MemoryStorage) NewMultipartUpload(ctx context.Context, bucket, objectName, contentType string) (string, error) {
+	if err := validObject(objectName); err != nil {
+		return "", err
+	}
+	if err := m.CreateBucket(ctx, bucket); err != nil {
+		return "", err
+	}
+	id := newUploadID()
+	m.mu.Lock()
+	m.uploads[id] = &memUpload{bucket: bucket, object: objectName, contentType: contentType, parts: map[int]*memObject{}}
+	m.mu.Unlock()
+	return id, nil
+}
+
+func (m *MemoryStorage) upload(bucket, objectName, uploadID string) (*memUpload, error) {
+	u := m.uploads[uploadID]
+	if u == nil || u.bucket != bucket || u.object != objectName {
+		return nil, ErrUploadNotFound
+	}
+	return u, nil
+}
+
+func (m *MemoryStorage) PutObjectPart(ctx context.Context, bucket, objectName, uploadID string, partNumber int, r io.Reader, size int64) (Part, error) {
+	obj, err := readMemObject(r, size, "")
+	if err != nil {
+		return Part{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, err := m.upload(bucket, objectName, uploadID)
+	if err != nil {
+		return Part{}, err
+	}
+	u.parts[partNumber] = obj
+	return Part{Number: partNumber, ETag: obj.etag, Size: int64(len(obj.data))}, nil
+}
+
+func (m *MemoryStorage) ListObjectParts(ctx context.Context, bucket, objectName, uploadID string) ([]Part, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	u, err := m.upload(bucket, objectName, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]Part, 0, len(u.parts))
+	for n, p := range u.parts {
+		parts = append(parts, Part{Number: n, ETag: p.etag, Size: int64(len(p.data))})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	return parts, nil
+}
+
+func (m *MemoryStorage) CompleteMultipartUpload(ctx context.Context, bucket, objectName, uploadID string, parts []Part) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, err := m.upload(bucket, objectName, uploadID)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, p := range parts {
+		part := u.parts[p.Number]
+		if part == nil || part.etag != p.ETag {
+			return fmt.Errorf("%w: %d", ErrInvalidPart, p.Number)
+		}
+		buf.Write(part.data)
+	}
+	data := buf.Bytes()
+	sum := md5.Sum(data)
+	m.buckets[bucket][objectName] = &memObject{data: data, contentType: u.contentType, etag: hex.EncodeToString(sum[:]) + "-" + strconv.Itoa(len(parts)), modTime: time.Now()}
+	delete(m.uploads, uploadID)
+	return nil
+}
+
+// AbortMultipartUpload 不存在的上传视为已中止
+func (m *MemoryStorage) AbortMultipartUpload(ctx context.Context, bucket, objectName, uploadID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.uploads, uploadID)
+	return nil
+}
+
+func putConstraints(contentType string, size int64) url.Values {
+	return url.Values{"content-type": {contentType}, "content-length": {strconv.FormatInt(size, 10)}}
+}
+
+func newUploadID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidMetadata = errors.New("invalid metadata: keys may contain letters, digits, - and _, values must be printable ASCII, 2KB in total")
+	ErrInvalidTags     = errors.New("invalid tags: at most 10, keys 1-128 and values up to 256 characters of letters, digits, spaces and + - = . _ : / @")
+	ErrSameObject      = errors.New("source and destination are the same")
+	ErrTooManyObjects  = errors.New("too many objects under prefix")
+)
+
+// 与 S3 的限制一致
+const (
+	maxMetadataBytes = 2 << 10
+	maxTags          = 10
+	maxTagKeyLen     = 128
+	maxTagValueLen   = 256
+)
+
+// MaxBatchObjects 批量删除与按前缀复制/移动的单次对象数上限
+const MaxBatchObjects = 1000
+
+var (
+	metaKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	tagPattern     = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+)
+
+// NormalizeMetadata 校验自定义元数据并统一为小写键（S3 元数据键不区分大小写）
+func NormalizeMetadata(meta map[string]string) (map[string]string, error) {
+	out := make(map[string]string, len(meta))
+	total := 0
+	for k, v := range meta {
+		if !metaKeyPattern.MatchString(k) {
+			return nil, ErrInvalidMetadata
+		}
+		for i := 0; i < len(v); i++ {
+			if v[i] < 0x20 || v[i] > 0x7e {
+				return nil, ErrInvalidMetadata
+			}
+		}
+		total += len(k) + len(v)
+		out[strings.ToLower(k)] = v
+	}
+	if total > maxMetadataBytes {
+		return nil, ErrInvalidMetadata
+	}
+	return out, nil
+}
+
+// ValidateTags 校验对象标签
+func ValidateTags(tags map[string]string) error {
+	if len(tags) > maxTags {
+		return ErrInvalidTags
+	}
+	for k, v := range tags {
+		if k == "" || utf8.RuneCountInString(k) > maxTagKeyLen || utf8.RuneCountInString(v) > maxTagValueLen ||
+			!tagPattern.MatchString(k) || !tagPattern.MatchString(v) {
+			return ErrInvalidTags
+		}
+	}
+	return nil
+}
+
+// cloneMap 复制元数据或标签，空 map 返回 nil
+func cloneMap(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// TransferObjects 复制或移动对象：srcKey 以 / 结尾时按前缀处理（“文件夹”），其下全部对象以 dstKey 为新前缀（为空表示 bucket 根目录），
+// 对象数超过 MaxBatchObjects 时不做任何操作。移动为逐个复制后删除源对象，中途失败时已处理的对象保留在目标位置。
+// 返回处理的对象数
+func TransferObjects(ctx context.Context, s ObjectStorage, srcBucket, srcKey, dstBucket, dstKey string, move bool) (int, error) {
+	if !strings.HasSuffix(srcKey, "/") {
+		if dstKey == "" || strings.HasSuffix(dstKey, "/") { // 复制到 bucket 根目录或“文件夹”内，保留原文件名
+			dstKey += srcKey[strings.LastIndex(srcKey, "/")+1:]
+		}
+		if srcBucket == dstBucket && srcKey == dstKey {
+			return 0, ErrSameObject
+		}
+		return 1, transfer(ctx, s, srcBucket, srcKey, dstBucket, dstKey, move)
+	}
+	// dstKey 为空表示移到 bucket 根目录
+	if dstKey != "" && !strings.HasSuffix(dstKey, "/") {
+		dstKey += "/"
+	}
+	if srcBucket == dstBucket && srcKey == dstKey {
+		return 0, ErrSameObject
+	}
+	// 先列出全部对象再处理，目标前缀位于源前缀之下时不会重复处理新写入的对象
+	var keys []string
+	token := ""
+	for {
+		page, err := s.ListObjectPage(ctx, srcBucket, srcKey, true, token, MaxBatchObjects)
+		if err != nil {
+			return 0, err
+		}
+		for _, o := range page.Objects {
+			keys = append(keys, o.Key)
+		}
+		if len(keys) > MaxBatchObjects {
+			return 0, ErrTooManyObjects
+		}
+		if page.NextToken == "" {
+			break
+		}
+		token = page.NextToken
+	}
+	for i, k := range keys {
+		if err := transfer(ctx, s, srcBucket, k, dstBucket, dstKey+strings.TrimPrefix(k, srcKey), move); err != nil {
+			return i, err
+		}
+	}
+	return len(keys), nil
+}
+
+func transfer(ctx context.Context, s ObjectStorage, srcBucket, srcKey, dstBucket, dstKey string, move bool) error {
+	if err := s.CopyObject(ctx, srcBucket, srcKey, dstBucket, dstKey); err != nil {
+		return err
+	}
+	if move {
+		return s.DeleteObject(ctx, srcBucket, srcKey)
+	}
+	return nil
+}
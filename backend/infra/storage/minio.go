@@ -1,15 +1,19 @@
 package storage
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
-	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/tags"
 
 	"alice/infra/config"
 	"alice/pkg/logger"
@@ -19,17 +23,82 @@ import (
 type ObjectStorage interface {
 	CreateBucket(ctx context.Context, bucket string) error
 	DeleteBucket(ctx context.Context, bucket string) error
-	PutObject(ctx context.Context, bucket, objectName string, data []byte, contentType string) (string, error)
+	// PutObject 流式写入对象，size 为 -1 表示长度未知
+	PutObject(ctx context.Context, bucket, objectName string, r io.Reader, size int64, contentType string) (string, error)
+	// GetObject 读取对象，返回可随机读取的内容与对象大小
+	GetObject(ctx context.Context, bucket, objectName string) (ObjectReader, int64, error)
+	// StatObject 对象元信息，不存在时返回 ErrObjectNotFound
+	StatObject(ctx context.Context, bucket, objectName string) (ObjectInfo, error)
 	DeleteObject(ctx context.Context, bucket, objectName string) error
+	// DeleteObjects 批量删除，返回删除失败的对象及原因；不存在的对象视为已删除
+	DeleteObjects(ctx context.Context, bucket string, objectNames []string) (map[string]error, error)
+	// CopyObject 服务端复制对象（含类型、自定义元数据与标签），目标已存在时覆盖；源不存在时返回 ErrObjectNotFound
+	CopyObject(ctx context.Context, srcBucket, srcObject, dstBucket, dstObject string) error
+	// UpdateObjectMetadata 替换对象的自定义元数据，contentType 为空时保留原类型
+	UpdateObjectMetadata(ctx context.Context, bucket, objectName, contentType string, metadata map[string]string) error
+	// GetObjectTags / SetObjectTags 对象标签，SetObjectTags 整体替换，tags 为空时清除
+	GetObjectTags(ctx context.Context, bucket, objectName string) (map[string]string, error)
+	SetObjectTags(ctx context.Context, bucket, objectName string, tags map[string]string) error
 	ListBuckets(ctx context.Context) ([]string, error)
 	ListObjects(ctx context.Context, bucket, prefix string, recursive bool, limit int) ([]string, error)
+	// ListObjectPage 分页列举对象及其元信息：token 为上一页的 NextToken（首页为空），非递归时下一级“目录”放入 Prefixes
+	ListObjectPage(ctx context.Context, bucket, prefix string, recursive bool, token string, limit int) (ObjectPage, error)
 	GetPresignedURL(ctx context.Context, bucket, objectName string, expiry time.Duration) (string, error)
 	SetBucketPublic(ctx context.Context, bucket string, public bool) error
+
+	// 客户端直传：PresignedPutURL 的签名包含 Content-Type 与 Content-Length，客户端必须按声明的类型与大小上传
+	PresignedPutURL(ctx context.Context, bucket, objectName, contentType string, size int64, expiry time.Duration) (string, error)
+	// PresignedPostPolicy 表单直传，策略限定对象名、类型与大小上限；返回表单地址与需原样提交的字段
+	PresignedPostPolicy(ctx context.Context, bucket, objectName, contentType string, maxSize int64, expiry time.Duration) (string, map[string]string, error)
+
+	// 分片上传（S3 multipart），用于大文件断点续传
+	NewMultipartUpload(ctx context.Context, bucket, objectName, contentType string) (string, error)
+	PutObjectPart(ctx context.Context, bucket, objectName, uploadID string, partNumber int, r io.Reader, size int64) (Part, error)
+	// ListObjectParts 已上传的分片，按分片号升序
+	ListObjectParts(ctx context.Context, bucket, objectName, uploadID string) ([]Part, error)
+	CompleteMultipartUpload(ctx context.Context, bucket, objectName, uploadID string, parts []Part) error
+	AbortMultipartUpload(ctx context.Context, bucket, objectName, uploadID string) error
+}
+
+// ErrObjectNotFound 对象不存在
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectInfo 对象元信息
+type ObjectInfo struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"content_type"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+	// Metadata 自定义元数据（键为小写），列举结果中可能为空
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// ObjectPage 一页列举结果，对象与“目录”前缀合计不超过 limit 项；NextToken 为空表示已列举完
+type ObjectPage struct {
+	Objects   []ObjectInfo `json:"objects"`
+	Prefixes  []string     `json:"prefixes"`
+	NextToken string       `json:"next_token"`
+}
+
+// ObjectReader 对象内容，支持随机读取（解析视频容器头等）
+type ObjectReader interface {
+	io.Reader
+	io.ReaderAt
+	io.Closer
+}
+
+// Part 已上传的分片
+type Part struct {
+	Number int    `json:"part_number"`
+	ETag   string `json:"etag"`
+	Size   int64  `json:"size"`
 }
 
 type MinioStorage struct {
-	cli     *minio.Client
-	baseURL string
+	cli  *minio.Client
+	core *minio.Core // 分片上传等底层 API
+	urls *URLResolver
 }
 
 // NewMinio 根据配置创建 MinIO 客户端
@@ -44,15 +113,7 @@ func NewMinio(cfg config.MinioConfig) (*MinioStorage, error) {
 	if err != nil {
 		return nil, err
 	}
-	base := cfg.BaseURL
-	if base == "" { // 生成默认 base url
-		scheme := "http"
-		if cfg.UseSSL {
-			scheme = "https"
-		}
-		base = scheme + "://" + cfg.Endpoint
-	}
-	return &MinioStorage{cli: client, baseURL: base}, nil
+	return &MinioStorage{cli: client, core: &minio.Core{Client: client}, urls: NewURLResolver(MinioBaseURL(cfg))}, nil
 }
 
 func (m *MinioStorage) CreateBucket(ctx context.Context, bucket string) error {
@@ -70,25 +131,118 @@ func (m *MinioStorage) DeleteBucket(ctx context.Context, bucket string) error {
 	return m.cli.RemoveBucket(ctx, bucket)
 }
 
-func (m *MinioStorage) PutObject(ctx context.Context, bucket, objectName string, data []byte, contentType string) (string, error) {
+func (m *MinioStorage) PutObject(ctx context.Context, bucket, objectName string, r io.Reader, size int64, contentType string) (string, error) {
 	if err := m.CreateBucket(ctx, bucket); err != nil { // 确保 bucket 存在
 		return "", err
 	}
-	reader := bytes.NewReader(data)
-	_, err := m.cli.PutObject(ctx, bucket, objectName, reader, int64(len(data)), minio.PutObjectOptions{ContentType: contentType})
+	_, err := m.cli.PutObject(ctx, bucket, objectName, r, size, minio.PutObjectOptions{ContentType: contentType})
 	if err != nil {
 		return "", err
 	}
 	// 返回可访问 URL (基础 + bucket + objectName)
-	u, _ := url.Parse(m.baseURL)
-	u.Path = path.Join(u.Path, bucket, objectName)
-	return u.String(), nil
+	return m.urls.Resolve(ObjectPath(bucket, objectName)), nil
+}
+
+func (m *MinioStorage) GetObject(ctx context.Context, bucket, objectName string) (ObjectReader, int64, error) {
+	obj, err := m.cli.GetObject(ctx, bucket, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := obj.Stat() // 对象不存在时在此返回错误
+	if err != nil {
+		obj.Close()
+		return nil, 0, err
+	}
+	return obj, info.Size, nil
+}
+
+func (m *MinioStorage) StatObject(ctx context.Context, bucket, objectName string) (ObjectInfo, error) {
+	info, err := m.cli.StatObject(ctx, bucket, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, notFound(err)
+	}
+	meta := make(map[string]string, len(info.UserMetadata))
+	for k, v := range info.UserMetadata {
+		meta[strings.ToLower(k)] = v
+	}
+	return ObjectInfo{Key: info.Key, Size: info.Size, ContentType: info.ContentType, ETag: info.ETag, LastModified: info.LastModified, Metadata: meta}, nil
+}
+
+// notFound 将对象或 bucket 不存在的错误转为 ErrObjectNotFound
+func notFound(err error) error {
+	if code := minio.ToErrorResponse(err).Code; code == "NoSuchKey" || code == "NoSuchBucket" {
+		return ErrObjectNotFound
+	}
+	return err
 }
 
 func (m *MinioStorage) DeleteObject(ctx context.Context, bucket, objectName string) error {
 	return m.cli.RemoveObject(ctx, bucket, objectName, minio.RemoveObjectOptions{})
 }
 
+func (m *MinioStorage) DeleteObjects(ctx context.Context, bucket string, objectNames []string) (map[string]error, error) {
+	ch := make(chan minio.ObjectInfo, len(objectNames))
+	for _, name := range objectNames {
+		ch <- minio.ObjectInfo{Key: name}
+	}
+	close(ch)
+	failed := map[string]error{}
+	for e := range m.cli.RemoveObjects(ctx, bucket, ch, minio.RemoveObjectsOptions{}) {
+		if e.ObjectName == "" { // 请求本身失败（如 bucket 不存在）
+			return nil, e.Err
+		}
+		failed[e.ObjectName] = e.Err
+	}
+	return failed, nil
+}
+
+func (m *MinioStorage) CopyObject(ctx context.Context, srcBucket, srcObject, dstBucket, dstObject string) error {
+	if err := m.CreateBucket(ctx, dstBucket); err != nil {
+		return err
+	}
+	// 元数据与标签默认随源对象复制
+	_, err := m.cli.CopyObject(ctx, minio.CopyDestOptions{Bucket: dstBucket, Object: dstObject}, minio.CopySrcOptions{Bucket: srcBucket, Object: srcObject})
+	return notFound(err)
+}
+
+func (m *MinioStorage) UpdateObjectMetadata(ctx context.Context, bucket, objectName, contentType string, metadata map[string]string) error {
+	info, err := m.StatObject(ctx, bucket, objectName)
+	if err != nil {
+		return err
+	}
+	// S3 不支持原地修改元数据，复制到自身并替换
+	meta := make(map[string]string, len(metadata)+1)
+	for k, v := range metadata {
+		meta[k] = v
+	}
+	if contentType == "" {
+		contentType = info.ContentType
+	}
+	meta["Content-Type"] = contentType
+	_, err = m.cli.CopyObject(ctx, minio.CopyDestOptions{Bucket: bucket, Object: objectName, UserMetadata: meta, ReplaceMetadata: true},
+		minio.CopySrcOptions{Bucket: bucket, Object: objectName, MatchETag: info.ETag})
+	return notFound(err)
+}
+
+func (m *MinioStorage) GetObjectTags(ctx context.Context, bucket, objectName string) (map[string]string, error) {
+	t, err := m.cli.GetObjectTagging(ctx, bucket, objectName, minio.GetObjectTaggingOptions{})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return t.ToMap(), nil
+}
+
+func (m *MinioStorage) SetObjectTags(ctx context.Context, bucket, objectName string, tagMap map[string]string) error {
+	if len(tagMap) == 0 {
+		return notFound(m.cli.RemoveObjectTagging(ctx, bucket, objectName, minio.RemoveObjectTaggingOptions{}))
+	}
+	t, err := tags.NewTags(tagMap, true)
+	if err != nil {
+		return ErrInvalidTags
+	}
+	return notFound(m.cli.PutObjectTagging(ctx, bucket, objectName, t, minio.PutObjectTaggingOptions{}))
+}
+
 func (m *MinioStorage) ListBuckets(ctx context.Context) ([]string, error) {
 	buckets, err := m.cli.ListBuckets(ctx)
 	if err != nil {
@@ -117,6 +271,50 @@ func (m *MinioStorage) ListObjects(ctx context.Context, bucket, prefix string, r
 	return res, nil
 }
 
+func (m *MinioStorage) ListObjectPage(ctx context.Context, bucket, prefix string, recursive bool, token string, limit int) (ObjectPage, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // 提前结束遍历时停止后台列举
+	var page ObjectPage
+	n := 0
+	for obj := range m.cli.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: recursive, StartAfter: token, WithMetadata: true}) {
+		if obj.Err != nil {
+			if minio.ToErrorResponse(obj.Err).Code == "NoSuchBucket" {
+				return ObjectPage{}, ErrBucketNotFound
+			}
+			return ObjectPage{}, obj.Err
+		}
+		// StartAfter 落在某个“目录”内时，S3 可能再次返回该前缀
+		if obj.Key <= token {
+			continue
+		}
+		if limit > 0 && n >= limit {
+			return page, nil
+		}
+		if !recursive && strings.HasSuffix(obj.Key, "/") {
+			page.Prefixes = append(page.Prefixes, obj.Key)
+		} else {
+			page.Objects = append(page.Objects, ObjectInfo{Key: obj.Key, Size: obj.Size, ContentType: listedContentType(obj), ETag: obj.ETag, LastModified: obj.LastModified})
+		}
+		n++
+		page.NextToken = obj.Key
+	}
+	page.NextToken = ""
+	return page, nil
+}
+
+// listedContentType 列举结果中的类型：MinIO 在携带 metadata 参数时通过元数据返回，其他 S3 实现为空
+func listedContentType(obj minio.ObjectInfo) string {
+	if obj.ContentType != "" {
+		return obj.ContentType
+	}
+	for k, v := range obj.UserMetadata {
+		if strings.EqualFold(k, "Content-Type") {
+			return v
+		}
+	}
+	return ""
+}
+
 func (m *MinioStorage) GetPresignedURL(ctx context.Context, bucket, objectName string, expiry time.Duration) (string, error) {
 	if expiry <= 0 {
 		expiry = time.Hour
@@ -129,6 +327,43 @@ func (m *MinioStorage) GetPresignedURL(ctx context.Context, bucket, objectName s
 	return presigned.String(), nil
 }
 
+func (m *MinioStorage) PresignedPutURL(ctx context.Context, bucket, objectName, contentType string, size int64, expiry time.Duration) (string, error) {
+	if err := m.CreateBucket(ctx, bucket); err != nil {
+		return "", err
+	}
+	h := make(http.Header)
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.FormatInt(size, 10))
+	u, err := m.cli.PresignHeader(ctx, http.MethodPut, bucket, objectName, expiry, nil, h)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (m *MinioStorage) PresignedPostPolicy(ctx context.Context, bucket, objectName, contentType string, maxSize int64, expiry time.Duration) (string, map[string]string, error) {
+	if err := m.CreateBucket(ctx, bucket); err != nil {
+		return "", nil, err
+	}
+	policy := minio.NewPostPolicy()
+	for _, err := range []error{
+		policy.SetBucket(bucket),
+		policy.SetKey(objectName),
+		policy.SetContentType(contentType),
+		policy.SetContentLengthRange(1, maxSize),
+		policy.SetExpires(time.Now().UTC().Add(expiry)),
+	} {
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	u, form, err := m.cli.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return "", nil, err
+	}
+	return u.String(), form, nil
+}
+
 // SetBucketPublic 设置 bucket 是否公共读
 func (m *MinioStorage) SetBucketPublic(ctx context.Context, bucket string, public bool) error {
 	if public {
@@ -150,3 +385,49 @@ func (m *MinioStorage) HealthCheck(ctx context.Context) error {
 	}
 	return nil
 }
+
+func (m *MinioStorage) NewMultipartUpload(ctx context.Context, bucket, objectName, contentType string) (string, error) {
+	if err := m.CreateBucket(ctx, bucket); err != nil {
+		return "", err
+	}
+	return m.core.NewMultipartUpload(ctx, bucket, objectName, minio.PutObjectOptions{ContentType: contentType})
+}
+
+func (m *MinioStorage) PutObjectPart(ctx context.Context, bucket, objectName, uploadID string, partNumber int, r io.Reader, size int64) (Part, error) {
+	p, err := m.core.PutObjectPart(ctx, bucket, objectName, uploadID, partNumber, r, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return Part{}, err
+	}
+	return Part{Number: p.PartNumber, ETag: p.ETag, Size: p.Size}, nil
+}
+
+func (m *MinioStorage) ListObjectParts(ctx context.Context, bucket, objectName, uploadID string) ([]Part, error) {
+	var parts []Part
+	marker := 0
+	for {
+		res, err := m.core.ListObjectParts(ctx, bucket, objectName, uploadID, marker, 1000)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range res.ObjectParts {
+			parts = append(parts, Part{Number: p.PartNumber, ETag: p.ETag, Size: p.Size})
+		}
+		if !res.IsTruncated {
+			return parts, nil
+		}
+		marker = res.NextPartNumberMarker
+	}
+}
+
+func (m *MinioStorage) CompleteMultipartUpload(ctx context.Context, bucket, objectName, uploadID string, parts []Part) error {
+	complete := make([]minio.CompletePart, 0, len(parts))
+	for _, p := range parts {
+		complete = append(complete, minio.CompletePart{PartNumber: p.Number, ETag: p.ETag})
+	}
+	_, err := m.core.CompleteMultipartUpload(ctx, bucket, objectName, uploadID, complete, minio.PutObjectOptions{})
+	return err
+}
+
+func (m *MinioStorage) AbortMultipartUpload(ctx context.Context, bucket, objectName, uploadID string) error {
+	return m.core.AbortMultipartUpload(ctx, bucket, objectName, uploadID)
+}
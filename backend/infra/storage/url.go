@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"alice/infra/config"
+	"alice/pkg/logger"
+)
+
+// URLResolver 将数据库中保存的相对路径 /bucket/object 转为客户端可访问的完整 URL
+type URLResolver struct {
+	base string
+	// 私有 bucket 的对象不可公开访问，解析时签发短时有效的预签名地址
+	signer  ObjectStorage
+	private map[string]bool
+	ttl     time.Duration
+}
+
+// NewURLResolver base 为公共读对象的基础地址，对象 URL 为 {base}/{bucket}/{object}
+func NewURLResolver(base string) *URLResolver {
+	return &URLResolver{base: strings.TrimRight(base, "/")}
+}
+
+// MinioBaseURL 优先使用 MinioConfig.BaseURL（自定义域名/CDN），未配置时由 endpoint 推导
+func MinioBaseURL(cfg config.MinioConfig) string {
+	if cfg.BaseURL != "" {
+		return cfg.BaseURL
+	}
+	scheme := "http"
+	if cfg.UseSSL {
+		scheme = "https"
+	}
+	return scheme + "://" + cfg.Endpoint
+}
+
+// SignPrivate 指定私有 bucket：其中的对象解析为有效期 ttl 的预签名地址。
+// 调用方负责只向有权访问的用户返回这些路径的解析结果
+func (r *URLResolver) SignPrivate(signer ObjectStorage, buckets []string, ttl time.Duration) {
+	r.signer, r.ttl = signer, ttl
+	r.private = make(map[string]bool, len(buckets))
+	for _, b := range buckets {
+		r.private[b] = true
+	}
+}
+
+// IsPrivate 相对路径是否位于私有 bucket
+func (r *URLResolver) IsPrivate(raw string) bool {
+	bucket, _, ok := SplitObjectPath(raw)
+	return ok && r.private[bucket]
+}
+
+// Resolve 空串返回 ""；已是 http(s) 完整 URL 的旧数据原样返回；私有 bucket 签发预签名地址（失败返回 ""）；
+// 其余视为相对路径拼接 base
+func (r *URLResolver) Resolve(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	lower := strings.ToLower(raw)
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+		return raw
+	}
+	if !strings.HasPrefix(raw, "/") {
+		raw = "/" + raw
+	}
+	if bucket, object, ok := SplitObjectPath(raw); ok && r.private[bucket] {
+		if r.signer == nil {
+			return ""
+		}
+		u, err := r.signer.GetPresignedURL(context.Background(), bucket, object, r.ttl)
+		if err != nil {
+			logger.Warnf("presign %s failed: %v", raw, err)
+			return ""
+		}
+		return u
+	}
+	return r.base + raw
+}
+
+// ObjectPath 对象的相对路径 /bucket/object（数据库统一保存该形式）
+func ObjectPath(bucket, objectName string) string {
+	return "/" + bucket + "/" + objectName
+}
+
+// SplitObjectPath 拆分相对路径 /bucket/object
+func SplitObjectPath(raw string) (bucket, objectName string, ok bool) {
+	bucket, objectName, ok = strings.Cut(strings.TrimPrefix(raw, "/"), "/")
+	return bucket, objectName, ok && bucket != "" && objectName != ""
+}
@@ -0,0 +1,126 @@
+package ahocorasick
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Match 一次命中：Pattern 为命中的模式下标，Start/End 为 rune 下标区间 [Start, End)
+type Match struct {
+	Pattern int
+	Start   int
+	End     int
+}
+
+type node struct {
+	next map[rune]int
+	fail int
+	// out 以该节点结尾的模式下标（含 fail 链上继承的输出）
+	out []int
+}
+
+// Matcher Aho–Corasick 多模式匹配自动机（构建后只读，可并发使用）
+// 匹配按 rune 进行，按 Fold 忽略大小写，适用于中英文混合文本。
+type Matcher struct {
+	nodes    []node
+	patterns []string
+	lens     []int // 模式长度（rune）
+}
+
+// New 根据模式列表构建自动机，空模式会被忽略
+func New(patterns []string) *Matcher {
+	m := &Matcher{nodes: []node{{next: map[rune]int{}}}, patterns: patterns, lens: make([]int, len(patterns))}
+	for i, p := range patterns {
+		m.lens[i] = len([]rune(p))
+		m.insert(i, p)
+	}
+	m.build()
+	return m
+}
+
+// Patterns 返回构建时的模式列表
+func (m *Matcher) Patterns() []string { return m.patterns }
+
+func (m *Matcher) insert(idx int, p string) {
+	if p == "" {
+		return
+	}
+	cur := 0
+	for _, r := range p {
+		r = fold(r)
+		nxt, ok := m.nodes[cur].next[r]
+		if !ok {
+			m.nodes = append(m.nodes, node{next: map[rune]int{}})
+			nxt = len(m.nodes) - 1
+			m.nodes[cur].next[r] = nxt
+		}
+		cur = nxt
+	}
+	m.nodes[cur].out = append(m.nodes[cur].out, idx)
+}
+
+// build BFS 计算 fail 指针并合并输出
+func (m *Matcher) build() {
+	queue := make([]int, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		m.nodes[child].fail = 0
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[cur].next {
+			f := m.nodes[cur].fail
+			for f > 0 {
+				if _, ok := m.nodes[f].next[r]; ok {
+					break
+				}
+				f = m.nodes[f].fail
+			}
+			if nxt, ok := m.nodes[f].next[r]; ok && nxt != child {
+				m.nodes[child].fail = nxt
+			} else {
+				m.nodes[child].fail = 0
+			}
+			m.nodes[child].out = append(m.nodes[child].out, m.nodes[m.nodes[child].fail].out...)
+			queue = append(queue, child)
+		}
+	}
+}
+
+// FindAll 返回文本中的全部命中（允许重叠）
+func (m *Matcher) FindAll(text string) []Match {
+	if m == nil || len(m.nodes) <= 1 {
+		return nil
+	}
+	var res []Match
+	cur := 0
+	i := 0
+	for _, r := range text {
+		r = fold(r)
+		for cur > 0 {
+			if _, ok := m.nodes[cur].next[r]; ok {
+				break
+			}
+			cur = m.nodes[cur].fail
+		}
+		if nxt, ok := m.nodes[cur].next[r]; ok {
+			cur = nxt
+		}
+		for _, p := range m.nodes[cur].out {
+			res = append(res, Match{Pattern: p, Start: i + 1 - m.lens[p], End: i + 1})
+		}
+		i++
+	}
+	return res
+}
+
+// Fold 返回匹配时使用的大小写折叠形式（逐 rune unicode.ToLower，rune 数量不变），
+// 调用方对模式去重时应使用同一折叠，避免与 strings.ToLower 的多 rune 映射不一致
+func Fold(s string) string {
+	return strings.Map(fold, s)
+}
+
+func fold(r rune) rune {
+	return unicode.ToLower(r)
+}
@@ -0,0 +1,151 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"unicode/utf8"
+)
+
+// sorted 按 End、Start、Pattern 排序，命中顺序与测试无关
+func sorted(ms []Match) []Match {
+	slices.SortFunc(ms, func(a, b Match) int {
+		if a.End != b.End {
+			return a.End - b.End
+		}
+		if a.Start != b.Start {
+			return a.Start - b.Start
+		}
+		return a.Pattern - b.Pattern
+	})
+	return ms
+}
+
+func TestFindAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		text     string
+		want     []Match
+	}{
+		{
+			name:     "overlapping patterns",
+			patterns: []string{"he", "she", "his", "hers"},
+			text:     "ushers",
+			want:     []Match{{Pattern: 1, Start: 1, End: 4}, {Pattern: 0, Start: 2, End: 4}, {Pattern: 3, Start: 2, End: 6}},
+		},
+		{
+			name:     "suffixes of another pattern",
+			patterns: []string{"abc", "bc", "c"},
+			text:     "xabcc",
+			want:     []Match{{Pattern: 0, Start: 1, End: 4}, {Pattern: 1, Start: 2, End: 4}, {Pattern: 2, Start: 3, End: 4}, {Pattern: 2, Start: 4, End: 5}},
+		},
+		{
+			name:     "repeated overlapping occurrences",
+			patterns: []string{"aa"},
+			text:     "aaaa",
+			want:     []Match{{Pattern: 0, Start: 0, End: 2}, {Pattern: 0, Start: 1, End: 3}, {Pattern: 0, Start: 2, End: 4}},
+		},
+		{
+			name:     "CJK runes",
+			patterns: []string{"敏感词", "感词", "词汇"},
+			text:     "这是敏感词汇",
+			want:     []Match{{Pattern: 0, Start: 2, End: 5}, {Pattern: 1, Start: 3, End: 5}, {Pattern: 2, Start: 4, End: 6}},
+		},
+		{
+			name:     "mixed scripts",
+			patterns: []string{"spam广告"},
+			text:     "免费SPAM广告!",
+			want:     []Match{{Pattern: 0, Start: 2, End: 8}},
+		},
+		{
+			name:     "ASCII case folding",
+			patterns: []string{"BadWord"},
+			text:     "a bADwORD here",
+			want:     []Match{{Pattern: 0, Start: 2, End: 9}},
+		},
+		{
+			// Ⱥ (2 字节) 小写为 ⱥ (3 字节)：按 rune 折叠后偏移不受字节长度变化影响
+			name:     "fold grows in bytes",
+			patterns: []string{"ⱥb"},
+			text:     "中ȺB",
+			want:     []Match{{Pattern: 0, Start: 1, End: 3}},
+		},
+		{
+			// 开尔文符号 K (3 字节) 小写为 k (1 字节)
+			name:     "fold shrinks in bytes",
+			patterns: []string{"kelvin"},
+			text:     "x\u212Aelvin",
+			want:     []Match{{Pattern: 0, Start: 1, End: 7}},
+		},
+		{
+			// strings.ToLower 把 İ 映射为两个 rune，逐 rune 折叠只有一个，模式长度与文本偏移保持一致
+			name:     "dotted capital I",
+			patterns: []string{"İstanbul"},
+			text:     "go istanbul",
+			want:     []Match{{Pattern: 0, Start: 3, End: 11}},
+		},
+		{
+			name:     "empty pattern ignored",
+			patterns: []string{"", "b"},
+			text:     "abc",
+			want:     []Match{{Pattern: 1, Start: 1, End: 2}},
+		},
+		{
+			name:     "no match",
+			patterns: []string{"abc"},
+			text:     "abxabyc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sorted(New(tt.patterns).FindAll(tt.text))
+			if !reflect.DeepEqual(got, sorted(tt.want)) {
+				t.Errorf("FindAll = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindAllEmpty(t *testing.T) {
+	var nilMatcher *Matcher
+	if got := nilMatcher.FindAll("abc"); got != nil {
+		t.Errorf("nil matcher = %v", got)
+	}
+	if got := New(nil).FindAll("abc"); got != nil {
+		t.Errorf("no patterns = %v", got)
+	}
+	if got := New([]string{""}).FindAll("abc"); got != nil {
+		t.Errorf("only empty pattern = %v", got)
+	}
+}
+
+// 审核按 rune 下标把命中替换为 *，偏移必须落在原文的 rune 上
+func TestMatchOffsetsMaskText(t *testing.T) {
+	m := New([]string{"ⱥbc", "敏感"})
+	text := "ȺBC和敏感词, \u212A"
+	runes := []rune(text)
+	for _, hit := range m.FindAll(text) {
+		for i := hit.Start; i < hit.End; i++ {
+			runes[i] = '*'
+		}
+	}
+	if got, want := string(runes), "***和**词, \u212A"; got != want {
+		t.Errorf("masked = %q, want %q", got, want)
+	}
+}
+
+func TestFold(t *testing.T) {
+	for _, s := range []string{"ȺBC", "\u212AELVIN", "İstanbul", "中文ABC"} {
+		f := Fold(s)
+		if utf8.RuneCountInString(f) != utf8.RuneCountInString(s) {
+			t.Errorf("Fold(%q) = %q changes the rune count", s, f)
+		}
+		if len(New([]string{s}).FindAll(f)) != 1 {
+			t.Errorf("pattern %q does not match its folded form %q", s, f)
+		}
+	}
+	if got := Fold("ȺBC中"); got != "ⱥbc中" {
+		t.Errorf("Fold = %q", got)
+	}
+}
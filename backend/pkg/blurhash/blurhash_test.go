@@ -0,0 +1,68 @@
+package blurhash
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// gradient 左上角位于 origin 的 w x h 渐变图：R 随 x、G 随 y 递增，B 随 x+y 递减
+func gradient(origin image.Point, w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rectangle{Min: origin, Max: origin.Add(image.Pt(w, h))})
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(origin.X+x, origin.Y+y, color.NRGBA{R: uint8(x * 40), G: uint8(y * 60), B: uint8(255 - (x+y)*20), A: 255})
+		}
+	}
+	return img
+}
+
+func solid(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+func TestEncode(t *testing.T) {
+	red := color.NRGBA{R: 255, A: 255}
+	tests := []struct {
+		name string
+		x, y int
+		img  image.Image
+		want string
+	}{
+		// 参考值由 blurhash 官方 C 实现的算法独立计算
+		{name: "gradient 4x3", x: 4, y: 3, img: gradient(image.Point{}, 6, 4), want: "L+EL^7G1SRxd#2R?b0noece@fRe?"},
+		{name: "solid DC only", x: 1, y: 1, img: solid(3, 2, red), want: "00TI:j"},
+		// 超过采样上限的大图按网格采样，纯色结果不变
+		{name: "solid sampled", x: 1, y: 1, img: solid(300, 200, red), want: "00TI:j"},
+		// 图片边界不从原点开始（如 SubImage 的结果）
+		{name: "offset bounds", x: 4, y: 3, img: gradient(image.Pt(5, 7), 6, 4), want: "L+EL^7G1SRxd#2R?b0noece@fRe?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Encode(tt.x, tt.y, tt.img)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Encode = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	img := gradient(image.Point{}, 4, 4)
+	for _, c := range [][2]int{{0, 3}, {4, 0}, {10, 3}, {4, 10}} {
+		if _, err := Encode(c[0], c[1], img); !errors.Is(err, ErrInvalidComponents) {
+			t.Errorf("Encode(%d, %d) = %v, want %v", c[0], c[1], err, ErrInvalidComponents)
+		}
+	}
+	if _, err := Encode(4, 3, image.NewNRGBA(image.Rect(0, 0, 0, 0))); err == nil {
+		t.Error("Encode empty image succeeded")
+	}
+}
@@ -0,0 +1,147 @@
+// Package imaging 纯 Go 图片处理：按字节嗅探真实类型、去除元数据（EXIF/GPS 等）、按 EXIF 方向摆正、生成缩略图
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif" // 注册 GIF 解码
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported image type")
+	ErrTooLarge    = errors.New("image dimensions too large")
+)
+
+// maxPixels 解码前校验像素总数，防止解压炸弹
+const maxPixels = 50_000_000
+
+// JPEG 编码质量
+const (
+	originalQuality = 90
+	variantQuality  = 85
+)
+
+// Spec 衍生图规格：最长边不超过 MaxSide
+type Spec struct {
+	Name    string
+	MaxSide int
+}
+
+// DefaultVariants 缩略图（列表/九宫格）与中图（详情/全屏预览）
+var DefaultVariants = []Spec{{Name: "thumb", MaxSide: 320}, {Name: "medium", MaxSide: 1080}}
+
+// Variant 衍生图
+type Variant struct {
+	Name        string
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// Result 处理结果
+type Result struct {
+	ContentType string // 嗅探得到的真实类型
+	Ext         string // 与真实类型对应的扩展名
+	Data        []byte // 去除元数据并摆正后的原图（GIF 保留原文件以免丢失动画）
+	Width       int
+	Height      int
+	Image       image.Image // 摆正后的图像，可用于生成占位等
+	// Variants 按 specs 顺序生成；原图不超过规格时按原尺寸重新编码，保证各规格始终存在
+	Variants []Variant
+}
+
+// Sniff 按文件头判断真实类型（不信任客户端 Content-Type）
+func Sniff(data []byte) string {
+	return http.DetectContentType(data)
+}
+
+var extByType = map[string]string{"image/jpeg": ".jpg", "image/png": ".png", "image/gif": ".gif"}
+
+// Process 校验并处理图片，specs 为需要生成的衍生图规格
+func Process(data []byte, specs []Spec) (*Result, error) {
+	ct := Sniff(data)
+	ext, ok := extByType[ct]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	img := toNRGBA(src)
+	if ct == "image/jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+	b := img.Bounds()
+	res := &Result{ContentType: ct, Ext: ext, Width: b.Dx(), Height: b.Dy(), Image: img}
+
+	// 重新编码即丢弃全部元数据；GIF 不含 EXIF，保留原文件以免丢失动画
+	switch ct {
+	case "image/jpeg":
+		res.Data, err = encodeJPEG(img, originalQuality)
+	case "image/png":
+		res.Data, err = encodePNG(img)
+	default:
+		res.Data = data
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, spec := range specs {
+		if spec.MaxSide <= 0 {
+			continue
+		}
+		dst := Fit(img, spec.MaxSide)
+		v := Variant{Name: spec.Name, Width: dst.Bounds().Dx(), Height: dst.Bounds().Dy()}
+		// 有透明通道的保存为 PNG，其余统一为 JPEG
+		if dst.Opaque() {
+			v.ContentType, v.Ext = "image/jpeg", ".jpg"
+			v.Data, err = encodeJPEG(dst, variantQuality)
+		} else {
+			v.ContentType, v.Ext = "image/png", ".png"
+			v.Data, err = encodePNG(dst)
+		}
+		if err != nil {
+			return nil, err
+		}
+		res.Variants = append(res.Variants, v)
+	}
+	return res, nil
+}
+
+func toNRGBA(src image.Image) *image.NRGBA {
+	if n, ok := src.(*image.NRGBA); ok && n.Bounds().Min == (image.Point{}) {
+		return n
+	}
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+func encodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	return buf.Bytes(), err
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	err := (&png.Encoder{CompressionLevel: png.BestSpeed}).Encode(&buf, img)
+	return buf.Bytes(), err
+}
@@ -0,0 +1,243 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// exifSegment 只含方向标签的 APP1/EXIF 段
+func exifSegment(bo binary.AppendByteOrder, orientation uint16) []byte {
+	tiff := []byte("II")
+	if bo == binary.BigEndian {
+		tiff = []byte("MM")
+	}
+	tiff = bo.AppendUint16(tiff, 42)
+	tiff = bo.AppendUint32(tiff, 8)
+	tiff = bo.AppendUint16(tiff, 1)      // 条目数
+	tiff = bo.AppendUint16(tiff, 0x0112) // Orientation
+	tiff = bo.AppendUint16(tiff, 3)      // SHORT
+	tiff = bo.AppendUint32(tiff, 1)
+	tiff = bo.AppendUint16(tiff, orientation)
+	tiff = bo.AppendUint16(tiff, 0)
+	tiff = bo.AppendUint32(tiff, 0) // 无下一个 IFD
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := []byte{0xFF, 0xE1}
+	seg = binary.BigEndian.AppendUint16(seg, uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// withSegment 在 SOI 之后插入段
+func withSegment(jpg, seg []byte) []byte {
+	return append(append(append([]byte{}, jpg[:2]...), seg...), jpg[2:]...)
+}
+
+// markers 列出 JPEG 图像数据之前的段标记
+func markers(t *testing.T, data []byte) []byte {
+	t.Helper()
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		t.Fatal("not a JPEG")
+	}
+	var out []byte
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		out = append(out, data[i+1])
+		if data[i+1] == 0xDA {
+			break
+		}
+		i += 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+	}
+	return out
+}
+
+// testJPEG w x h 的蓝色图片，左上角 50x50 为红色
+func testJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{B: 255, A: 255}
+			if x < 50 && y < 50 {
+				c = color.NRGBA{R: 255, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func TestProcessStripsExifAndOrients(t *testing.T) {
+	src := withSegment(testJPEG(t, 400, 200), exifSegment(binary.BigEndian, 6))
+	if o := jpegOrientation(src); o != 6 {
+		t.Fatalf("fixture orientation = %d", o)
+	}
+
+	res, err := Process(src, DefaultVariants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ContentType != "image/jpeg" || res.Ext != ".jpg" {
+		t.Errorf("type = %q %q", res.ContentType, res.Ext)
+	}
+	// 方向 6 为顺时针旋转 90°：宽高互换，左上角的红块转到右上角
+	if res.Width != 200 || res.Height != 400 {
+		t.Fatalf("size = %dx%d, want 200x400", res.Width, res.Height)
+	}
+	out, err := jpeg.Decode(bytes.NewReader(res.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := out.Bounds(); b.Dx() != 200 || b.Dy() != 400 {
+		t.Fatalf("encoded size = %v", b)
+	}
+	if !isRed(out.At(175, 25)) || isRed(out.At(25, 25)) {
+		t.Errorf("pixels not rotated: top-right %v, top-left %v", out.At(175, 25), out.At(25, 25))
+	}
+	for _, m := range markers(t, res.Data) {
+		if m >= 0xE1 && m <= 0xEF {
+			t.Errorf("output keeps APP%d segment", m-0xE0)
+		}
+	}
+	if bytes.Contains(res.Data, []byte("Exif\x00\x00")) {
+		t.Error("output contains EXIF header")
+	}
+
+	// 缩略图按最长边 320 等比缩小；中图上限大于原图，按原尺寸重新编码
+	want := []struct {
+		name string
+		w, h int
+	}{{"thumb", 160, 320}, {"medium", 200, 400}}
+	if len(res.Variants) != len(want) {
+		t.Fatalf("variants = %d, want %d", len(res.Variants), len(want))
+	}
+	for i, v := range res.Variants {
+		if v.Name != want[i].name || v.Width != want[i].w || v.Height != want[i].h || v.ContentType != "image/jpeg" || v.Ext != ".jpg" {
+			t.Errorf("variant %d = %s %dx%d %s %s, want %s %dx%d", i, v.Name, v.Width, v.Height, v.ContentType, v.Ext, want[i].name, want[i].w, want[i].h)
+		}
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(v.Data))
+		if err != nil || cfg.Width != v.Width || cfg.Height != v.Height {
+			t.Errorf("variant %s decodes to %dx%d (%v)", v.Name, cfg.Width, cfg.Height, err)
+		}
+		if bytes.Contains(v.Data, []byte("Exif\x00\x00")) {
+			t.Errorf("variant %s contains EXIF header", v.Name)
+		}
+	}
+}
+
+func TestProcessTransparentVariant(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 640, 480))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+	data, err := encodePNG(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := Process(data, []Spec{{Name: "thumb", MaxSide: 320}, {Name: "skipped"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ContentType != "image/png" || len(res.Variants) != 1 {
+		t.Fatalf("result = %s with %d variants", res.ContentType, len(res.Variants))
+	}
+	if v := res.Variants[0]; v.ContentType != "image/png" || v.Width != 320 || v.Height != 240 {
+		t.Errorf("variant = %s %dx%d, want image/png 320x240", v.ContentType, v.Width, v.Height)
+	}
+}
+
+// pngHeader 只有签名与 IHDR 的 PNG，足以让 DecodeConfig 读出尺寸
+func pngHeader(w, h uint32) []byte {
+	ihdr := []byte("IHDR")
+	ihdr = binary.BigEndian.AppendUint32(ihdr, w)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, h)
+	ihdr = append(ihdr, 8, 2, 0, 0, 0) // 8 位 RGB
+	out := []byte("\x89PNG\r\n\x1a\n")
+	out = binary.BigEndian.AppendUint32(out, 13)
+	out = append(out, ihdr...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(ihdr))
+}
+
+func TestProcessRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{name: "too many pixels", data: pngHeader(10000, 10000), want: ErrTooLarge},
+		{name: "plain text", data: []byte("hello, world"), want: ErrUnsupported},
+		{name: "html disguised", data: []byte("<html><img src=x></html>"), want: ErrUnsupported},
+		{name: "truncated jpeg", data: testJPEG(t, 64, 64)[:40], want: ErrUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Process(tt.data, DefaultVariants); !errors.Is(err, tt.want) {
+				t.Fatalf("Process error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	jpg := testJPEG(t, 8, 8)
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{name: "no exif", data: jpg, want: 1},
+		{name: "big endian", data: withSegment(jpg, exifSegment(binary.BigEndian, 6)), want: 6},
+		{name: "little endian", data: withSegment(jpg, exifSegment(binary.LittleEndian, 8)), want: 8},
+		{name: "out of range", data: withSegment(jpg, exifSegment(binary.BigEndian, 9)), want: 1},
+		{name: "truncated segment", data: withSegment(jpg, exifSegment(binary.BigEndian, 6))[:20], want: 1},
+		{name: "not jpeg", data: []byte("GIF89a"), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jpegOrientation(tt.data); got != tt.want {
+				t.Errorf("jpegOrientation = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// 2x1 图片：左红右蓝
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+	src.SetNRGBA(1, 0, color.NRGBA{B: 255, A: 255})
+	tests := []struct {
+		o       int
+		w, h    int
+		redX, y int
+	}{
+		{o: 1, w: 2, h: 1, redX: 0, y: 0},
+		{o: 2, w: 2, h: 1, redX: 1, y: 0},
+		{o: 3, w: 2, h: 1, redX: 1, y: 0},
+		{o: 4, w: 2, h: 1, redX: 0, y: 0},
+		{o: 5, w: 1, h: 2, redX: 0, y: 0},
+		{o: 6, w: 1, h: 2, redX: 0, y: 0},
+		{o: 7, w: 1, h: 2, redX: 0, y: 1},
+		{o: 8, w: 1, h: 2, redX: 0, y: 1},
+	}
+	for _, tt := range tests {
+		dst := orient(src, tt.o)
+		if b := dst.Bounds(); b.Dx() != tt.w || b.Dy() != tt.h {
+			t.Errorf("orient %d size = %v, want %dx%d", tt.o, b, tt.w, tt.h)
+			continue
+		}
+		if !isRed(dst.At(tt.redX, tt.y)) {
+			t.Errorf("orient %d: red pixel not at (%d,%d)", tt.o, tt.redX, tt.y)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package imaging
+
+import "image"
+
+// Fit 等比缩小到最长边不超过 maxSide（区域平均采样，只缩不放）
+func Fit(src *image.NRGBA, maxSide int) *image.NRGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw <= maxSide && sh <= maxSide {
+		return src
+	}
+	dw, dh := maxSide, maxSide
+	if sw >= sh {
+		dh = max(1, sh*maxSide/sw)
+	} else {
+		dw = max(1, sw*maxSide/sh)
+	}
+	return Resize(src, dw, dh)
+}
+
+// Resize 区域平均缩小到 dw x dh；每个目标像素取其覆盖的源像素按不透明度加权的平均值
+func Resize(src *image.NRGBA, dw, dh int) *image.NRGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*sh/dh, max((dy+1)*sh/dh, dy*sh/dh+1)
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := dx*sw/dw, max((dx+1)*sw/dw, dx*sw/dw+1)
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride:]
+				for x := x0; x < x1; x++ {
+					p := row[x*4 : x*4+4]
+					pa := uint64(p[3])
+					r += uint64(p[0]) * pa
+					g += uint64(p[1]) * pa
+					b += uint64(p[2]) * pa
+					a += pa
+					n++
+				}
+			}
+			o := dst.Pix[dy*dst.Stride+dx*4:]
+			if a > 0 {
+				o[0], o[1], o[2] = uint8(r/a), uint8(g/a), uint8(b/a)
+			}
+			o[3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,51 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTPProvider 本地替身：把推送以 JSON POST 到指定地址（开发环境可用任意 HTTP 服务接收查看）。
+// 对端返回 404/410 视为令牌失效，与 APNs/FCM 的语义一致
+type HTTPProvider struct {
+	endpoint string
+	client   *http.Client
+}
+
+func NewHTTPProvider(endpoint string, timeout time.Duration) *HTTPProvider {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &HTTPProvider{endpoint: endpoint, client: &http.Client{Timeout: timeout}}
+}
+
+func (p *HTTPProvider) Send(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if msg.CollapseKey != "" {
+		req.Header.Set("apns-collapse-id", msg.CollapseKey)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrInvalidToken
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("push: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
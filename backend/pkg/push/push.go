@@ -0,0 +1,33 @@
+package push
+
+import (
+	"context"
+	"errors"
+)
+
+// 设备平台
+const (
+	PlatformAPNs = "apns"
+	PlatformFCM  = "fcm"
+)
+
+// ErrInvalidToken 设备令牌已失效（卸载/重装等），调用方应删除该令牌
+var ErrInvalidToken = errors.New("push: invalid device token")
+
+// Message 推送消息，字段取 APNs 与 FCM 的公共子集
+type Message struct {
+	Platform string `json:"platform"`
+	Token    string `json:"token"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+	// Badge 应用角标数（APNs aps.badge / FCM notification_count）
+	Badge int64 `json:"badge"`
+	// CollapseKey 同一会话的多条推送在设备上只保留最新一条（APNs apns-collapse-id / FCM collapse_key）
+	CollapseKey string            `json:"collapse_key"`
+	Data        map[string]string `json:"data,omitempty"`
+}
+
+// Provider 推送通道（APNs / FCM 或本地替身）
+type Provider interface {
+	Send(ctx context.Context, msg *Message) error
+}
@@ -0,0 +1,43 @@
+package push
+
+import (
+	"context"
+	"sync"
+)
+
+// RecordingProvider 只记录不发送，用于测试与本地调试；InvalidTokens 中的令牌返回 ErrInvalidToken
+type RecordingProvider struct {
+	mu            sync.Mutex
+	sent          []Message
+	InvalidTokens map[string]bool
+}
+
+func NewRecordingProvider() *RecordingProvider {
+	return &RecordingProvider{InvalidTokens: make(map[string]bool)}
+}
+
+func (p *RecordingProvider) Send(_ context.Context, msg *Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.InvalidTokens[msg.Token] {
+		return ErrInvalidToken
+	}
+	p.sent = append(p.sent, *msg)
+	return nil
+}
+
+// Messages 已记录的推送（副本）
+func (p *RecordingProvider) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]Message, len(p.sent))
+	copy(out, p.sent)
+	return out
+}
+
+// Reset 清空记录
+func (p *RecordingProvider) Reset() {
+	p.mu.Lock()
+	p.sent = nil
+	p.mu.Unlock()
+}
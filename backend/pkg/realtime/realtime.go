@@ -0,0 +1,78 @@
+package realtime
+
+import (
+	"sync"
+	"time"
+)
+
+// Sink 实时投递通道（如 WebSocket Hub）。用户不在线时返回 false
+type Sink interface {
+	Deliver(userID uint, msg any) bool
+}
+
+// Emitter 业务服务推送事件用的最小接口
+type Emitter interface {
+	Emit(userID uint, event string, data any) bool
+}
+
+// OfflineHandler 用户不在线（所有 Sink 均未送达）时的兜底处理，如离线推送
+type OfflineHandler interface {
+	Offline(userID uint, msg any)
+}
+
+// Event 推送给客户端的事件结构，与聊天消息共用 type 字段区分
+type Event struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+	Ts   int64  `json:"ts"`
+}
+
+// Dispatcher 将事件分发给已挂载的 Sink；任一 Sink 投递成功即视为在线送达
+type Dispatcher struct {
+	mu      sync.RWMutex
+	sinks   []Sink
+	offline []OfflineHandler
+}
+
+func NewDispatcher() *Dispatcher { return &Dispatcher{} }
+
+// Attach 挂载投递通道（在路由初始化时由 Hub 调用）
+func (d *Dispatcher) Attach(s Sink) {
+	d.mu.Lock()
+	d.sinks = append(d.sinks, s)
+	d.mu.Unlock()
+}
+
+// OnOffline 注册离线兜底处理（应用初始化阶段调用）
+func (d *Dispatcher) OnOffline(h OfflineHandler) {
+	d.mu.Lock()
+	d.offline = append(d.offline, h)
+	d.mu.Unlock()
+}
+
+// Send 投递原始消息，未送达时交给离线处理
+func (d *Dispatcher) Send(userID uint, msg any) bool {
+	if d == nil || userID == 0 {
+		return false
+	}
+	d.mu.RLock()
+	sinks, offline := d.sinks, d.offline
+	d.mu.RUnlock()
+	delivered := false
+	for _, s := range sinks {
+		if s.Deliver(userID, msg) {
+			delivered = true
+		}
+	}
+	if !delivered {
+		for _, h := range offline {
+			h.Offline(userID, msg)
+		}
+	}
+	return delivered
+}
+
+// Emit 投递业务事件
+func (d *Dispatcher) Emit(userID uint, event string, data any) bool {
+	return d.Send(userID, Event{Type: event, Data: data, Ts: time.Now().Unix()})
+}
@@ -0,0 +1,97 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamd INSTREAM 每块大小，需小于 clamd 的 StreamMaxLength
+const chunkSize = 64 * 1024
+
+// ClamdScanner 通过 clamd 的 INSTREAM 命令扫描：
+// 发送 "zINSTREAM\0"，随后每块为 4 字节大端长度 + 数据，以长度 0 结束；
+// 应答形如 "stream: OK" 或 "stream: {特征名} FOUND"
+type ClamdScanner struct {
+	network string
+	addr    string
+	timeout time.Duration
+}
+
+// NewClamdScanner address 形如 tcp://127.0.0.1:3310 或 unix:///var/run/clamav/clamd.ctl（不带协议时按 tcp）
+func NewClamdScanner(address string, timeout time.Duration) *ClamdScanner {
+	network, addr := "tcp", address
+	if i := strings.Index(address, "://"); i >= 0 {
+		network, addr = address[:i], address[i+3:]
+	}
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &ClamdScanner{network: network, addr: addr, timeout: timeout}
+}
+
+func (s *ClamdScanner) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	var d net.Dialer
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	conn, err := d.DialContext(ctx, s.network, s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, rerr := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				// clamd 超过 StreamMaxLength 时会提前应答并断开，读取应答以返回具体原因
+				return s.reply(conn, err)
+			}
+		}
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			break
+		}
+		if rerr != nil {
+			return nil, rerr
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return s.reply(conn, nil)
+}
+
+// reply 读取并解析以 \0 结尾的应答
+func (s *ClamdScanner) reply(conn net.Conn, writeErr error) (*Result, error) {
+	data, err := io.ReadAll(io.LimitReader(conn, 4096))
+	if len(data) == 0 {
+		if writeErr == nil {
+			writeErr = err
+		}
+		return nil, fmt.Errorf("%w: no reply: %v", ErrUnavailable, writeErr)
+	}
+	return parseReply(string(bytes.TrimRight(data, "\x00\n")))
+}
+
+func parseReply(line string) (*Result, error) {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "stream:"))
+	switch {
+	case line == "OK":
+		return &Result{}, nil
+	case strings.HasSuffix(line, " FOUND"):
+		return &Result{Infected: true, Signature: strings.TrimSpace(strings.TrimSuffix(line, " FOUND"))}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnavailable, line)
+}
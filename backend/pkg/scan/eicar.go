@@ -0,0 +1,44 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// EICARSignature EICAR 标准测试文件，各杀毒引擎均将其识别为病毒
+const EICARSignature = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// EICARScanner 仅识别 EICAR 测试串的替身，用于离线开发与测试；命中时的特征名与 clamd 一致
+type EICARScanner struct{}
+
+func NewEICARScanner() *EICARScanner { return &EICARScanner{} }
+
+func (EICARScanner) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	pattern := []byte(EICARSignature)
+	buf := make([]byte, 32*1024)
+	// 保留上一块末尾 len(pattern)-1 字节，测试串跨块时也能命中
+	var tail []byte
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := r.Read(buf)
+		if n > 0 {
+			chunk := append(tail, buf[:n]...)
+			if bytes.Contains(chunk, pattern) {
+				return &Result{Infected: true, Signature: "Eicar-Test-Signature"}, nil
+			}
+			if keep := len(pattern) - 1; len(chunk) > keep {
+				chunk = chunk[len(chunk)-keep:]
+			}
+			tail = append(tail[:0:0], chunk...)
+		}
+		if err == io.EOF {
+			return &Result{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
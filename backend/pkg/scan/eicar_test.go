@@ -0,0 +1,82 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// splitReader 第一次读取只返回前 n 字节，模拟测试串落在两次读取之间
+type splitReader struct {
+	data []byte
+	n    int
+}
+
+func (r *splitReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := len(r.data)
+	if r.n > 0 && r.n < n {
+		n = r.n
+	}
+	n = copy(p, r.data[:n])
+	r.data = r.data[n:]
+	r.n = 0
+	return n, nil
+}
+
+func TestEICARScanner(t *testing.T) {
+	sig := []byte(EICARSignature)
+	big := bytes.Repeat([]byte("a"), 32*1024-10)
+	// 测试串跨越扫描器 32KiB 读缓冲的边界
+	acrossBuffer := append(append([]byte{}, big...), sig...)
+
+	tests := []struct {
+		name     string
+		r        io.Reader
+		infected bool
+	}{
+		{name: "empty", r: strings.NewReader("")},
+		{name: "clean", r: strings.NewReader("hello world")},
+		{name: "exact signature", r: bytes.NewReader(sig), infected: true},
+		{name: "embedded signature", r: strings.NewReader("prefix " + EICARSignature + " suffix"), infected: true},
+		{name: "truncated signature", r: bytes.NewReader(sig[:len(sig)-1])},
+		{name: "split across reads", r: &splitReader{data: append([]byte("xx"), sig...), n: 20}, infected: true},
+		{name: "split after first byte", r: &splitReader{data: sig, n: 1}, infected: true},
+		{name: "one byte per read", r: iotest.OneByteReader(bytes.NewReader(sig)), infected: true},
+		{name: "across read buffer", r: bytes.NewReader(acrossBuffer), infected: true},
+		{name: "large clean", r: bytes.NewReader(bytes.Repeat([]byte("X5O!P%@AP"), 10000))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := NewEICARScanner().Scan(context.Background(), tt.r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Infected != tt.infected {
+				t.Fatalf("Infected = %v, want %v", res.Infected, tt.infected)
+			}
+			if tt.infected && res.Signature != "Eicar-Test-Signature" {
+				t.Errorf("Signature = %q", res.Signature)
+			}
+		})
+	}
+}
+
+func TestEICARScannerErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewEICARScanner().Scan(ctx, strings.NewReader("hello")); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled scan = %v, want %v", err, context.Canceled)
+	}
+
+	readErr := errors.New("boom")
+	if _, err := NewEICARScanner().Scan(context.Background(), iotest.ErrReader(readErr)); !errors.Is(err, readErr) {
+		t.Errorf("failing reader = %v, want %v", err, readErr)
+	}
+}
@@ -0,0 +1,23 @@
+// Package scan 上传文件病毒扫描：ClamAV clamd（INSTREAM 协议）与离线测试用的 EICAR 替身
+package scan
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrUnavailable 扫描引擎不可用（连接失败、超时或返回错误），调用方应拒绝上传而不是放行
+var ErrUnavailable = errors.New("scan: scanner unavailable")
+
+// Result 扫描结果
+type Result struct {
+	Infected bool
+	// Signature 命中的病毒特征名（如 Eicar-Test-Signature）
+	Signature string
+}
+
+// Scanner 扫描引擎
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (*Result, error)
+}
@@ -0,0 +1,247 @@
+// Package videoprobe 纯 Go 解析 MP4/MOV（ISO BMFF / QuickTime）容器头，读取时长与画面尺寸，不解码视频帧
+package videoprobe
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrUnsupported  = errors.New("unsupported video container")
+	ErrNoVideoTrack = errors.New("no video track")
+)
+
+// maxBoxes 单个文件最多解析的 box 数，防止构造的畸形文件拖垮解析
+const maxBoxes = 10000
+
+// Info 探测结果
+type Info struct {
+	ContentType string // video/mp4 或 video/quicktime
+	Duration    time.Duration
+	// Width / Height 按旋转矩阵换算后的显示尺寸
+	Width    int
+	Height   int
+	Rotation int // 0 / 90 / 180 / 270
+	// FastStart moov 位于 mdat 之前，可边下边播
+	FastStart bool
+}
+
+type box struct {
+	typ  string
+	off  int64 // 内容起始偏移（不含头部）
+	size int64 // 内容长度
+}
+
+type parser struct {
+	r io.ReaderAt
+	n int
+}
+
+// Probe 解析 r 中长度为 size 的 MP4/MOV 文件
+func Probe(r io.ReaderAt, size int64) (*Info, error) {
+	p := &parser{r: r}
+	top, err := p.boxes(0, size)
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{ContentType: "video/mp4"}
+	var moov *box
+	sawFtyp, sawMdat := false, false
+	for i := range top {
+		switch top[i].typ {
+		case "ftyp":
+			d, err := p.read(top[i], 4)
+			if err != nil {
+				return nil, err
+			}
+			sawFtyp = true
+			if string(d) == "qt  " {
+				info.ContentType = "video/quicktime"
+			}
+		case "moov":
+			if moov == nil {
+				moov = &top[i]
+				info.FastStart = !sawMdat
+			}
+		case "mdat":
+			sawMdat = true
+		}
+	}
+	if moov == nil {
+		return nil, ErrUnsupported
+	}
+	// 早期 QuickTime 文件没有 ftyp
+	if !sawFtyp {
+		info.ContentType = "video/quicktime"
+	}
+
+	children, err := p.boxes(moov.off, moov.off+moov.size)
+	if err != nil {
+		return nil, err
+	}
+	sawMvhd, sawVideo := false, false
+	for _, c := range children {
+		switch c.typ {
+		case "mvhd":
+			if info.Duration, err = p.movieDuration(c); err != nil {
+				return nil, err
+			}
+			sawMvhd = true
+		case "trak":
+			if sawVideo {
+				continue
+			}
+			w, h, rot, ok, err := p.videoTrack(c)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				sawVideo = true
+				info.Width, info.Height, info.Rotation = w, h, rot
+			}
+		}
+	}
+	if !sawMvhd {
+		return nil, ErrUnsupported
+	}
+	if !sawVideo {
+		return nil, ErrNoVideoTrack
+	}
+	return info, nil
+}
+
+// boxes 列出 [start, end) 范围内的同级 box
+func (p *parser) boxes(start, end int64) ([]box, error) {
+	var out []box
+	for off := start; off+8 <= end; {
+		p.n++
+		if p.n > maxBoxes {
+			return nil, ErrUnsupported
+		}
+		var hdr [16]byte
+		if _, err := p.r.ReadAt(hdr[:8], off); err != nil {
+			return nil, ErrUnsupported
+		}
+		size := int64(binary.BigEndian.Uint32(hdr[:4]))
+		hlen := int64(8)
+		switch size {
+		case 0: // 延伸到末尾
+			size = end - off
+		case 1: // 64 位长度
+			if _, err := p.r.ReadAt(hdr[8:16], off+8); err != nil {
+				return nil, ErrUnsupported
+			}
+			size = int64(binary.BigEndian.Uint64(hdr[8:16]))
+			hlen = 16
+		}
+		if size < hlen || size > end-off {
+			return nil, ErrUnsupported
+		}
+		out = append(out, box{typ: string(hdr[4:8]), off: off + hlen, size: size - hlen})
+		off += size
+	}
+	return out, nil
+}
+
+// read 读取 box 内容的前 n 字节
+func (p *parser) read(b box, n int) ([]byte, error) {
+	if b.size < int64(n) {
+		return nil, ErrUnsupported
+	}
+	buf := make([]byte, n)
+	if _, err := p.r.ReadAt(buf, b.off); err != nil {
+		return nil, ErrUnsupported
+	}
+	return buf, nil
+}
+
+// movieDuration 解析 mvhd 中的时间刻度与时长
+func (p *parser) movieDuration(b box) (time.Duration, error) {
+	d, err := p.read(b, 20)
+	if err != nil {
+		return 0, err
+	}
+	var scale, dur uint64
+	if d[0] == 1 {
+		if d, err = p.read(b, 32); err != nil {
+			return 0, err
+		}
+		scale, dur = uint64(binary.BigEndian.Uint32(d[20:24])), binary.BigEndian.Uint64(d[24:32])
+	} else {
+		scale, dur = uint64(binary.BigEndian.Uint32(d[12:16])), uint64(binary.BigEndian.Uint32(d[16:20]))
+		if dur == 0xFFFFFFFF { // 时长未知
+			dur = 0
+		}
+	}
+	if scale == 0 {
+		return 0, ErrUnsupported
+	}
+	return time.Duration(float64(dur) / float64(scale) * float64(time.Second)), nil
+}
+
+// videoTrack 若 trak 为视频轨，返回显示尺寸与旋转角度
+func (p *parser) videoTrack(trak box) (w, h, rot int, ok bool, err error) {
+	children, err := p.boxes(trak.off, trak.off+trak.size)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	var tkhd *box
+	isVideo := false
+	for i, c := range children {
+		switch c.typ {
+		case "tkhd":
+			tkhd = &children[i]
+		case "mdia":
+			sub, err := p.boxes(c.off, c.off+c.size)
+			if err != nil {
+				return 0, 0, 0, false, err
+			}
+			for _, s := range sub {
+				if s.typ != "hdlr" {
+					continue
+				}
+				d, err := p.read(s, 12)
+				if err != nil {
+					return 0, 0, 0, false, err
+				}
+				isVideo = string(d[8:12]) == "vide"
+			}
+		}
+	}
+	if !isVideo || tkhd == nil {
+		return 0, 0, 0, false, nil
+	}
+
+	// 矩阵与宽高的偏移随版本不同（v1 的时间字段为 64 位）
+	matrixAt := 40
+	d, err := p.read(*tkhd, 84)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	if d[0] == 1 {
+		matrixAt = 52
+		if d, err = p.read(*tkhd, 96); err != nil {
+			return 0, 0, 0, false, err
+		}
+	}
+	a := int32(binary.BigEndian.Uint32(d[matrixAt : matrixAt+4]))
+	b := int32(binary.BigEndian.Uint32(d[matrixAt+4 : matrixAt+8]))
+	const one = 0x10000 // 16.16 定点数的 1
+	switch {
+	case a == 0 && b == one:
+		rot = 90
+	case a == -one && b == 0:
+		rot = 180
+	case a == 0 && b == -one:
+		rot = 270
+	}
+	// 宽高为 16.16 定点数
+	w = int(binary.BigEndian.Uint32(d[matrixAt+36:matrixAt+40]) >> 16)
+	h = int(binary.BigEndian.Uint32(d[matrixAt+40:matrixAt+44]) >> 16)
+	if rot == 90 || rot == 270 {
+		w, h = h, w
+	}
+	return w, h, rot, true, nil
+}
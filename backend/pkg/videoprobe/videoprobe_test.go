@@ -0,0 +1,194 @@
+package videoprobe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+func u16(v uint16) []byte { return binary.BigEndian.AppendUint16(nil, v) }
+func u32(v uint32) []byte { return binary.BigEndian.AppendUint32(nil, v) }
+func u64(v uint64) []byte { return binary.BigEndian.AppendUint64(nil, v) }
+
+func cat(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+// bx 32 位长度的 box
+func bx(typ string, payload ...[]byte) []byte {
+	body := cat(payload...)
+	return cat(u32(uint32(8+len(body))), []byte(typ), body)
+}
+
+// bx64 64 位长度的 box（size 字段为 1）
+func bx64(typ string, payload ...[]byte) []byte {
+	body := cat(payload...)
+	return cat(u32(1), []byte(typ), u64(uint64(16+len(body))), body)
+}
+
+// bxToEnd 延伸到文件末尾的 box（size 字段为 0），只能放在最后
+func bxToEnd(typ string, payload ...[]byte) []byte {
+	return cat(u32(0), []byte(typ), cat(payload...))
+}
+
+func ftyp(brand string) []byte { return bx("ftyp", []byte(brand), u32(0)) }
+
+func mvhd(version byte, scale uint32, dur uint64) []byte {
+	if version == 1 {
+		return bx("mvhd", []byte{1, 0, 0, 0}, u64(0), u64(0), u32(scale), u64(dur), make([]byte, 80))
+	}
+	return bx("mvhd", []byte{0, 0, 0, 0}, u32(0), u32(0), u32(scale), u32(uint32(dur)), make([]byte, 80))
+}
+
+// matrix 按旋转角度生成 tkhd 的 3x3 变换矩阵（16.16 / 2.30 定点数）
+func matrix(rot int) []byte {
+	const one = 0x10000
+	a, b, c, d := int32(one), int32(0), int32(0), int32(one)
+	switch rot {
+	case 90:
+		a, b, c, d = 0, one, -one, 0
+	case 180:
+		a, d = -one, -one
+	case 270:
+		a, b, c, d = 0, -one, one, 0
+	}
+	return cat(u32(uint32(a)), u32(uint32(b)), u32(0), u32(uint32(c)), u32(uint32(d)), u32(0), u32(0), u32(0), u32(0x40000000))
+}
+
+func tkhd(version byte, w, h uint32, rot int) []byte {
+	var head []byte
+	if version == 1 {
+		head = cat([]byte{1, 0, 0, 7}, u64(0), u64(0), u32(1), u32(0), u64(0))
+	} else {
+		head = cat([]byte{0, 0, 0, 7}, u32(0), u32(0), u32(1), u32(0), u32(0))
+	}
+	return bx("tkhd", head, make([]byte, 8), u16(0), u16(0), u16(0), u16(0), matrix(rot), u32(w<<16), u32(h<<16))
+}
+
+func trak(version byte, handler string, w, h uint32, rot int) []byte {
+	hdlr := bx("hdlr", u32(0), u32(0), []byte(handler), make([]byte, 12))
+	return bx("trak", tkhd(version, w, h, rot), bx("mdia", bx("mdhd", make([]byte, 24)), hdlr))
+}
+
+func moov(version byte, rot int) []byte {
+	return bx("moov", mvhd(version, 1000, 2500), trak(version, "vide", 1920, 1080, rot))
+}
+
+var mdat = bx("mdat", make([]byte, 32))
+
+func probe(data []byte) (*Info, error) {
+	return Probe(bytes.NewReader(data), int64(len(data)))
+}
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		want   Info
+		errIs  error
+		anyErr bool
+	}{
+		{
+			name: "mp4 v0 fast start",
+			data: cat(ftyp("isom"), moov(0, 0), mdat),
+			want: Info{ContentType: "video/mp4", Duration: 2500 * time.Millisecond, Width: 1920, Height: 1080, FastStart: true},
+		},
+		{
+			name: "mp4 v1 headers",
+			data: cat(ftyp("mp42"), mdat, bx("moov", mvhd(1, 600, 6000), trak(1, "vide", 1280, 720, 0))),
+			want: Info{ContentType: "video/mp4", Duration: 10 * time.Second, Width: 1280, Height: 720},
+		},
+		{
+			name: "quicktime rotated 90",
+			data: cat(ftyp("qt  "), moov(0, 90), mdat),
+			want: Info{ContentType: "video/quicktime", Duration: 2500 * time.Millisecond, Width: 1080, Height: 1920, Rotation: 90, FastStart: true},
+		},
+		{
+			name: "rotated 180",
+			data: cat(ftyp("isom"), moov(0, 180), mdat),
+			want: Info{ContentType: "video/mp4", Duration: 2500 * time.Millisecond, Width: 1920, Height: 1080, Rotation: 180, FastStart: true},
+		},
+		{
+			name: "v1 rotated 270",
+			data: cat(ftyp("isom"), moov(1, 270), mdat),
+			want: Info{ContentType: "video/mp4", Duration: 2500 * time.Millisecond, Width: 1080, Height: 1920, Rotation: 270, FastStart: true},
+		},
+		{
+			name: "no ftyp is quicktime",
+			data: cat(mdat, moov(0, 0)),
+			want: Info{ContentType: "video/quicktime", Duration: 2500 * time.Millisecond, Width: 1920, Height: 1080},
+		},
+		{
+			name: "64-bit sizes and box to end of file",
+			data: cat(ftyp("isom"), bx64("moov", mvhd(0, 1000, 2500), trak(0, "vide", 640, 480, 0)), bx64("free"), bxToEnd("mdat", make([]byte, 100))),
+			want: Info{ContentType: "video/mp4", Duration: 2500 * time.Millisecond, Width: 640, Height: 480, FastStart: true},
+		},
+		{
+			name: "audio track skipped before video",
+			data: cat(ftyp("isom"), bx("moov", mvhd(0, 1000, 2500), trak(0, "soun", 0, 0, 0), trak(0, "vide", 320, 240, 0)), mdat),
+			want: Info{ContentType: "video/mp4", Duration: 2500 * time.Millisecond, Width: 320, Height: 240, FastStart: true},
+		},
+		{
+			name: "unknown duration",
+			data: cat(ftyp("isom"), bx("moov", mvhd(0, 1000, 0xFFFFFFFF), trak(0, "vide", 320, 240, 0))),
+			want: Info{ContentType: "video/mp4", Width: 320, Height: 240, FastStart: true},
+		},
+		{name: "audio only", data: cat(ftyp("isom"), bx("moov", mvhd(0, 1000, 2500), trak(0, "soun", 0, 0, 0))), errIs: ErrNoVideoTrack},
+		{name: "empty", data: nil, errIs: ErrUnsupported},
+		{name: "no moov", data: cat(ftyp("isom"), mdat), errIs: ErrUnsupported},
+		{name: "no mvhd", data: cat(ftyp("isom"), bx("moov", trak(0, "vide", 320, 240, 0))), errIs: ErrUnsupported},
+		{name: "zero timescale", data: cat(ftyp("isom"), bx("moov", mvhd(0, 0, 2500), trak(0, "vide", 320, 240, 0))), errIs: ErrUnsupported},
+		{name: "short mvhd", data: cat(ftyp("isom"), bx("moov", bx("mvhd", make([]byte, 10)))), errIs: ErrUnsupported},
+		{name: "short tkhd", data: cat(ftyp("isom"), bx("moov", mvhd(0, 1000, 2500), bx("trak", bx("tkhd", make([]byte, 40)),
+			bx("mdia", bx("hdlr", u32(0), u32(0), []byte("vide")))))), errIs: ErrUnsupported},
+		{name: "box smaller than header", data: cat(ftyp("isom"), u32(4), []byte("free")), errIs: ErrUnsupported},
+		{name: "box past end of file", data: cat(ftyp("isom"), u32(1000), []byte("moov"), make([]byte, 20)), errIs: ErrUnsupported},
+		{name: "child overlaps parent end", data: cat(ftyp("isom"), u32(24), []byte("moov"), u32(64), []byte("mvhd"), make([]byte, 8)), errIs: ErrUnsupported},
+		{name: "truncated 64-bit size", data: cat(ftyp("isom"), u32(1), []byte("moov"), u32(0)), errIs: ErrUnsupported},
+		{name: "huge 64-bit size", data: cat(ftyp("isom"), u32(1), []byte("moov"), u64(1<<63)), errIs: ErrUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := probe(tt.data)
+			if tt.errIs != nil {
+				if !errors.Is(err, tt.errIs) {
+					t.Fatalf("Probe error = %v, want %v", err, tt.errIs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *info != tt.want {
+				t.Errorf("Probe = %+v, want %+v", *info, tt.want)
+			}
+		})
+	}
+}
+
+// 任意位置截断都应返回 ErrUnsupported 而不是 panic
+func TestProbeTruncated(t *testing.T) {
+	full := cat(ftyp("isom"), mdat, moov(1, 90))
+	if _, err := probe(full); err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < len(full); n++ {
+		if _, err := probe(full[:n]); !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("Probe(%d of %d bytes) = %v, want %v", n, len(full), err, ErrUnsupported)
+		}
+	}
+}
+
+func TestProbeMaxBoxes(t *testing.T) {
+	// 合法文件本身有 ftyp / mdat / moov / mvhd / trak / tkhd / mdia / mdhd / hdlr 共 9 个 box
+	const own = 9
+	file := cat(ftyp("isom"), moov(0, 0), mdat)
+	padding := func(n int) []byte { return bytes.Repeat(bx("free"), n) }
+
+	if _, err := probe(cat(padding(maxBoxes-own), file)); err != nil {
+		t.Fatalf("Probe at the box limit = %v", err)
+	}
+	if _, err := probe(cat(padding(maxBoxes-own+1), file)); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Probe over the box limit = %v, want %v", err, ErrUnsupported)
+	}
+}